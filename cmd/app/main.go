@@ -2,6 +2,7 @@ package main
 
 import (
 	"log"
+	_ "time/tzdata" // hotel timezones resolve even without zoneinfo on the host
 
 	"github.com/Avazbek-02/Online-Hotel-System/config"
 	"github.com/Avazbek-02/Online-Hotel-System/internal/app"
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
@@ -9,14 +10,17 @@ import (
 type (
 	// Config -.
 	Config struct {
-		App   `yaml:"app"`
-		HTTP  `yaml:"http"`
-		Log   `yaml:"logger"`
-		PG    `yaml:"postgres"`
-		JWT   `yaml:"jwt"`
-		Redis `yaml:"redis"`
-		Gmail `yaml:"gmail"`
-		MinIO `yaml:"minio"`
+		App     `yaml:"app"`
+		HTTP    `yaml:"http"`
+		Log     `yaml:"logger"`
+		PG      `yaml:"postgres"`
+		JWT     `yaml:"jwt"`
+		Redis   `yaml:"redis"`
+		Gmail   `yaml:"gmail"`
+		MinIO   `yaml:"minio"`
+		Payment `yaml:"payment"`
+		Storage `yaml:"storage"`
+		RBAC    `yaml:"rbac"`
 	}
 
 	// App -.
@@ -28,6 +32,9 @@ type (
 	// HTTP -.
 	HTTP struct {
 		Port string `env-required:"true" yaml:"port" env:"HTTP_PORT"`
+		// TrustedProxies are the addresses or CIDRs of the proxies allowed to set
+		// X-Forwarded-For. With none, the client IP is the address of the connection.
+		TrustedProxies []string `yaml:"trusted_proxies" env:"HTTP_TRUSTED_PROXIES" env-separator:","`
 	}
 
 	// Log -.
@@ -59,10 +66,42 @@ type (
 		Port      string `env-required:"true" yaml:"port" env:"SMTP_PORT"`
 	}
 	MinIO struct {
-		MinioUrl      string `env-required:"true" yaml:"miniourl" env:"MINIOURL"`
-		MinioUser      string `env-required:"true" yaml:"miniouser" env:"MINIOUSER"`
-		MinIOSecredKey string `env-required:"true" yaml:"miniosecredkey" env:"MINIOSECREDKEY"`
-		MinIOBucketName string `env-required:"true" yaml:"minibucketname" env:"MINIOBUCKETNAME"`
+		MinioUrl        string `yaml:"miniourl" env:"MINIOURL"`
+		MinioUser       string `yaml:"miniouser" env:"MINIOUSER"`
+		MinIOSecredKey  string `yaml:"miniosecredkey" env:"MINIOSECREDKEY"`
+		MinIOBucketName string `yaml:"minibucketname" env:"MINIOBUCKETNAME"`
+		// Base URL objects are served from, e.g. a CDN in front of the bucket. Defaults to the bucket on MinioUrl.
+		MinIOPublicURL     string        `yaml:"miniopublicurl" env:"MINIOPUBLICURL"`
+		MinIOUseSSL        bool          `yaml:"miniousessl" env:"MINIOUSESSL"`
+		MinIOPresignExpiry time.Duration `yaml:"miniopresignexpiry" env:"MINIOPRESIGNEXPIRY" env-default:"15m"`
+		// Let anyone read objects without credentials. Otherwise objects are served through
+		// the media route, which redirects to presigned URLs.
+		MinIOPublicRead bool `yaml:"miniopublicread" env:"MINIOPUBLICREAD" env-default:"false"`
+	}
+
+	// Storage -.
+	Storage struct {
+		Backend      string `yaml:"backend"        env:"STORAGE_BACKEND"        env-default:"minio"` // minio, local or memory
+		LocalDir     string `yaml:"local_dir"      env:"STORAGE_LOCAL_DIR"      env-default:"./uploads"`
+		LocalPath    string `yaml:"local_path"     env:"STORAGE_LOCAL_PATH"     env-default:"/storage"` // Route the local backend is served on
+		LocalBaseURL string `yaml:"local_base_url" env:"STORAGE_LOCAL_BASE_URL" env-default:"http://localhost:8080/storage"`
+		MediaBaseURL string `yaml:"media_base_url" env:"STORAGE_MEDIA_BASE_URL" env-default:"http://localhost:8080/v1/media"` // Media route, serves objects of a private bucket
+	}
+
+	// RBAC -.
+	RBAC struct {
+		ModelPath string `yaml:"model_path" env:"RBAC_MODEL_PATH" env-default:"config/rbac.conf"`
+		// Instances are told about policy changes over Redis, this is the fallback if a message is lost.
+		ReloadInterval time.Duration `yaml:"reload_interval" env:"RBAC_RELOAD_INTERVAL" env-default:"5m"`
+	}
+
+	// Payment -.
+	Payment struct {
+		Provider      string        `env-required:"true" yaml:"provider"       env:"PAYMENT_PROVIDER"`
+		WebhookSecret string        `env-required:"true" yaml:"webhook_secret" env:"PAYMENT_WEBHOOK_SECRET"`
+		WebhookURL    string        `yaml:"webhook_url"   env:"PAYMENT_WEBHOOK_URL"`
+		WebhookDelay  time.Duration `yaml:"webhook_delay" env:"PAYMENT_WEBHOOK_DELAY"`
+		Timeout       time.Duration `yaml:"timeout"       env:"PAYMENT_TIMEOUT"`
 	}
 )
 
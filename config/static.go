@@ -3,22 +3,31 @@ package config
 import "time"
 
 var (
-	ErrorInvalidRequest = "INVALID_REQUEST"
-	ErrorInvalidToken   = "INVALID_TOKEN"
-	ErrorInvalidUser    = "INVALID_USER"
-	ErrorInvalidPass    = "INVALID_PASS"
-	ErrorInvalidEmail   = "INVALID_EMAIL"
-	ErrorInvalidPhone   = "INVALID_PHONE"
-	ErrorSessionExpired = "SESSION_EXPIRED"
-	ErrorInternalServer = "INTERNAL_SERVER"
-	ErrorNotFound       = "NOT_FOUND"
-	ErrorUnauthorized   = "UNAUTHORIZED"
-	ErrorForbidden      = "FORBIDDEN"
-	ErrorConflict       = "CONFLICT"
-	ErrorBadRequest     = "BAD_REQUEST"
-	ErrorDuplicateKey   = "DUPLICATE_KEY"
+	ErrorInvalidRequest  = "INVALID_REQUEST"
+	ErrorInvalidToken    = "INVALID_TOKEN"
+	ErrorInvalidUser     = "INVALID_USER"
+	ErrorInvalidPass     = "INVALID_PASS"
+	ErrorInvalidEmail    = "INVALID_EMAIL"
+	ErrorInvalidPhone    = "INVALID_PHONE"
+	ErrorSessionExpired  = "SESSION_EXPIRED"
+	ErrorInternalServer  = "INTERNAL_SERVER"
+	ErrorNotFound        = "NOT_FOUND"
+	ErrorUnauthorized    = "UNAUTHORIZED"
+	ErrorForbidden       = "FORBIDDEN"
+	ErrorConflict        = "CONFLICT"
+	ErrorBadRequest      = "BAD_REQUEST"
+	ErrorDuplicateKey    = "DUPLICATE_KEY"
+	ErrorRoomUnavailable = "ROOM_UNAVAILABLE"
+	ErrorPaymentDeclined = "PAYMENT_DECLINED"
+	ErrorPaymentTimeout  = "PAYMENT_TIMEOUT"
+	ErrorNotImplemented  = "NOT_IMPLEMENTED"
+	ErrorTokenReused     = "TOKEN_REUSED"
+	ErrorTooManyRequests = "TOO_MANY_REQUESTS"
+
+	ErrorIdempotencyMismatch = "IDEMPOTENCY_KEY_REUSED"
 )
 
 var (
-	TokenExpireTime = 24 * time.Hour * 7 // 7 days
+	AccessTokenExpireTime = 15 * time.Minute
+	TokenExpireTime       = 24 * time.Hour * 7 // 7 days, lifetime of a refresh token and its session
 )
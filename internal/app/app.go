@@ -1,6 +1,7 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/signal"
@@ -14,8 +15,12 @@ import (
 	minio "github.com/Avazbek-02/Online-Hotel-System/pkg/MinIO"
 	"github.com/Avazbek-02/Online-Hotel-System/pkg/httpserver"
 	"github.com/Avazbek-02/Online-Hotel-System/pkg/logger"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/otp"
 	"github.com/Avazbek-02/Online-Hotel-System/pkg/postgres"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/rbac"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/storage"
 	rediscache "github.com/golanguzb70/redis-cache"
+	"github.com/redis/go-redis/v9"
 )
 
 func Run(cfg *config.Config) {
@@ -32,7 +37,7 @@ func Run(cfg *config.Config) {
 	useCase := usecase.New(pg, cfg, l)
 
 	// redis
-	redis, err := rediscache.New(&rediscache.Config{
+	cache, err := rediscache.New(&rediscache.Config{
 		RedisHost: cfg.Redis.RedisHost,
 		RedisPort: cfg.Redis.RedisPort,
 	})
@@ -40,14 +45,39 @@ func Run(cfg *config.Config) {
 		l.Fatal(fmt.Errorf("app - Run - rediscache.New: %w", err))
 	}
 
+	// one-time codes need atomic counters and policy changes pub/sub, which the cache client doesn't expose
+	rdb := redis.NewClient(&redis.Options{
+		Addr: fmt.Sprintf("%s:%d", cfg.Redis.RedisHost, cfg.Redis.RedisPort),
+	})
+	defer rdb.Close()
+
+	otpStore := otp.New(rdb)
+
+	// access policy
+	enforcer, err := rbac.New(cfg.RBAC.ModelPath, useCase.PolicyRepo, rdb,
+		rbac.ReloadInterval(cfg.RBAC.ReloadInterval),
+		rbac.OnError(func(err error) { l.Error(err) }),
+	)
+	if err != nil {
+		l.Fatal(fmt.Errorf("app - Run - rbac.New: %w", err))
+	}
+
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	defer stopWatch()
+	go enforcer.Watch(watchCtx)
+
 	// HTTP Server
 	handler := gin.New()
-	//minio
-	minio, err := minio.MinIOConnect(cfg)
+	// rate limits and lockouts go by client IP, so only trusted proxies may forward it
+	if err = handler.SetTrustedProxies(cfg.HTTP.TrustedProxies); err != nil {
+		l.Fatal(fmt.Errorf("app - Run - SetTrustedProxies: %w", err))
+	}
+	// storage
+	store, err := newStorage(cfg)
 	if err != nil {
-		l.Fatal(fmt.Errorf("app - Run - MinIo.New: %w", err))
+		l.Fatal(fmt.Errorf("app - Run - newStorage: %w", err))
 	}
-	v1.NewRouter(handler, l, cfg, useCase, redis, minio)
+	v1.NewRouter(handler, l, cfg, useCase, cache, store, otpStore, enforcer)
 
 	httpServer := httpserver.New(handler, httpserver.Port(cfg.HTTP.Port))
 
@@ -70,3 +100,27 @@ func Run(cfg *config.Config) {
 		l.Error(fmt.Errorf("app - Run - httpServer.Shutdown: %w", err))
 	}
 }
+
+// newStorage opens the file storage backend selected in the config.
+func newStorage(cfg *config.Config) (storage.Storage, error) {
+	switch cfg.Storage.Backend {
+	case "", "minio":
+		if cfg.MinioUrl == "" || cfg.MinIOBucketName == "" {
+			return nil, fmt.Errorf("minio backend requires MINIOURL and MINIOBUCKETNAME")
+		}
+
+		var opts []minio.Option
+		if cfg.MinIOPublicRead {
+			opts = append(opts, minio.PublicRead())
+		} else {
+			opts = append(opts, minio.MediaURL(cfg.Storage.MediaBaseURL))
+		}
+		return minio.MinIOConnect(cfg, opts...)
+	case "local":
+		return storage.NewLocal(cfg.Storage.LocalDir, cfg.Storage.LocalBaseURL)
+	case "memory":
+		return storage.NewMemory(cfg.Storage.LocalBaseURL), nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.Storage.Backend)
+	}
+}
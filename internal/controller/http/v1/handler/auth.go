@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -10,14 +11,92 @@ import (
 	"github.com/Avazbek-02/Online-Hotel-System/pkg/etc"
 	"github.com/Avazbek-02/Online-Hotel-System/pkg/hash"
 	"github.com/Avazbek-02/Online-Hotel-System/pkg/jwt"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/otp"
 	"github.com/gin-gonic/gin"
 )
 
+// startSession opens a session for a user who just signed in and issues its first
+// access and refresh token. twoFactorVerified records that the sign-in passed a second
+// factor, which refreshing an admin's tokens depends on.
+func (h *Handler) startSession(ctx *gin.Context, user entity.User, platform string, twoFactorVerified bool) (entity.Session, entity.TokenResponse, error) {
+	refreshToken, token, err := newRefreshToken()
+	if err != nil {
+		return entity.Session{}, entity.TokenResponse{}, err
+	}
+
+	session, err := h.UseCase.SessionRepo.Create(ctx, entity.Session{
+		UserID:            user.ID,
+		IPAddress:         ctx.ClientIP(),
+		ExpiresAt:         token.ExpiresAt,
+		UserAgent:         ctx.Request.UserAgent(),
+		IsActive:          true,
+		LastActiveAt:      time.Now().UTC().Format(time.RFC3339),
+		Platform:          platform,
+		TwoFactorVerified: twoFactorVerified,
+	})
+	if err != nil {
+		return entity.Session{}, entity.TokenResponse{}, err
+	}
+
+	token.SessionID = session.ID
+	if _, err = h.UseCase.SessionRepo.CreateRefreshToken(ctx, token); err != nil {
+		return entity.Session{}, entity.TokenResponse{}, err
+	}
+
+	accessToken, expiresAt, err := h.accessToken(user, session)
+	if err != nil {
+		return entity.Session{}, entity.TokenResponse{}, err
+	}
+
+	return session, entity.TokenResponse{
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  expiresAt.Format(time.RFC3339),
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: token.ExpiresAt,
+	}, nil
+}
+
+// accessToken signs a short-lived access token for a session.
+func (h *Handler) accessToken(user entity.User, session entity.Session) (string, time.Time, error) {
+	now := time.Now().UTC()
+	expiresAt := now.Add(config.AccessTokenExpireTime)
+
+	token, err := jwt.GenerateJWT(map[string]interface{}{
+		"sub":        user.ID,
+		"user_role":  user.UserRole,
+		"user_type":  user.UserType,
+		"platform":   session.Platform,
+		"session_id": session.ID,
+		"email":      user.Email,
+		"iat":        now.Unix(),
+		"exp":        expiresAt.Unix(),
+	}, h.Config.JWT.Secret)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return token, expiresAt, nil
+}
+
+// newRefreshToken generates a refresh token. Only its hash is stored, the token itself
+// is handed to the client once.
+func newRefreshToken() (string, entity.RefreshToken, error) {
+	token, err := etc.GenerateToken()
+	if err != nil {
+		return "", entity.RefreshToken{}, err
+	}
+
+	return token, entity.RefreshToken{
+		TokenHash: hash.HashToken(token),
+		ExpiresAt: time.Now().UTC().Add(config.TokenExpireTime).Format(time.RFC3339),
+	}, nil
+}
 
 // Login godoc
 // @Router /auth/login [post]
 // @Summary Login
-// @Description Login
+// @Description Login. When two-factor authentication is enabled, or mandatory for the role, a challenge is returned
+// @Description instead of tokens and the login is finished with /auth/login/2fa.
 // @Tags auth
 // @Accept  json
 // @Produce  json
@@ -50,46 +129,122 @@ func (h *Handler) Login(ctx *gin.Context) {
 		return
 	}
 	
-	if !hash.CheckPasswordHash(body.Password, user.Password_hash) {
+	passwordHash, err := h.UseCase.UserRepo.GetPasswordHash(ctx, entity.Id{ID: user.ID})
+	if h.HandleDbError(ctx, err, "Error getting user") {
+		return
+	}
+
+	if !hash.CheckPasswordHash(body.Password, passwordHash) {
 		h.ReturnError(ctx, config.ErrorInvalidPass, "Incorrect password", http.StatusBadRequest)
 		return
 	}
 
-	// create session
-	newSession := entity.Session{
-		UserID:       user.ID,
-		IPAddress:    ctx.ClientIP(),
-		ExpiresAt:    time.Now().Add(time.Hour * 999999).Format(time.RFC3339),
-		UserAgent:    ctx.Request.UserAgent(),
-		IsActive:     true,
-		LastActiveAt: time.Now().Format(time.RFC3339),
-		Platform:     body.Platform,
+	twoFactor, err := h.UseCase.TwoFactorRepo.Get(ctx, entity.Id{ID: user.ID})
+	if h.HandleDbError(ctx, err, "Error getting two-factor settings") {
+		return
+	}
+
+	// the login is finished by LoginTwoFactor
+	if twoFactor.Enabled || requiresTwoFactor(user.UserRole) {
+		challenge, err := h.twoFactorChallenge(ctx, user, twoFactor, body.Platform)
+		if h.HandleDbError(ctx, err, "Error creating two-factor challenge") {
+			return
+		}
+
+		ctx.JSON(200, challenge)
+		return
 	}
 
-	session, err := h.UseCase.SessionRepo.Create(ctx, newSession)
+	session, tokens, err := h.startSession(ctx, user, body.Platform, false)
 	if h.HandleDbError(ctx, err, "Error while creating new session") {
 		return
 	}
 
-	// generate jwt token
-	jwtFields := map[string]interface{}{
-		"sub":        user.ID,
-		"user_role":  user.UserRole,
-		"user_type":  user.UserType,
-		"platform":   body.Platform,
-		"session_id": session.ID,
-		"email": user.Email,
+	user.AccessToken = tokens.AccessToken
+	user.RefreshToken = tokens.RefreshToken
+
+	ctx.JSON(200, gin.H{
+		"user":    user,
+		"session": session,
+	})
+}
+
+// RefreshToken godoc
+// @Router /auth/refresh [post]
+// @Summary Refresh the access token
+// @Description Exchange a refresh token for a new access token and a new refresh token. Every refresh token can be used once,
+// @Description presenting one that was already used revokes the whole session.
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Param body body entity.RefreshTokenRequest true "Refresh token"
+// @Success 200 {object} entity.TokenResponse
+// @Failure 400 {object} entity.ErrorResponse
+// @Failure 401 {object} entity.ErrorResponse
+func (h *Handler) RefreshToken(ctx *gin.Context) {
+	var (
+		body entity.RefreshTokenRequest
+	)
+
+	err := ctx.ShouldBindJSON(&body)
+	if err != nil || body.RefreshToken == "" {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Invalid request body", 400)
+		return
 	}
 
-	user.AccessToken, err = jwt.GenerateJWT(jwtFields, h.Config.JWT.Secret)
+	refreshToken, next, err := newRefreshToken()
 	if err != nil {
 		h.ReturnError(ctx, config.ErrorInternalServer, "Oops, something went wrong!!!", http.StatusInternalServerError)
 		return
 	}
 
-	ctx.JSON(200, gin.H{
-		"user":    user,
-		"session": session,
+	session, err := h.UseCase.SessionRepo.RotateRefreshToken(ctx, hash.HashToken(body.RefreshToken), next)
+	if errors.Is(err, entity.ErrRefreshTokenReused) {
+		h.ReturnError(ctx, config.ErrorTokenReused, "Refresh token was already used, the session has been revoked", http.StatusUnauthorized)
+		return
+	}
+	if errors.Is(err, entity.ErrInvalidRefreshToken) {
+		h.ReturnError(ctx, config.ErrorInvalidToken, "Refresh token is invalid or expired", http.StatusUnauthorized)
+		return
+	}
+	if h.HandleDbError(ctx, err, "Error rotating refresh token") {
+		return
+	}
+
+	user, err := h.UseCase.UserRepo.GetSingle(ctx, entity.UserSingleRequest{
+		ID: session.UserID,
+	})
+	if h.HandleDbError(ctx, err, "Error getting user") {
+		return
+	}
+
+	if user.UserStatus == "blocked" {
+		h.ReturnError(ctx, config.ErrorForbidden, "User is blocked", http.StatusForbidden)
+		return
+	}
+
+	// e.g. a guest promoted to admin keeps the session they signed in with without a second factor
+	if requiresTwoFactor(user.UserRole) && !session.TwoFactorVerified {
+		err = h.UseCase.SessionRepo.Revoke(ctx, entity.Session{ID: session.ID, UserID: user.ID})
+		if h.HandleDbError(ctx, err, "Error revoking session") {
+			return
+		}
+
+		h.ReturnError(ctx, config.ErrorSessionExpired, "Two-factor authentication is required, please log in again", http.StatusUnauthorized)
+		return
+	}
+
+	accessToken, expiresAt, err := h.accessToken(user, session)
+	if err != nil {
+		h.ReturnError(ctx, config.ErrorInternalServer, "Oops, something went wrong!!!", http.StatusInternalServerError)
+		return
+	}
+
+	ctx.JSON(200, entity.TokenResponse{
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  expiresAt.Format(time.RFC3339),
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: next.ExpiresAt,
 	})
 }
 
@@ -171,24 +326,9 @@ func (h *Handler) Register(ctx *gin.Context) {
 		return
 	}
 
-	// send verification code to user
-	otp := etc.GenerateOTP(6)
-	err = h.Redis.Set(ctx, fmt.Sprintf("otp-%s", user.Email), otp, 5*60)
-	if err != nil {
-		h.ReturnError(ctx, config.ErrorInternalServer, "Error setting OTP", 500)
-		return
-	}
-
-	// send otp code to user's email
-	emailBody, err := etc.GenerateOtpEmailBody(otp)
-	if err != nil {
-		h.ReturnError(ctx, config.ErrorInternalServer, "Error sending OTP", 500)
-		return
-	}
-
-	err = etc.SendEmail(h.Config.Gmail.Host, h.Config.Gmail.Port, h.Config.Gmail.Email, h.Config.Gmail.EmailPass, body.Email, emailBody)
-	if err != nil {
-		h.ReturnError(ctx, config.ErrorInternalServer, "Error sending OTP", 500)
+	// send verification code to user's email
+	err = h.sendOtp(ctx, otpVerifyEmail, user.Email, user.Email)
+	if h.HandleOtpError(ctx, err) {
 		return
 	}
 
@@ -218,16 +358,8 @@ func (h *Handler) VerifyEmail(ctx *gin.Context) {
 		return
 	}
 
-	key := fmt.Sprintf("otp-%s", body.Email)
-
-	otp, err := h.Redis.Get(ctx, key)
-	if err != nil {
-		h.ReturnError(ctx, config.ErrorInternalServer, "Ooops, something went wrong", http.StatusInternalServerError)
-		return
-	}
-
-	if otp != body.Otp {
-		h.ReturnError(ctx, config.ErrorBadRequest, "Incorrect otp", http.StatusBadRequest)
+	err = h.OTP.Verify(ctx, otpVerifyEmail, body.Email, ctx.ClientIP(), body.Otp)
+	if h.HandleOtpError(ctx, err) {
 		return
 	}
 
@@ -245,39 +377,173 @@ func (h *Handler) VerifyEmail(ctx *gin.Context) {
 		return
 	}
 
-	// create session
-	newSession := entity.Session{
-		UserID:       user.ID,
-		IPAddress:    ctx.ClientIP(),
-		ExpiresAt:    time.Now().Add(time.Hour * 999999).Format(time.RFC3339),
-		UserAgent:    ctx.Request.UserAgent(),
-		IsActive:     true,
-		LastActiveAt: time.Now().Format(time.RFC3339),
-		Platform:     body.Platform,
+	// an emailed code is not a second factor
+	if requiresTwoFactor(user.UserRole) {
+		ctx.JSON(200, entity.SuccessResponse{
+			Message: "Email verified, please log in",
+		})
+		return
 	}
 
-	session, err := h.UseCase.SessionRepo.Create(ctx, newSession)
+	session, tokens, err := h.startSession(ctx, user, body.Platform, false)
 	if h.HandleDbError(ctx, err, "Error while creating new session") {
 		return
 	}
 
-	// generate jwt token
-	jwtFields := map[string]interface{}{
-		"sub":        user.ID,
-		"user_role":  user.UserRole,
-		"user_type":  user.UserType,
-		"platform":   body.Platform,
-		"session_id": session.ID,
+	user.AccessToken = tokens.AccessToken
+	user.RefreshToken = tokens.RefreshToken
+
+	ctx.JSON(200, gin.H{
+		"user":    user,
+		"session": session,
+	})
+}
+
+// ForgotPassword godoc
+// @Router /auth/forgot-password [post]
+// @Summary Request a password reset
+// @Description Emails a one-time code to reset the password. The response is the same whether the email is registered or not.
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Param body body entity.ForgotPasswordRequest true "Email"
+// @Success 200 {object} entity.SuccessResponse
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) ForgotPassword(ctx *gin.Context) {
+	var (
+		body entity.ForgotPasswordRequest
+	)
+
+	err := ctx.ShouldBindJSON(&body)
+	if err != nil || body.Email == "" {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Invalid request body", 400)
+		return
+	}
+
+	response := entity.SuccessResponse{
+		Message: "If the email is registered, a reset code has been sent to it",
 	}
 
-	user.AccessToken, err = jwt.GenerateJWT(jwtFields, h.Config.JWT.Secret)
+	// telling unknown emails apart would let anyone check who has an account
+	user, err := h.UseCase.UserRepo.GetSingle(ctx, entity.UserSingleRequest{
+		Email: body.Email,
+	})
+	if err != nil {
+		ctx.JSON(200, response)
+		return
+	}
+
+	// a cooldown or a failed email would give the account away just the same, so neither is reported
+	err = h.sendOtp(ctx, otpResetPassword, user.Email, user.Email)
+	if _, ok := otp.RetryAfter(err); err != nil && !ok {
+		h.Logger.Error(err, "Error sending password reset code")
+	}
+
+	ctx.JSON(200, response)
+}
+
+// ResetPassword godoc
+// @Router /auth/reset-password [post]
+// @Summary Reset the password
+// @Description Sets a new password with the code sent by forgot-password and signs the user out of every session.
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Param body body entity.ResetPasswordRequest true "Reset request"
+// @Success 200 {object} entity.SuccessResponse
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) ResetPassword(ctx *gin.Context) {
+	var (
+		body entity.ResetPasswordRequest
+	)
+
+	err := ctx.ShouldBindJSON(&body)
+	if err != nil {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Invalid request body", 400)
+		return
+	}
+
+	if len(body.NewPassword) < minPasswordLength {
+		h.ReturnError(ctx, config.ErrorInvalidPass, fmt.Sprintf("Password must be at least %d characters", minPasswordLength), http.StatusBadRequest)
+		return
+	}
+
+	err = h.OTP.Verify(ctx, otpResetPassword, body.Email, ctx.ClientIP(), body.Otp)
+	if h.HandleOtpError(ctx, err) {
+		return
+	}
+
+	user, err := h.UseCase.UserRepo.GetSingle(ctx, entity.UserSingleRequest{
+		Email: body.Email,
+	})
+	if h.HandleDbError(ctx, err, "Error getting user") {
+		return
+	}
+
+	passwordHash, err := hash.HashPassword(body.NewPassword)
 	if err != nil {
 		h.ReturnError(ctx, config.ErrorInternalServer, "Oops, something went wrong!!!", http.StatusInternalServerError)
 		return
 	}
 
-	ctx.JSON(200, gin.H{
-		"user":    user,
-		"session": session,
+	err = h.UseCase.UserRepo.UpdatePassword(ctx, entity.User{
+		ID:            user.ID,
+		Password_hash: passwordHash,
+	})
+	if h.HandleDbError(ctx, err, "Error updating password") {
+		return
+	}
+
+	// whoever knew the old password may still be signed in
+	err = h.UseCase.SessionRepo.RevokeByUser(ctx, user.ID, "")
+	if h.HandleDbError(ctx, err, "Error revoking sessions") {
+		return
+	}
+
+	ctx.JSON(200, entity.SuccessResponse{
+		Message: "Password has been reset, please log in again",
 	})
 }
+
+// ResendOtp godoc
+// @Router /auth/resend-otp [post]
+// @Summary Resend the email verification code
+// @Description Sends a new verification code to an email that is not verified yet. Codes can be requested once a minute.
+// @Description The answer is the same whether or not a code was sent.
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Param body body entity.ResendOtpRequest true "Email"
+// @Success 200 {object} entity.SuccessResponse
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) ResendOtp(ctx *gin.Context) {
+	var (
+		body entity.ResendOtpRequest
+	)
+
+	err := ctx.ShouldBindJSON(&body)
+	if err != nil || body.Email == "" {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Invalid request body", 400)
+		return
+	}
+
+	response := entity.SuccessResponse{
+		Message: "If the email is waiting for verification, a new code has been sent to it",
+	}
+
+	user, err := h.UseCase.UserRepo.GetSingle(ctx, entity.UserSingleRequest{
+		Email: body.Email,
+	})
+	if err != nil || user.UserStatus != "inverify" {
+		ctx.JSON(200, response)
+		return
+	}
+
+	// like in ForgotPassword, a cooldown or a failed email would tell that the account exists
+	err = h.sendOtp(ctx, otpVerifyEmail, user.Email, user.Email)
+	if _, ok := otp.RetryAfter(err); err != nil && !ok {
+		h.Logger.Error(err, "Error resending verification code")
+	}
+
+	ctx.JSON(200, response)
+}
@@ -4,22 +4,23 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/Avazbek-02/Online-Hotel-System/internal/entity"
 	"github.com/Avazbek-02/Online-Hotel-System/pkg/jwt"
-	"github.com/casbin/casbin"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/rbac"
 	"github.com/gin-gonic/gin"
 )
 
+const sessionActivityInterval = time.Minute
 
-
-
-func (h *Handler) AuthMiddleware(e *casbin.Enforcer) gin.HandlerFunc {
+func (h *Handler) AuthMiddleware(e *rbac.Enforcer) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var (
-			userRole string
-			act      = c.Request.Method
-			obj      = c.FullPath()
+			userRole     string
+			invalidToken bool
+			act          = c.Request.Method
+			obj          = c.FullPath()
 		)
 
 		token := c.GetHeader("Authorization")
@@ -28,12 +29,12 @@ func (h *Handler) AuthMiddleware(e *casbin.Enforcer) gin.HandlerFunc {
 		}
 
 		if userRole == "" {
-			
 			token = strings.TrimPrefix(token, "Bearer ")
 
 			claims, err := jwt.ParseJWT(token, h.Config.JWT.Secret)
 			if err != nil {
 				userRole = "unauthorized"
+				invalidToken = true
 			}
 
 			v, ok := claims["user_role"].(string)
@@ -59,6 +60,22 @@ func (h *Handler) AuthMiddleware(e *casbin.Enforcer) gin.HandlerFunc {
 				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Session is not active"})
 				return
 			}
+
+			if expiresAt, err := time.Parse(time.RFC3339, session.ExpiresAt); err == nil && time.Now().After(expiresAt) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Session has expired"})
+				return
+			}
+
+			// activity is recorded at most once per interval, not on every request
+			if lastActiveAt, err := time.Parse(time.RFC3339, session.LastActiveAt); err != nil || time.Since(lastActiveAt) > sessionActivityInterval {
+				err = h.UseCase.SessionRepo.Touch(c, entity.Session{
+					ID:        session.ID,
+					IPAddress: c.ClientIP(),
+				}, sessionActivityInterval)
+				if err != nil {
+					h.Logger.Error(err, "Error updating session activity")
+				}
+			}
 		}
 		ok, err := e.EnforceSafe(userRole, obj, act)
 		if err != nil {
@@ -68,6 +85,12 @@ func (h *Handler) AuthMiddleware(e *casbin.Enforcer) gin.HandlerFunc {
 		}
 
 		if !ok {
+			// an expired access token should make the client refresh it rather than give up
+			if invalidToken {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token is invalid or expired"})
+				return
+			}
+
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "access denied"})
 			return
 		}
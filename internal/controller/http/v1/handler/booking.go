@@ -0,0 +1,562 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Avazbek-02/Online-Hotel-System/config"
+	"github.com/Avazbek-02/Online-Hotel-System/internal/entity"
+	"github.com/gin-gonic/gin"
+)
+
+// parseStayDates validates a check-in/check-out pair and returns them as dates.
+func parseStayDates(checkIn, checkOut string) (time.Time, time.Time, error) {
+	in, err := time.Parse(time.DateOnly, checkIn)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("check_in_date must be in YYYY-MM-DD format")
+	}
+
+	out, err := time.Parse(time.DateOnly, checkOut)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("check_out_date must be in YYYY-MM-DD format")
+	}
+
+	if !out.After(in) {
+		return time.Time{}, time.Time{}, errors.New("check_out_date must be after check_in_date")
+	}
+
+	return in, out, nil
+}
+
+// stayNights returns the number of nights between check-in and check-out.
+func stayNights(checkIn, checkOut time.Time) float64 {
+	return float64(checkOut.Sub(checkIn) / (24 * time.Hour))
+}
+
+// CreateBooking godoc
+// @Router /booking [post]
+// @Summary Create a new booking
+// @Description Book a room type for a stay at the quoted price, the room is assigned at check-in. Guests always book for themselves,
+// @Description admins may pass user_id for room types of their hotels.
+// @Security BearerAuth
+// @Tags booking
+// @Accept  json
+// @Produce  json
+// @Param booking body entity.Booking true "Booking object"
+// @Success 201 {object} entity.Booking
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) CreateBooking(ctx *gin.Context) {
+	var (
+		body entity.Booking
+	)
+
+	err := ctx.ShouldBindJSON(&body)
+	if err != nil {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Invalid request body", 400)
+		return
+	}
+
+	if ctx.GetHeader("user_role") == "user" || body.UserID == "" {
+		body.UserID = ctx.GetHeader("sub")
+	}
+
+	checkIn, checkOut, err := parseStayDates(body.CheckInDate, body.CheckOutDate)
+	if err != nil {
+		h.ReturnError(ctx, config.ErrorBadRequest, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	roomType, err := h.UseCase.RoomTypeRepo.GetSingle(ctx, entity.Id{ID: body.RoomTypeID})
+	if h.HandleDbError(ctx, err, "Error getting room type") {
+		return
+	}
+
+	if !h.canManageHotel(ctx, roomType.HotelID) {
+		return
+	}
+
+	today, err := h.hotelToday(ctx, roomType.HotelID)
+	if h.HandleDbError(ctx, err, "Error getting hotel date") {
+		return
+	}
+
+	if checkIn.Before(today) {
+		h.ReturnError(ctx, config.ErrorBadRequest, "check_in_date can't be in the past", http.StatusBadRequest)
+		return
+	}
+
+	// the booking keeps the quoted total, later rate changes don't touch it
+	quote, ok := h.quoteStay(ctx, roomType, checkIn, checkOut)
+	if !ok {
+		return
+	}
+
+	body.Status = entity.BookingStatusPending
+	body.TotalPrice = quote.Total
+
+	booking, err := h.UseCase.BookingRepo.Create(ctx, body)
+	if errors.Is(err, entity.ErrRoomTypeUnavailable) {
+		h.ReturnError(ctx, config.ErrorRoomUnavailable, "No room of this type is free for the selected dates", http.StatusConflict)
+		return
+	}
+	if h.HandleDbError(ctx, err, "Error creating booking") {
+		return
+	}
+
+	ctx.JSON(201, booking)
+}
+
+// GetBooking godoc
+// @Router /booking/{id} [get]
+// @Summary Get a booking by ID
+// @Description Get a booking by ID
+// @Security BearerAuth
+// @Tags booking
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Booking ID"
+// @Success 200 {object} entity.Booking
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) GetBooking(ctx *gin.Context) {
+	var (
+		req entity.Id
+	)
+
+	req.ID = ctx.Param("id")
+
+	booking, err := h.UseCase.BookingRepo.GetSingle(ctx, req)
+	if h.HandleDbError(ctx, err, "Error getting booking") {
+		return
+	}
+
+	if ctx.GetHeader("user_role") == "user" && booking.UserID != ctx.GetHeader("sub") {
+		h.ReturnError(ctx, config.ErrorForbidden, "You can only view your own bookings", http.StatusForbidden)
+		return
+	}
+
+	if !h.canManageHotel(ctx, booking.HotelID) {
+		return
+	}
+
+	ctx.JSON(200, booking)
+}
+
+// GetBookings godoc
+// @Router /booking/list [get]
+// @Summary Get a list of bookings
+// @Description Get a list of bookings. Guests only see their own bookings, admins and staff those of their hotels.
+// @Security BearerAuth
+// @Tags booking
+// @Accept  json
+// @Produce  json
+// @Param page query number true "page"
+// @Param limit query number true "limit"
+// @Param hotel_id query string false "hotel_id"
+// @Param user_id query string false "user_id"
+// @Param room_type_id query string false "room_type_id"
+// @Param room_id query string false "room_id"
+// @Param status query string false "status"
+// @Success 200 {object} entity.BookingList
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) GetBookings(ctx *gin.Context) {
+	var (
+		req entity.GetListFilter
+	)
+
+	page := ctx.DefaultQuery("page", "1")
+	limit := ctx.DefaultQuery("limit", "10")
+	userId := ctx.DefaultQuery("user_id", "")
+	roomTypeId := ctx.DefaultQuery("room_type_id", "")
+	roomId := ctx.DefaultQuery("room_id", "")
+	status := ctx.DefaultQuery("status", "")
+	hotelId := ctx.DefaultQuery("hotel_id", "")
+
+	if ctx.GetHeader("user_role") == "user" {
+		userId = ctx.GetHeader("sub")
+	}
+
+	hotelIds, all, err := h.hotelScope(ctx)
+	if h.HandleDbError(ctx, err, "Error getting hotel assignments") {
+		return
+	}
+
+	if !all {
+		req.Filters = append(req.Filters, entity.Filter{
+			Column: "hotel_id",
+			Type:   "in",
+			Values: hotelIds,
+		})
+	}
+
+	if hotelId != "" {
+		req.Filters = append(req.Filters, entity.Filter{
+			Column: "hotel_id",
+			Type:   "eq",
+			Value:  hotelId,
+		})
+	}
+
+	req.Page, _ = strconv.Atoi(page)
+	req.Limit, _ = strconv.Atoi(limit)
+
+	if userId != "" {
+		req.Filters = append(req.Filters, entity.Filter{
+			Column: "user_id",
+			Type:   "eq",
+			Value:  userId,
+		})
+	}
+
+	if roomTypeId != "" {
+		req.Filters = append(req.Filters, entity.Filter{
+			Column: "room_type_id",
+			Type:   "eq",
+			Value:  roomTypeId,
+		})
+	}
+
+	if roomId != "" {
+		req.Filters = append(req.Filters, entity.Filter{
+			Column: "room_id",
+			Type:   "eq",
+			Value:  roomId,
+		})
+	}
+
+	if status != "" {
+		req.Filters = append(req.Filters, entity.Filter{
+			Column: "status",
+			Type:   "eq",
+			Value:  status,
+		})
+	}
+
+	req.OrderBy = append(req.OrderBy, entity.OrderBy{
+		Column: "check_in_date",
+		Order:  "desc",
+	})
+
+	bookings, err := h.UseCase.BookingRepo.GetList(ctx, req)
+	if h.HandleDbError(ctx, err, "Error getting bookings") {
+		return
+	}
+
+	ctx.JSON(200, bookings)
+}
+
+// UpdateBooking godoc
+// @Router /booking [put]
+// @Summary Update a booking
+// @Description Update a booking. Only admins may set the price, for the front desk it follows from the stay.
+// @Description The status can't be changed here, it follows from payments and /booking/{id}/cancel, check-in and check-out.
+// @Security BearerAuth
+// @Tags booking
+// @Accept  json
+// @Produce  json
+// @Param booking body entity.Booking true "Booking object"
+// @Success 200 {object} entity.Booking
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) UpdateBooking(ctx *gin.Context) {
+	var (
+		body entity.Booking
+	)
+
+	err := ctx.ShouldBindJSON(&body)
+	if err != nil {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Invalid request body", 400)
+		return
+	}
+
+	// the price follows from the stay for everyone but admins
+	if !isAdminRole(ctx.GetHeader("user_role")) {
+		body.TotalPrice = 0
+	}
+
+	current, err := h.UseCase.BookingRepo.GetSingle(ctx, entity.Id{ID: body.ID})
+	if h.HandleDbError(ctx, err, "Error getting booking") {
+		return
+	}
+
+	if !h.canManageHotel(ctx, current.HotelID) {
+		return
+	}
+
+	// the status moves only with the rooms, refunds and payments that go with it
+	if body.Status != "" && body.Status != "string" && body.Status != current.Status {
+		h.ReturnError(ctx, config.ErrorBadRequest, "The status can't be changed here, cancel, check in or check out the booking instead", http.StatusBadRequest)
+		return
+	}
+	body.Status = ""
+
+	// the room is assigned at check-in and can't be picked here
+	body.RoomID = ""
+
+	if body.RoomTypeID == current.RoomTypeID {
+		body.RoomTypeID = ""
+	}
+	if body.RoomTypeID != "" && current.RoomID != "" {
+		h.ReturnError(ctx, config.ErrorBadRequest, "The room type can't change once a room is assigned", http.StatusBadRequest)
+		return
+	}
+
+	if body.CheckInDate != "" || body.CheckOutDate != "" || body.RoomTypeID != "" {
+		roomTypeID, checkInDate, checkOutDate := current.RoomTypeID, current.CheckInDate, current.CheckOutDate
+		if body.RoomTypeID != "" {
+			roomTypeID = body.RoomTypeID
+		}
+		if body.CheckInDate != "" {
+			checkInDate = body.CheckInDate
+		}
+		if body.CheckOutDate != "" {
+			checkOutDate = body.CheckOutDate
+		}
+
+		checkIn, checkOut, err := parseStayDates(checkInDate, checkOutDate)
+		if err != nil {
+			h.ReturnError(ctx, config.ErrorBadRequest, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		roomType, err := h.UseCase.RoomTypeRepo.GetSingle(ctx, entity.Id{ID: roomTypeID})
+		if h.HandleDbError(ctx, err, "Error getting room type") {
+			return
+		}
+
+		if roomType.HotelID != current.HotelID {
+			h.ReturnError(ctx, config.ErrorBadRequest, "The room type must be of the same hotel", http.StatusBadRequest)
+			return
+		}
+
+		quote, ok := h.quoteStay(ctx, roomType, checkIn, checkOut)
+		if !ok {
+			return
+		}
+
+		// the stay changed, so it is quoted at today's rates unless the admin set a price explicitly
+		if body.TotalPrice == 0 {
+			body.TotalPrice = quote.Total
+		}
+	}
+
+	booking, err := h.UseCase.BookingRepo.Update(ctx, body)
+	if errors.Is(err, entity.ErrRoomTypeUnavailable) {
+		h.ReturnError(ctx, config.ErrorRoomUnavailable, "No room of this type is free for the selected dates", http.StatusConflict)
+		return
+	}
+	if h.HandleDbError(ctx, err, "Error updating booking") {
+		return
+	}
+
+	ctx.JSON(200, booking)
+}
+
+// CancelBooking godoc
+// @Router /booking/{id}/cancel [put]
+// @Summary Cancel a booking
+// @Description Cancel a booking and refund what was paid according to the cancellation policy of the room category.
+// @Description Payments still pending are voided. Refunds the payment provider doesn't take, and those of payments
+// @Description taken offline, stay pending until settled with PUT /refund/{id}/settle.
+// @Description Guests can only cancel their own bookings.
+// @Security BearerAuth
+// @Tags booking
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Booking ID"
+// @Success 200 {object} entity.CancelBookingResponse
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) CancelBooking(ctx *gin.Context) {
+	booking, err := h.UseCase.BookingRepo.GetSingle(ctx, entity.Id{ID: ctx.Param("id")})
+	if h.HandleDbError(ctx, err, "Error getting booking") {
+		return
+	}
+
+	if ctx.GetHeader("user_role") == "user" && booking.UserID != ctx.GetHeader("sub") {
+		h.ReturnError(ctx, config.ErrorForbidden, "You can only cancel your own bookings", http.StatusForbidden)
+		return
+	}
+
+	if !h.canManageHotel(ctx, booking.HotelID) {
+		return
+	}
+
+	policy, err := h.cancellationPolicyFor(ctx, booking.RoomTypeID)
+	if h.HandleDbError(ctx, err, "Error getting cancellation policy") {
+		return
+	}
+
+	location, err := h.hotelLocation(ctx, booking.HotelID)
+	if h.HandleDbError(ctx, err, "Error getting hotel time zone") {
+		return
+	}
+
+	// the free cancellation window ends at the start of the check-in day at the hotel
+	checkIn, err := time.ParseInLocation(time.DateOnly, booking.CheckInDate, location)
+	if err != nil {
+		h.ReturnError(ctx, config.ErrorInternalServer, "Invalid check-in date", http.StatusInternalServerError)
+		return
+	}
+
+	response, err := h.UseCase.BookingRepo.Cancel(ctx, entity.CancelBookingRequest{
+		BookingID:     booking.ID,
+		RefundPercent: refundPercent(policy, checkIn, time.Now()),
+	})
+	if errors.Is(err, entity.ErrBookingNotCancellable) {
+		h.ReturnError(ctx, config.ErrorConflict, "Booking is already cancelled, checked in or completed", http.StatusBadRequest)
+		return
+	}
+	if h.HandleDbError(ctx, err, "Error cancelling booking") {
+		return
+	}
+
+	// the cancellation and its refunds are committed, what the provider does can be retried
+	response.Refunds = h.payRefunds(ctx, response.Refunds)
+	for _, payment := range response.VoidedPayments {
+		h.voidCharge(ctx, payment)
+	}
+
+	ctx.JSON(200, response)
+}
+
+// CheckInBooking godoc
+// @Router /booking/{id}/check-in [put]
+// @Summary Check a guest in
+// @Description Check in a confirmed booking on or after its check-in date, assign it a room of the booked type and mark the room occupied.
+// @Description Without room_id the room the booking already has, or else any clean and free room of the type, is taken. Front desk only.
+// @Security BearerAuth
+// @Tags booking
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Booking ID"
+// @Param room body entity.CheckInRequest false "Room to assign"
+// @Success 200 {object} entity.Booking
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) CheckInBooking(ctx *gin.Context) {
+	var (
+		body entity.CheckInRequest
+	)
+
+	// the body is optional
+	err := ctx.ShouldBindJSON(&body)
+	if err != nil && !errors.Is(err, io.EOF) {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Invalid request body", 400)
+		return
+	}
+
+	booking, err := h.UseCase.BookingRepo.GetSingle(ctx, entity.Id{ID: ctx.Param("id")})
+	if h.HandleDbError(ctx, err, "Error getting booking") {
+		return
+	}
+
+	if !h.canManageHotel(ctx, booking.HotelID) {
+		return
+	}
+
+	checkIn, checkOut, err := parseStayDates(booking.CheckInDate, booking.CheckOutDate)
+	if err != nil {
+		h.ReturnError(ctx, config.ErrorInternalServer, "Invalid booking dates", http.StatusInternalServerError)
+		return
+	}
+
+	today, err := h.hotelToday(ctx, booking.HotelID)
+	if h.HandleDbError(ctx, err, "Error getting hotel date") {
+		return
+	}
+
+	if today.Before(checkIn) || !today.Before(checkOut) {
+		h.ReturnError(ctx, config.ErrorBadRequest, "The stay doesn't cover today", http.StatusBadRequest)
+		return
+	}
+
+	body.BookingID = booking.ID
+
+	booking, err = h.UseCase.BookingRepo.CheckIn(ctx, body)
+	switch {
+	case errors.Is(err, entity.ErrBookingNotConfirmed):
+		h.ReturnError(ctx, config.ErrorConflict, "Only a confirmed booking can be checked in", http.StatusBadRequest)
+		return
+	case errors.Is(err, entity.ErrRoomNotBookable):
+		h.ReturnError(ctx, config.ErrorBadRequest, "The room must be of the booked type and ready for a guest", http.StatusBadRequest)
+		return
+	case errors.Is(err, entity.ErrNoRoomAvailable):
+		h.ReturnError(ctx, config.ErrorRoomUnavailable, "No room of the booked type is ready", http.StatusConflict)
+		return
+	}
+	if h.HandleDbError(ctx, err, "Error checking in booking") {
+		return
+	}
+
+	ctx.JSON(200, booking)
+}
+
+// CheckOutBooking godoc
+// @Router /booking/{id}/check-out [put]
+// @Summary Check a guest out
+// @Description Complete a checked in booking and hand the room over to housekeeping for cleaning. Front desk only.
+// @Security BearerAuth
+// @Tags booking
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Booking ID"
+// @Success 200 {object} entity.Booking
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) CheckOutBooking(ctx *gin.Context) {
+	booking, err := h.UseCase.BookingRepo.GetSingle(ctx, entity.Id{ID: ctx.Param("id")})
+	if h.HandleDbError(ctx, err, "Error getting booking") {
+		return
+	}
+
+	if !h.canManageHotel(ctx, booking.HotelID) {
+		return
+	}
+
+	booking, err = h.UseCase.BookingRepo.CheckOut(ctx, entity.Id{ID: booking.ID})
+	if errors.Is(err, entity.ErrBookingNotCheckedIn) {
+		h.ReturnError(ctx, config.ErrorConflict, "Only a checked in booking can be checked out", http.StatusBadRequest)
+		return
+	}
+	if h.HandleDbError(ctx, err, "Error checking out booking") {
+		return
+	}
+
+	ctx.JSON(200, booking)
+}
+
+// DeleteBooking godoc
+// @Router /booking/{id} [delete]
+// @Summary Delete a booking
+// @Description Delete a booking of one of the admin's hotels
+// @Security BearerAuth
+// @Tags booking
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Booking ID"
+// @Success 200 {object} entity.SuccessResponse
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) DeleteBooking(ctx *gin.Context) {
+	var (
+		req entity.Id
+	)
+
+	req.ID = ctx.Param("id")
+
+	booking, err := h.UseCase.BookingRepo.GetSingle(ctx, req)
+	if h.HandleDbError(ctx, err, "Error getting booking") {
+		return
+	}
+
+	if !h.canManageHotel(ctx, booking.HotelID) {
+		return
+	}
+
+	err = h.UseCase.BookingRepo.Delete(ctx, req)
+	if h.HandleDbError(ctx, err, "Error deleting booking") {
+		return
+	}
+
+	ctx.JSON(200, entity.SuccessResponse{
+		Message: "Booking deleted successfully",
+	})
+}
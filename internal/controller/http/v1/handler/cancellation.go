@@ -0,0 +1,449 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/Avazbek-02/Online-Hotel-System/config"
+	"github.com/Avazbek-02/Online-Hotel-System/internal/entity"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v4"
+)
+
+// defaultCancellationPolicy applies to room categories their hotel set no policy for.
+var defaultCancellationPolicy = entity.CancellationPolicy{
+	FreeCancellationHours: 48,
+	LateRefundPercent:     50,
+}
+
+// refundPercent returns the share of the paid amount that is refunded when a booking
+// checking in on checkIn is cancelled at now. The free cancellation window is counted
+// back from checkIn, the start of the check-in day in the hotel's time zone.
+func refundPercent(policy entity.CancellationPolicy, checkIn, now time.Time) float64 {
+	if policy.NonRefundable {
+		return 0
+	}
+
+	if checkIn.Sub(now) >= time.Duration(policy.FreeCancellationHours)*time.Hour {
+		return 100
+	}
+
+	return float64(policy.LateRefundPercent)
+}
+
+// cancellationPolicyFor returns the policy the room type's hotel set for its category, or the default one.
+func (h *Handler) cancellationPolicyFor(ctx *gin.Context, roomTypeID string) (entity.CancellationPolicy, error) {
+	roomType, err := h.UseCase.RoomTypeRepo.GetSingle(ctx, entity.Id{ID: roomTypeID})
+	if err != nil {
+		return entity.CancellationPolicy{}, err
+	}
+
+	policy, err := h.UseCase.CancellationPolicyRepo.GetByCategory(ctx, entity.CancellationPolicy{
+		HotelID:      roomType.HotelID,
+		RoomCategory: roomType.Category,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return defaultCancellationPolicy, nil
+	}
+
+	return policy, err
+}
+
+// payRefunds pays the refunds written by a cancellation back through the payment provider.
+// A refund the provider doesn't take stays pending and can be retried with SettleRefund, as do
+// refunds of payments taken offline until they are paid back by hand.
+func (h *Handler) payRefunds(ctx *gin.Context, refunds []entity.Refund) []entity.Refund {
+	for i, refund := range refunds {
+		payment, err := h.UseCase.PaymentRepo.GetSingle(ctx, entity.Id{ID: refund.PaymentID})
+		if err != nil {
+			h.Logger.Error(err, "Error getting payment "+refund.PaymentID)
+			continue
+		}
+
+		if payment.ProviderRef == "" {
+			continue
+		}
+
+		refunds[i], err = h.payRefund(ctx, refund, payment)
+		if err != nil {
+			h.Logger.Error(err, "Error refunding payment "+payment.ID)
+		}
+	}
+
+	return refunds
+}
+
+// payRefund pays a pending refund back through the provider the payment was charged with.
+// The refund ID is the idempotency key, so a retry after an error pays it out only once.
+func (h *Handler) payRefund(ctx *gin.Context, refund entity.Refund, payment entity.Payment) (entity.Refund, error) {
+	result, err := h.UseCase.PaymentProvider.Refund(ctx, entity.RefundRequest{
+		ProviderRef:    payment.ProviderRef,
+		Amount:         refund.Amount,
+		IdempotencyKey: refund.ID,
+	})
+	if err != nil {
+		return refund, err
+	}
+
+	return h.UseCase.RefundRepo.UpdateStatus(ctx, entity.Refund{
+		ID:          refund.ID,
+		Status:      result.Status,
+		ProviderRef: result.ProviderRef,
+	})
+}
+
+// voidCharge gives back the charge of a voided payment, which the provider may still
+// settle after the booking was cancelled. It is keyed by the payment, so calling it again
+// for the same payment doesn't refund twice.
+func (h *Handler) voidCharge(ctx *gin.Context, payment entity.Payment) {
+	if payment.ProviderRef == "" {
+		return
+	}
+
+	result, err := h.UseCase.PaymentProvider.Refund(ctx, entity.RefundRequest{
+		ProviderRef:    payment.ProviderRef,
+		Amount:         payment.Amount,
+		IdempotencyKey: "void-" + payment.ID,
+	})
+	if err != nil {
+		h.Logger.Error(err, "Error voiding charge of payment "+payment.ID)
+		return
+	}
+	if result.Status == entity.PaymentStatusFailed {
+		h.Logger.Error("Provider refused to void the charge of payment " + payment.ID)
+	}
+}
+
+// CreateCancellationPolicy godoc
+// @Router /cancellation-policy [post]
+// @Summary Create a cancellation policy
+// @Description Create the cancellation policy of a room category at a hotel (admins of the hotel only)
+// @Security BearerAuth
+// @Tags cancellation-policy
+// @Accept  json
+// @Produce  json
+// @Param policy body entity.CancellationPolicy true "Cancellation policy object"
+// @Success 201 {object} entity.CancellationPolicy
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) CreateCancellationPolicy(ctx *gin.Context) {
+	var (
+		body entity.CancellationPolicy
+	)
+
+	err := ctx.ShouldBindJSON(&body)
+	if err != nil {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Invalid request body", 400)
+		return
+	}
+
+	if _, ok := entity.RoomCategoryCapacity[body.RoomCategory]; !ok {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Invalid room category", http.StatusBadRequest)
+		return
+	}
+
+	if !h.canManageHotel(ctx, body.HotelID) {
+		return
+	}
+
+	if !validCancellationTerms(body) {
+		h.ReturnError(ctx, config.ErrorBadRequest, "free_cancellation_hours must not be negative and late_refund_percent must be between 0 and 100", http.StatusBadRequest)
+		return
+	}
+
+	policy, err := h.UseCase.CancellationPolicyRepo.Create(ctx, body)
+	if h.HandleDbError(ctx, err, "Error creating cancellation policy") {
+		return
+	}
+
+	ctx.JSON(201, policy)
+}
+
+func validCancellationTerms(policy entity.CancellationPolicy) bool {
+	return policy.FreeCancellationHours >= 0 && policy.LateRefundPercent >= 0 && policy.LateRefundPercent <= 100
+}
+
+// canManageCancellationPolicy is canManageHotel for the hotel of a cancellation policy.
+func (h *Handler) canManageCancellationPolicy(ctx *gin.Context, id string) bool {
+	policy, err := h.UseCase.CancellationPolicyRepo.GetSingle(ctx, entity.Id{ID: id})
+	if h.HandleDbError(ctx, err, "Error getting cancellation policy") {
+		return false
+	}
+
+	return h.canManageHotel(ctx, policy.HotelID)
+}
+
+// GetCancellationPolicy godoc
+// @Router /cancellation-policy/{id} [get]
+// @Summary Get a cancellation policy by ID
+// @Description Get a cancellation policy by ID
+// @Tags cancellation-policy
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Cancellation policy ID"
+// @Success 200 {object} entity.CancellationPolicy
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) GetCancellationPolicy(ctx *gin.Context) {
+	var (
+		req entity.Id
+	)
+
+	req.ID = ctx.Param("id")
+
+	policy, err := h.UseCase.CancellationPolicyRepo.GetSingle(ctx, req)
+	if h.HandleDbError(ctx, err, "Error getting cancellation policy") {
+		return
+	}
+
+	ctx.JSON(200, policy)
+}
+
+// GetCancellationPolicies godoc
+// @Router /cancellation-policy/list [get]
+// @Summary Get a list of cancellation policies
+// @Description Get the cancellation policies of the room categories of every hotel, or of one
+// @Tags cancellation-policy
+// @Accept  json
+// @Produce  json
+// @Param page query number true "page"
+// @Param limit query number true "limit"
+// @Param hotel_id query string false "hotel_id"
+// @Param room_category query string false "room_category"
+// @Success 200 {object} entity.CancellationPolicyList
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) GetCancellationPolicies(ctx *gin.Context) {
+	var (
+		req entity.GetListFilter
+	)
+
+	page := ctx.DefaultQuery("page", "1")
+	limit := ctx.DefaultQuery("limit", "10")
+
+	req.Page, _ = strconv.Atoi(page)
+	req.Limit, _ = strconv.Atoi(limit)
+
+	for _, column := range []string{"hotel_id", "room_category"} {
+		if value := ctx.Query(column); value != "" {
+			req.Filters = append(req.Filters, entity.Filter{
+				Column: column,
+				Type:   "eq",
+				Value:  value,
+			})
+		}
+	}
+
+	req.OrderBy = append(req.OrderBy, entity.OrderBy{
+		Column: "room_category",
+		Order:  "asc",
+	})
+
+	policies, err := h.UseCase.CancellationPolicyRepo.GetList(ctx, req)
+	if h.HandleDbError(ctx, err, "Error getting cancellation policies") {
+		return
+	}
+
+	ctx.JSON(200, policies)
+}
+
+// UpdateCancellationPolicy godoc
+// @Router /cancellation-policy [put]
+// @Summary Update a cancellation policy
+// @Description Replace the terms of a cancellation policy (admins of its hotel only)
+// @Security BearerAuth
+// @Tags cancellation-policy
+// @Accept  json
+// @Produce  json
+// @Param policy body entity.CancellationPolicy true "Cancellation policy object"
+// @Success 200 {object} entity.CancellationPolicy
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) UpdateCancellationPolicy(ctx *gin.Context) {
+	var (
+		body entity.CancellationPolicy
+	)
+
+	err := ctx.ShouldBindJSON(&body)
+	if err != nil {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Invalid request body", 400)
+		return
+	}
+
+	if !validCancellationTerms(body) {
+		h.ReturnError(ctx, config.ErrorBadRequest, "free_cancellation_hours must not be negative and late_refund_percent must be between 0 and 100", http.StatusBadRequest)
+		return
+	}
+
+	if !h.canManageCancellationPolicy(ctx, body.ID) {
+		return
+	}
+
+	policy, err := h.UseCase.CancellationPolicyRepo.Update(ctx, body)
+	if h.HandleDbError(ctx, err, "Error updating cancellation policy") {
+		return
+	}
+
+	ctx.JSON(200, policy)
+}
+
+// DeleteCancellationPolicy godoc
+// @Router /cancellation-policy/{id} [delete]
+// @Summary Delete a cancellation policy
+// @Description Delete a cancellation policy (admins of its hotel only). The category falls back to the default policy.
+// @Security BearerAuth
+// @Tags cancellation-policy
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Cancellation policy ID"
+// @Success 200 {object} entity.SuccessResponse
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) DeleteCancellationPolicy(ctx *gin.Context) {
+	var (
+		req entity.Id
+	)
+
+	req.ID = ctx.Param("id")
+
+	if !h.canManageCancellationPolicy(ctx, req.ID) {
+		return
+	}
+
+	err := h.UseCase.CancellationPolicyRepo.Delete(ctx, req)
+	if h.HandleDbError(ctx, err, "Error deleting cancellation policy") {
+		return
+	}
+
+	ctx.JSON(200, entity.SuccessResponse{
+		Message: "Cancellation policy deleted successfully",
+	})
+}
+
+// GetRefunds godoc
+// @Router /refund/list [get]
+// @Summary Get a list of refunds
+// @Description Get a list of refunds. Guests must pass one of their own booking_id, admins and staff
+// @Description not assigned to every hotel one of a booking of their hotels.
+// @Security BearerAuth
+// @Tags payment
+// @Accept  json
+// @Produce  json
+// @Param page query number true "page"
+// @Param limit query number true "limit"
+// @Param booking_id query string false "booking_id"
+// @Param payment_id query string false "payment_id"
+// @Param status query string false "status"
+// @Success 200 {object} entity.RefundList
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) GetRefunds(ctx *gin.Context) {
+	var (
+		req entity.GetListFilter
+	)
+
+	page := ctx.DefaultQuery("page", "1")
+	limit := ctx.DefaultQuery("limit", "10")
+	bookingId := ctx.DefaultQuery("booking_id", "")
+
+	if ctx.GetHeader("user_role") == "user" {
+		booking, err := h.UseCase.BookingRepo.GetSingle(ctx, entity.Id{ID: bookingId})
+		if err != nil || booking.UserID != ctx.GetHeader("sub") {
+			h.ReturnError(ctx, config.ErrorForbidden, "You can only view refunds of your own bookings", http.StatusForbidden)
+			return
+		}
+	}
+
+	hotelIds, all, err := h.hotelScope(ctx)
+	if h.HandleDbError(ctx, err, "Error getting hotel assignments") {
+		return
+	}
+
+	if !all {
+		booking, err := h.UseCase.BookingRepo.GetSingle(ctx, entity.Id{ID: bookingId})
+		if err != nil || !slices.Contains(hotelIds, booking.HotelID) {
+			h.ReturnError(ctx, config.ErrorForbidden, "You can only view refunds of bookings of your hotels", http.StatusForbidden)
+			return
+		}
+	}
+
+	req.Page, _ = strconv.Atoi(page)
+	req.Limit, _ = strconv.Atoi(limit)
+
+	for _, column := range []string{"booking_id", "payment_id", "status"} {
+		if value := ctx.Query(column); value != "" {
+			req.Filters = append(req.Filters, entity.Filter{
+				Column: column,
+				Type:   "eq",
+				Value:  value,
+			})
+		}
+	}
+
+	req.OrderBy = append(req.OrderBy, entity.OrderBy{
+		Column: "created_at",
+		Order:  "desc",
+	})
+
+	refunds, err := h.UseCase.RefundRepo.GetList(ctx, req)
+	if h.HandleDbError(ctx, err, "Error getting refunds") {
+		return
+	}
+
+	ctx.JSON(200, refunds)
+}
+
+// SettleRefund godoc
+// @Router /refund/{id}/settle [put]
+// @Summary Settle a pending refund
+// @Description Retry paying a pending refund back through the payment provider, or mark the refund of a payment
+// @Description taken offline as paid back by hand. Admins and the front desk of the booking's hotel only.
+// @Security BearerAuth
+// @Tags payment
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Refund ID"
+// @Success 200 {object} entity.Refund
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) SettleRefund(ctx *gin.Context) {
+	refund, err := h.UseCase.RefundRepo.GetSingle(ctx, entity.Id{ID: ctx.Param("id")})
+	if h.HandleDbError(ctx, err, "Error getting refund") {
+		return
+	}
+
+	booking, err := h.UseCase.BookingRepo.GetSingle(ctx, entity.Id{ID: refund.BookingID})
+	if h.HandleDbError(ctx, err, "Error getting booking") {
+		return
+	}
+
+	if !h.canManageHotel(ctx, booking.HotelID) {
+		return
+	}
+
+	payment, err := h.UseCase.PaymentRepo.GetSingle(ctx, entity.Id{ID: refund.PaymentID})
+	if h.HandleDbError(ctx, err, "Error getting payment") {
+		return
+	}
+
+	if payment.ProviderRef == "" {
+		refund, err = h.UseCase.RefundRepo.UpdateStatus(ctx, entity.Refund{
+			ID:     refund.ID,
+			Status: entity.RefundStatusSucceeded,
+		})
+	} else if refund.Status == entity.RefundStatusPending {
+		refund, err = h.payRefund(ctx, refund, payment)
+		if err != nil && !errors.Is(err, entity.ErrRefundNotPending) {
+			h.Logger.Error(err, "Error refunding payment "+payment.ID)
+			h.ReturnError(ctx, config.ErrorInternalServer, "Payment provider error, retry later", http.StatusBadGateway)
+			return
+		}
+	} else {
+		err = entity.ErrRefundNotPending
+	}
+	if errors.Is(err, entity.ErrRefundNotPending) {
+		h.ReturnError(ctx, config.ErrorConflict, "Refund is already settled", http.StatusBadRequest)
+		return
+	}
+	if h.HandleDbError(ctx, err, "Error settling refund") {
+		return
+	}
+
+	ctx.JSON(200, refund)
+}
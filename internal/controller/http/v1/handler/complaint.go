@@ -0,0 +1,367 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/Avazbek-02/Online-Hotel-System/config"
+	"github.com/Avazbek-02/Online-Hotel-System/internal/entity"
+	"github.com/gin-gonic/gin"
+)
+
+// getComplaint loads a complaint and checks that guests only reach their own and staff
+// only those of their hotels.
+func (h *Handler) getComplaint(ctx *gin.Context, id string) (entity.Complaint, bool) {
+	complaint, err := h.UseCase.ComplaintRepo.GetSingle(ctx, entity.Id{ID: id})
+	if h.HandleDbError(ctx, err, "Error getting complaint") {
+		return entity.Complaint{}, false
+	}
+
+	if ctx.GetHeader("user_role") == "user" && complaint.UserID != ctx.GetHeader("sub") {
+		h.ReturnError(ctx, config.ErrorForbidden, "You can only access your own complaints", http.StatusForbidden)
+		return entity.Complaint{}, false
+	}
+
+	if !h.canManageHotel(ctx, complaint.HotelID) {
+		return entity.Complaint{}, false
+	}
+
+	return complaint, true
+}
+
+// CreateComplaint godoc
+// @Router /complaint [post]
+// @Summary File a complaint
+// @Description File a complaint about one of your own bookings
+// @Security BearerAuth
+// @Tags complaint
+// @Accept  json
+// @Produce  json
+// @Param complaint body entity.Complaint true "Complaint object"
+// @Success 201 {object} entity.Complaint
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) CreateComplaint(ctx *gin.Context) {
+	var (
+		body entity.Complaint
+	)
+
+	err := ctx.ShouldBindJSON(&body)
+	if err != nil {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Invalid request body", 400)
+		return
+	}
+
+	if body.Message == "" {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Message is required", http.StatusBadRequest)
+		return
+	}
+
+	booking, err := h.UseCase.BookingRepo.GetSingle(ctx, entity.Id{ID: body.BookingID})
+	if h.HandleDbError(ctx, err, "Error getting booking") {
+		return
+	}
+
+	body.UserID = ctx.GetHeader("sub")
+	if booking.UserID != body.UserID {
+		h.ReturnError(ctx, config.ErrorForbidden, "You can only complain about your own bookings", http.StatusForbidden)
+		return
+	}
+	body.HotelID = booking.HotelID
+
+	complaint, err := h.UseCase.ComplaintRepo.Create(ctx, body)
+	if h.HandleDbError(ctx, err, "Error creating complaint") {
+		return
+	}
+
+	ctx.JSON(201, complaint)
+}
+
+// GetComplaint godoc
+// @Router /complaint/{id} [get]
+// @Summary Get a complaint by ID
+// @Description Get a complaint with its message thread. Guests can only view their own complaints.
+// @Security BearerAuth
+// @Tags complaint
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Complaint ID"
+// @Success 200 {object} entity.Complaint
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) GetComplaint(ctx *gin.Context) {
+	complaint, ok := h.getComplaint(ctx, ctx.Param("id"))
+	if !ok {
+		return
+	}
+
+	messages, err := h.UseCase.ComplaintRepo.GetMessages(ctx, entity.Id{ID: complaint.ID})
+	if h.HandleDbError(ctx, err, "Error getting complaint messages") {
+		return
+	}
+
+	complaint.Messages = messages.Items
+	ctx.JSON(200, complaint)
+}
+
+// GetComplaints godoc
+// @Router /complaint/list [get]
+// @Summary Get a list of complaints
+// @Description Get a list of complaints. Guests only see their own complaints, admins and staff those of their hotels.
+// @Security BearerAuth
+// @Tags complaint
+// @Accept  json
+// @Produce  json
+// @Param page query number true "page"
+// @Param limit query number true "limit"
+// @Param status query string false "status"
+// @Param booking_id query string false "booking_id"
+// @Param user_id query string false "user_id"
+// @Param assignee_id query string false "assignee_id"
+// @Success 200 {object} entity.ComplaintList
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) GetComplaints(ctx *gin.Context) {
+	var (
+		req entity.GetListFilter
+	)
+
+	page := ctx.DefaultQuery("page", "1")
+	limit := ctx.DefaultQuery("limit", "10")
+
+	req.Page, _ = strconv.Atoi(page)
+	req.Limit, _ = strconv.Atoi(limit)
+
+	for _, column := range []string{"status", "booking_id", "user_id", "assignee_id"} {
+		if value := ctx.Query(column); value != "" {
+			req.Filters = append(req.Filters, entity.Filter{
+				Column: column,
+				Type:   "eq",
+				Value:  value,
+			})
+		}
+	}
+
+	if ctx.GetHeader("user_role") == "user" {
+		req.Filters = append(req.Filters, entity.Filter{
+			Column: "user_id",
+			Type:   "eq",
+			Value:  ctx.GetHeader("sub"),
+		})
+	}
+
+	hotelIds, all, err := h.hotelScope(ctx)
+	if h.HandleDbError(ctx, err, "Error getting hotel assignments") {
+		return
+	}
+
+	if !all {
+		req.Filters = append(req.Filters, entity.Filter{
+			Column: "hotel_id",
+			Type:   "in",
+			Values: hotelIds,
+		})
+	}
+
+	req.OrderBy = append(req.OrderBy, entity.OrderBy{
+		Column: "created_at",
+		Order:  "desc",
+	})
+
+	complaints, err := h.UseCase.ComplaintRepo.GetList(ctx, req)
+	if h.HandleDbError(ctx, err, "Error getting complaints") {
+		return
+	}
+
+	ctx.JSON(200, complaints)
+}
+
+// AssignComplaint godoc
+// @Router /complaint/{id}/assign [put]
+// @Summary Assign a complaint
+// @Description Assign an open complaint to a staff member of its hotel (admin only). A pending complaint moves to in_progress.
+// @Security BearerAuth
+// @Tags complaint
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Complaint ID"
+// @Param assignee body entity.ComplaintAssignRequest true "Assignee"
+// @Success 200 {object} entity.Complaint
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) AssignComplaint(ctx *gin.Context) {
+	var (
+		body entity.ComplaintAssignRequest
+	)
+
+	err := ctx.ShouldBindJSON(&body)
+	if err != nil {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Invalid request body", 400)
+		return
+	}
+
+	complaint, ok := h.getComplaint(ctx, ctx.Param("id"))
+	if !ok {
+		return
+	}
+
+	if body.AssigneeID == "" {
+		body.AssigneeID = ctx.GetHeader("sub")
+	}
+
+	assignee, err := h.UseCase.UserRepo.GetSingle(ctx, entity.UserSingleRequest{ID: body.AssigneeID})
+	if h.HandleDbError(ctx, err, "Error getting assignee") {
+		return
+	}
+
+	if assignee.UserRole == "user" {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Complaints can only be assigned to staff", http.StatusBadRequest)
+		return
+	}
+
+	if assignee.UserRole != entity.UserRoleSuperadmin {
+		hotelIds, err := h.UseCase.HotelRepo.GetHotelIDsByStaff(ctx, entity.Id{ID: assignee.ID})
+		if h.HandleDbError(ctx, err, "Error getting hotel assignments") {
+			return
+		}
+
+		if !slices.Contains(hotelIds, complaint.HotelID) {
+			h.ReturnError(ctx, config.ErrorBadRequest, "Complaints can only be assigned to staff of the booking's hotel", http.StatusBadRequest)
+			return
+		}
+	}
+
+	complaint, err = h.UseCase.ComplaintRepo.Assign(ctx, entity.Complaint{
+		ID:         complaint.ID,
+		AssigneeID: assignee.ID,
+	})
+	if errors.Is(err, entity.ErrComplaintStatusChanged) {
+		h.ReturnError(ctx, config.ErrorConflict, "Complaint is already closed", http.StatusConflict)
+		return
+	}
+	if h.HandleDbError(ctx, err, "Error assigning complaint") {
+		return
+	}
+
+	ctx.JSON(200, complaint)
+}
+
+// UpdateComplaintStatus godoc
+// @Router /complaint/{id}/status [put]
+// @Summary Change the status of a complaint
+// @Description Move a complaint along pending -> in_progress -> resolved/rejected (admin only)
+// @Security BearerAuth
+// @Tags complaint
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Complaint ID"
+// @Param status body entity.ComplaintStatusRequest true "New status"
+// @Success 200 {object} entity.Complaint
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) UpdateComplaintStatus(ctx *gin.Context) {
+	var (
+		body entity.ComplaintStatusRequest
+	)
+
+	err := ctx.ShouldBindJSON(&body)
+	if err != nil {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Invalid request body", 400)
+		return
+	}
+
+	complaint, ok := h.getComplaint(ctx, ctx.Param("id"))
+	if !ok {
+		return
+	}
+
+	if !entity.CanTransitionComplaint(complaint.Status, body.Status) {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Complaint can't move from "+complaint.Status+" to "+body.Status, http.StatusBadRequest)
+		return
+	}
+
+	complaint, err = h.UseCase.ComplaintRepo.UpdateStatus(ctx, complaint.ID, complaint.Status, body.Status)
+	if errors.Is(err, entity.ErrComplaintStatusChanged) {
+		h.ReturnError(ctx, config.ErrorConflict, "Complaint was changed by someone else, reload it and try again", http.StatusConflict)
+		return
+	}
+	if h.HandleDbError(ctx, err, "Error updating complaint status") {
+		return
+	}
+
+	ctx.JSON(200, complaint)
+}
+
+// AddComplaintMessage godoc
+// @Router /complaint/{id}/message [post]
+// @Summary Reply to a complaint
+// @Description Add a message to the thread of an open complaint. Guests can only write on their own complaints.
+// @Security BearerAuth
+// @Tags complaint
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Complaint ID"
+// @Param message body entity.ComplaintMessage true "Message object"
+// @Success 201 {object} entity.ComplaintMessage
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) AddComplaintMessage(ctx *gin.Context) {
+	var (
+		body entity.ComplaintMessage
+	)
+
+	err := ctx.ShouldBindJSON(&body)
+	if err != nil {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Invalid request body", 400)
+		return
+	}
+
+	if body.Message == "" {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Message is required", http.StatusBadRequest)
+		return
+	}
+
+	complaint, ok := h.getComplaint(ctx, ctx.Param("id"))
+	if !ok {
+		return
+	}
+
+	if complaint.Status == entity.ComplaintStatusResolved || complaint.Status == entity.ComplaintStatusRejected {
+		h.ReturnError(ctx, config.ErrorConflict, "Complaint is already "+complaint.Status, http.StatusConflict)
+		return
+	}
+
+	message, err := h.UseCase.ComplaintRepo.AddMessage(ctx, entity.ComplaintMessage{
+		ComplaintID: complaint.ID,
+		UserID:      ctx.GetHeader("sub"),
+		Message:     body.Message,
+	})
+	if h.HandleDbError(ctx, err, "Error adding complaint message") {
+		return
+	}
+
+	ctx.JSON(201, message)
+}
+
+// DeleteComplaint godoc
+// @Router /complaint/{id} [delete]
+// @Summary Delete a complaint
+// @Description Delete a complaint and its messages (admin only)
+// @Security BearerAuth
+// @Tags complaint
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Complaint ID"
+// @Success 200 {object} entity.SuccessResponse
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) DeleteComplaint(ctx *gin.Context) {
+	complaint, ok := h.getComplaint(ctx, ctx.Param("id"))
+	if !ok {
+		return
+	}
+
+	err := h.UseCase.ComplaintRepo.Delete(ctx, entity.Id{ID: complaint.ID})
+	if h.HandleDbError(ctx, err, "Error deleting complaint") {
+		return
+	}
+
+	ctx.JSON(200, entity.SuccessResponse{
+		Message: "Complaint deleted successfully",
+	})
+}
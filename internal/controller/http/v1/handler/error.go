@@ -47,6 +47,27 @@ func (h Handler) HandleDbError(c *gin.Context, err error, message string) bool {
 				Code:    config.ErrorConflict,
 			}
 			statusCode = http.StatusBadRequest
+		case "23P01":
+			// Exclusion constraint violation (overlapping booking)
+			errorResponse = entity.ErrorResponse{
+				Message: "The room is already booked for the selected dates.",
+				Code:    config.ErrorRoomUnavailable,
+			}
+			statusCode = http.StatusConflict
+		case "23514":
+			// Check constraint violation
+			errorResponse = entity.ErrorResponse{
+				Message: "Value violates a check constraint.",
+				Code:    config.ErrorInvalidRequest,
+			}
+			statusCode = http.StatusBadRequest
+		case "22P02":
+			// Invalid input syntax (e.g. unknown enum value or malformed uuid)
+			errorResponse = entity.ErrorResponse{
+				Message: "Invalid input value.",
+				Code:    config.ErrorInvalidRequest,
+			}
+			statusCode = http.StatusBadRequest
 		case "22001":
 			// Value too long for column
 			errorResponse = entity.ErrorResponse{
@@ -3,25 +3,34 @@ package handler
 import (
 	"github.com/Avazbek-02/Online-Hotel-System/config"
 	"github.com/Avazbek-02/Online-Hotel-System/internal/usecase"
-	minio "github.com/Avazbek-02/Online-Hotel-System/pkg/MinIO"
 	"github.com/Avazbek-02/Online-Hotel-System/pkg/logger"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/otp"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/rbac"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/storage"
+	"github.com/gin-gonic/gin"
 	rediscache "github.com/golanguzb70/redis-cache"
 )
 
 type Handler struct {
-	Logger  *logger.Logger
-	Config  *config.Config
-	UseCase *usecase.UseCase
-	Redis   rediscache.RedisCache
-	MinIO   *minio.MinIO
+	Logger   *logger.Logger
+	Config   *config.Config
+	UseCase  *usecase.UseCase
+	Redis    rediscache.RedisCache
+	Storage  storage.Storage
+	OTP      *otp.Store
+	Enforcer *rbac.Enforcer
+	// Routes registered on the engine, to tell which policies match no route
+	Routes gin.RoutesInfo
 }
 
-func NewHandler(l *logger.Logger, c *config.Config, useCase *usecase.UseCase, redis rediscache.RedisCache, minio *minio.MinIO) *Handler {
+func NewHandler(l *logger.Logger, c *config.Config, useCase *usecase.UseCase, redis rediscache.RedisCache, storage storage.Storage, otp *otp.Store, enforcer *rbac.Enforcer) *Handler {
 	return &Handler{
-		Logger:  l,
-		Config:  c,
-		UseCase: useCase,
-		Redis:   redis,
-		MinIO:   minio,
+		Logger:   l,
+		Config:   c,
+		UseCase:  useCase,
+		Redis:    redis,
+		Storage:  storage,
+		OTP:      otp,
+		Enforcer: enforcer,
 	}
 }
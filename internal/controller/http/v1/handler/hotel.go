@@ -0,0 +1,404 @@
+package handler
+
+import (
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/Avazbek-02/Online-Hotel-System/config"
+	"github.com/Avazbek-02/Online-Hotel-System/internal/entity"
+	"github.com/gin-gonic/gin"
+)
+
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// hotelScope returns the hotels the caller is assigned to. all is true for superadmins and
+// guests, who aren't tied to a hotel.
+func (h *Handler) hotelScope(ctx *gin.Context) (hotelIDs []string, all bool, err error) {
+	switch ctx.GetHeader("user_role") {
+	case entity.UserRoleSuperadmin, entity.UserRoleUser:
+		return nil, true, nil
+	}
+
+	hotelIDs, err = h.UseCase.HotelRepo.GetHotelIDsByStaff(ctx, entity.Id{ID: ctx.GetHeader("sub")})
+	return hotelIDs, false, err
+}
+
+// canManageHotel reports whether the caller may act on the hotel and writes the error
+// response when not.
+func (h *Handler) canManageHotel(ctx *gin.Context, hotelID string) bool {
+	hotelIDs, all, err := h.hotelScope(ctx)
+	if h.HandleDbError(ctx, err, "Error getting hotel assignments") {
+		return false
+	}
+
+	if !all && !slices.Contains(hotelIDs, hotelID) {
+		h.ReturnError(ctx, config.ErrorForbidden, "You are not assigned to this hotel", http.StatusForbidden)
+		return false
+	}
+
+	return true
+}
+
+// canManageRoom is canManageHotel for the hotel of a room.
+func (h *Handler) canManageRoom(ctx *gin.Context, roomID string) bool {
+	room, err := h.UseCase.RoomsRepo.GetSingle(ctx, entity.Id{ID: roomID})
+	if h.HandleDbError(ctx, err, "Error getting room") {
+		return false
+	}
+
+	return h.canManageHotel(ctx, room.HotelID)
+}
+
+// hotelLocation returns the time zone of the hotel.
+func (h *Handler) hotelLocation(ctx *gin.Context, hotelID string) (*time.Location, error) {
+	hotel, err := h.UseCase.HotelRepo.GetSingle(ctx, entity.Id{ID: hotelID})
+	if err != nil {
+		return nil, err
+	}
+
+	return time.LoadLocation(hotel.Timezone)
+}
+
+// hotelToday returns the current date at the hotel, as a UTC midnight like parsed stay dates.
+func (h *Handler) hotelToday(ctx *gin.Context, hotelID string) (time.Time, error) {
+	location, err := h.hotelLocation(ctx, hotelID)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	now := time.Now().In(location)
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC), nil
+}
+
+// validateHotel checks the fields of a hotel that are set.
+func (h *Handler) validateHotel(ctx *gin.Context, body entity.Hotel) bool {
+	if body.Timezone != "" {
+		if _, err := time.LoadLocation(body.Timezone); err != nil {
+			h.ReturnError(ctx, config.ErrorBadRequest, "timezone must be an IANA name such as Asia/Tashkent", http.StatusBadRequest)
+			return false
+		}
+	}
+
+	if body.Currency != "" && !currencyCode.MatchString(body.Currency) {
+		h.ReturnError(ctx, config.ErrorBadRequest, "currency must be an ISO 4217 code such as USD", http.StatusBadRequest)
+		return false
+	}
+
+	if body.Latitude < -90 || body.Latitude > 90 || body.Longitude < -180 || body.Longitude > 180 {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Invalid coordinates", http.StatusBadRequest)
+		return false
+	}
+
+	return true
+}
+
+// CreateHotel godoc
+// @Router /hotel [post]
+// @Summary Create a new hotel
+// @Description Create a new hotel (superadmin only)
+// @Security BearerAuth
+// @Tags hotel
+// @Accept  json
+// @Produce  json
+// @Param hotel body entity.Hotel true "Hotel object"
+// @Success 201 {object} entity.Hotel
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) CreateHotel(ctx *gin.Context) {
+	var (
+		body entity.Hotel
+	)
+
+	err := ctx.ShouldBindJSON(&body)
+	if err != nil {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Invalid request body", 400)
+		return
+	}
+
+	if body.Name == "" {
+		h.ReturnError(ctx, config.ErrorBadRequest, "name is required", http.StatusBadRequest)
+		return
+	}
+
+	if body.Timezone == "" {
+		body.Timezone = "UTC"
+	}
+	if body.Currency == "" {
+		body.Currency = "USD"
+	}
+
+	if !h.validateHotel(ctx, body) {
+		return
+	}
+
+	hotel, err := h.UseCase.HotelRepo.Create(ctx, body)
+	if h.HandleDbError(ctx, err, "Error creating hotel") {
+		return
+	}
+
+	ctx.JSON(201, hotel)
+}
+
+// GetHotel godoc
+// @Router /hotel/{id} [get]
+// @Summary Get a hotel by ID
+// @Description Get a hotel by ID
+// @Tags hotel
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Hotel ID"
+// @Success 200 {object} entity.Hotel
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) GetHotel(ctx *gin.Context) {
+	var (
+		req entity.Id
+	)
+
+	req.ID = ctx.Param("id")
+
+	hotel, err := h.UseCase.HotelRepo.GetSingle(ctx, req)
+	if h.HandleDbError(ctx, err, "Error getting hotel") {
+		return
+	}
+
+	ctx.JSON(200, hotel)
+}
+
+// GetHotels godoc
+// @Router /hotel/list [get]
+// @Summary Get a list of hotels
+// @Description Get a list of hotels
+// @Tags hotel
+// @Accept  json
+// @Produce  json
+// @Param page query number true "page"
+// @Param limit query number true "limit"
+// @Param name query string false "name"
+// @Success 200 {object} entity.HotelList
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) GetHotels(ctx *gin.Context) {
+	var (
+		req entity.GetListFilter
+	)
+
+	page := ctx.DefaultQuery("page", "1")
+	limit := ctx.DefaultQuery("limit", "10")
+	name := ctx.DefaultQuery("name", "")
+
+	req.Page, _ = strconv.Atoi(page)
+	req.Limit, _ = strconv.Atoi(limit)
+
+	if name != "" {
+		req.Filters = append(req.Filters, entity.Filter{
+			Column: "name",
+			Type:   "search",
+			Value:  name,
+		})
+	}
+
+	req.OrderBy = append(req.OrderBy, entity.OrderBy{
+		Column: "name",
+		Order:  "asc",
+	})
+
+	hotels, err := h.UseCase.HotelRepo.GetList(ctx, req)
+	if h.HandleDbError(ctx, err, "Error getting hotels") {
+		return
+	}
+
+	ctx.JSON(200, hotels)
+}
+
+// UpdateHotel godoc
+// @Router /hotel [put]
+// @Summary Update a hotel
+// @Description Update a hotel. Admins may only update the hotels they are assigned to.
+// @Security BearerAuth
+// @Tags hotel
+// @Accept  json
+// @Produce  json
+// @Param hotel body entity.Hotel true "Hotel object"
+// @Success 200 {object} entity.Hotel
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) UpdateHotel(ctx *gin.Context) {
+	var (
+		body entity.Hotel
+	)
+
+	err := ctx.ShouldBindJSON(&body)
+	if err != nil {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Invalid request body", 400)
+		return
+	}
+
+	if !h.validateHotel(ctx, body) || !h.canManageHotel(ctx, body.ID) {
+		return
+	}
+
+	hotel, err := h.UseCase.HotelRepo.Update(ctx, body)
+	if h.HandleDbError(ctx, err, "Error updating hotel") {
+		return
+	}
+
+	ctx.JSON(200, hotel)
+}
+
+// DeleteHotel godoc
+// @Router /hotel/{id} [delete]
+// @Summary Delete a hotel
+// @Description Delete a hotel that has no rooms left (superadmin only)
+// @Security BearerAuth
+// @Tags hotel
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Hotel ID"
+// @Success 200 {object} entity.SuccessResponse
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) DeleteHotel(ctx *gin.Context) {
+	var (
+		req entity.Id
+	)
+
+	req.ID = ctx.Param("id")
+
+	err := h.UseCase.HotelRepo.Delete(ctx, req)
+	if h.HandleDbError(ctx, err, "Error deleting hotel") {
+		return
+	}
+
+	ctx.JSON(200, entity.SuccessResponse{
+		Message: "Hotel deleted successfully",
+	})
+}
+
+// GetHotelStaff godoc
+// @Router /hotel/{id}/staff [get]
+// @Summary Get the staff of a hotel
+// @Description Get the admins and staff assigned to a hotel
+// @Security BearerAuth
+// @Tags hotel
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Hotel ID"
+// @Success 200 {object} entity.HotelStaffList
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) GetHotelStaff(ctx *gin.Context) {
+	var (
+		req entity.Id
+	)
+
+	req.ID = ctx.Param("id")
+
+	if !h.canManageHotel(ctx, req.ID) {
+		return
+	}
+
+	staff, err := h.UseCase.HotelRepo.GetStaff(ctx, req)
+	if h.HandleDbError(ctx, err, "Error getting hotel staff") {
+		return
+	}
+
+	ctx.JSON(200, staff)
+}
+
+// AddHotelStaff godoc
+// @Router /hotel/{id}/staff [post]
+// @Summary Assign a user to a hotel
+// @Description Assign an admin or staff member to a hotel. Only superadmins assign admins.
+// @Security BearerAuth
+// @Tags hotel
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Hotel ID"
+// @Param staff body entity.HotelStaff true "Only user_id is read"
+// @Success 201 {object} entity.HotelStaff
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) AddHotelStaff(ctx *gin.Context) {
+	var (
+		body entity.HotelStaff
+	)
+
+	err := ctx.ShouldBindJSON(&body)
+	if err != nil {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Invalid request body", 400)
+		return
+	}
+
+	body.HotelID = ctx.Param("id")
+
+	if !h.canManageHotel(ctx, body.HotelID) {
+		return
+	}
+
+	user, err := h.UseCase.UserRepo.GetSingle(ctx, entity.UserSingleRequest{ID: body.UserID})
+	if h.HandleDbError(ctx, err, "Error getting user") {
+		return
+	}
+
+	switch user.UserRole {
+	case entity.UserRoleReceptionist, entity.UserRoleHousekeeping, entity.UserRoleManager:
+	case entity.UserRoleAdmin:
+		if ctx.GetHeader("user_role") != entity.UserRoleSuperadmin {
+			h.ReturnError(ctx, config.ErrorForbidden, "Only a superadmin can assign admins", http.StatusForbidden)
+			return
+		}
+	default:
+		h.ReturnError(ctx, config.ErrorBadRequest, "Only admins and staff can be assigned to a hotel", http.StatusBadRequest)
+		return
+	}
+
+	err = h.UseCase.HotelRepo.AddStaff(ctx, body)
+	if h.HandleDbError(ctx, err, "Error assigning user to hotel") {
+		return
+	}
+
+	body.FullName = user.FullName
+	body.Email = user.Email
+	body.UserRole = user.UserRole
+
+	ctx.JSON(201, body)
+}
+
+// RemoveHotelStaff godoc
+// @Router /hotel/{id}/staff/{user_id} [delete]
+// @Summary Remove a user from a hotel
+// @Description Unassign an admin or staff member from a hotel. Only superadmins unassign admins.
+// @Security BearerAuth
+// @Tags hotel
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Hotel ID"
+// @Param user_id path string true "User ID"
+// @Success 200 {object} entity.SuccessResponse
+// @Failure 404 {object} entity.ErrorResponse
+func (h *Handler) RemoveHotelStaff(ctx *gin.Context) {
+	req := entity.HotelStaff{
+		HotelID: ctx.Param("id"),
+		UserID:  ctx.Param("user_id"),
+	}
+
+	if !h.canManageHotel(ctx, req.HotelID) {
+		return
+	}
+
+	user, err := h.UseCase.UserRepo.GetSingle(ctx, entity.UserSingleRequest{ID: req.UserID})
+	if h.HandleDbError(ctx, err, "Error getting user") {
+		return
+	}
+
+	if isAdminRole(user.UserRole) && ctx.GetHeader("user_role") != entity.UserRoleSuperadmin {
+		h.ReturnError(ctx, config.ErrorForbidden, "Only a superadmin can unassign admins", http.StatusForbidden)
+		return
+	}
+
+	err = h.UseCase.HotelRepo.RemoveStaff(ctx, req)
+	if h.HandleDbError(ctx, err, "Error removing user from hotel") {
+		return
+	}
+
+	ctx.JSON(200, entity.SuccessResponse{
+		Message: "User removed from hotel successfully",
+	})
+}
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Avazbek-02/Online-Hotel-System/config"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/storage"
+	"github.com/gin-gonic/gin"
+)
+
+// GetMedia godoc
+// @Router /media/{object} [get]
+// @Summary Get a stored file
+// @Description Redirects to a short-lived URL of a stored image, so the bucket doesn't have to be public.
+// @Description Uploads waiting to be imported can't be read.
+// @Tags upload
+// @Param object path string true "Object name"
+// @Success 302
+// @Failure 404 {object} entity.ErrorResponse
+func (h *Handler) GetMedia(ctx *gin.Context) {
+	objectName := strings.TrimPrefix(ctx.Param("object"), "/")
+	if objectName == "" || strings.HasPrefix(objectName, storage.IncomingPrefix) || strings.Contains(objectName, "..") {
+		h.ReturnError(ctx, config.ErrorNotFound, "File not found", http.StatusNotFound)
+		return
+	}
+
+	url, _, err := h.Storage.PresignedGetURL(ctx, objectName)
+	if errors.Is(err, storage.ErrNotSupported) {
+		url = h.Storage.PublicURL(objectName)
+	} else if err != nil {
+		h.Logger.Error(err, "Error presigning download URL")
+		h.ReturnError(ctx, config.ErrorInternalServer, "Error getting file", http.StatusInternalServerError)
+		return
+	}
+
+	ctx.Redirect(http.StatusFound, url)
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/Avazbek-02/Online-Hotel-System/config"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/etc"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/otp"
+	"github.com/gin-gonic/gin"
+)
+
+// purposes of one-time codes, a code issued for one can't be used for another
+const (
+	otpVerifyEmail   = "verify-email"
+	otpResetPassword = "reset-password"
+	otpChangeEmail   = "change-email"
+)
+
+// sendOtp issues a code for purpose and subject and emails it to email.
+func (h *Handler) sendOtp(ctx *gin.Context, purpose, subject, email string) error {
+	return h.OTP.Issue(ctx, purpose, subject, func(code string) error {
+		var (
+			emailBody string
+			err       error
+		)
+		switch purpose {
+		case otpResetPassword:
+			emailBody, err = etc.GeneratePasswordResetEmailBody(code)
+		default:
+			emailBody, err = etc.GenerateOtpEmailBody(code)
+		}
+		if err != nil {
+			return err
+		}
+
+		return etc.SendEmail(h.Config.Gmail.Host, h.Config.Gmail.Port, h.Config.Gmail.Email, h.Config.Gmail.EmailPass, email, emailBody)
+	})
+}
+
+// HandleOtpError writes the response for an error of the OTP store and reports whether there was one.
+func (h *Handler) HandleOtpError(ctx *gin.Context, err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if wait, ok := otp.RetryAfter(err); ok {
+		seconds := int(math.Ceil(wait.Seconds()))
+		ctx.Header("Retry-After", strconv.Itoa(seconds))
+
+		message := "Too many incorrect codes"
+		if errors.Is(err, otp.ErrCooldown) {
+			message = "A code was sent recently"
+		}
+
+		h.ReturnError(ctx, config.ErrorTooManyRequests, fmt.Sprintf("%s, try again in %d seconds", message, seconds), http.StatusTooManyRequests)
+		return true
+	}
+
+	if errors.Is(err, otp.ErrInvalidCode) {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Incorrect or expired otp", http.StatusBadRequest)
+		return true
+	}
+
+	h.Logger.Error(err, "Error handling OTP")
+	h.ReturnError(ctx, config.ErrorInternalServer, "Ooops, something went wrong", http.StatusInternalServerError)
+	return true
+}
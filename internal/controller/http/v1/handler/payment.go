@@ -0,0 +1,407 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/Avazbek-02/Online-Hotel-System/config"
+	"github.com/Avazbek-02/Online-Hotel-System/internal/entity"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v4"
+)
+
+// CreatePayment godoc
+// @Router /payment [post]
+// @Summary Pay for a booking
+// @Description Charge a deposit or (partial) payment for a booking through the payment provider.
+// @Description Requests are idempotent per user and Idempotency-Key header, reusing a key for a different payment is rejected.
+// @Description Admins may record an offline payment by omitting the token.
+// @Security BearerAuth
+// @Tags payment
+// @Accept  json
+// @Produce  json
+// @Param Idempotency-Key header string true "Client generated idempotency key"
+// @Param payment body entity.Payment true "Payment object"
+// @Success 201 {object} entity.Payment
+// @Success 202 {object} entity.Payment
+// @Failure 400 {object} entity.ErrorResponse
+// @Failure 402 {object} entity.ErrorResponse
+// @Failure 422 {object} entity.ErrorResponse
+// @Failure 504 {object} entity.ErrorResponse
+func (h *Handler) CreatePayment(ctx *gin.Context) {
+	var (
+		body entity.Payment
+	)
+
+	err := ctx.ShouldBindJSON(&body)
+	if err != nil {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Invalid request body", 400)
+		return
+	}
+
+	body.IdempotencyKey = ctx.GetHeader("Idempotency-Key")
+	if body.IdempotencyKey == "" {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Idempotency-Key header is required", http.StatusBadRequest)
+		return
+	}
+
+	// keys are the caller's own, another user reusing one gets a payment of their own
+	body.UserID = ctx.GetHeader("sub")
+
+	if body.Type == "" || body.Type == "string" {
+		body.Type = entity.PaymentTypePayment
+	}
+	if body.Type != entity.PaymentTypePayment && body.Type != entity.PaymentTypeDeposit {
+		h.ReturnError(ctx, config.ErrorBadRequest, "type must be deposit or payment", http.StatusBadRequest)
+		return
+	}
+
+	if body.Amount <= 0 {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Amount must be greater than zero", http.StatusBadRequest)
+		return
+	}
+
+	isGuest := ctx.GetHeader("user_role") == "user"
+	if isGuest && body.Token == "" {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Payment token is required", http.StatusBadRequest)
+		return
+	}
+
+	// a retried request returns the payment recorded by the first one
+	payment, err := h.UseCase.PaymentRepo.GetByIdempotencyKey(ctx, body)
+	if err == nil {
+		if !h.canPayFor(ctx, payment.BookingID) {
+			return
+		}
+
+		if payment.BookingID != body.BookingID || payment.Amount != body.Amount || payment.Type != body.Type {
+			h.ReturnError(ctx, config.ErrorIdempotencyMismatch, "Idempotency-Key was already used for a different payment", http.StatusUnprocessableEntity)
+			return
+		}
+
+		// the provider never answered the first attempt, so it is charged again under the same key
+		if payment.Status == entity.PaymentStatusPending && payment.ProviderRef == "" {
+			h.chargePayment(ctx, payment, body.Token)
+			return
+		}
+
+		ctx.JSON(200, payment)
+		return
+	}
+	if !errors.Is(err, pgx.ErrNoRows) && h.HandleDbError(ctx, err, "Error getting payment") {
+		return
+	}
+
+	booking, err := h.UseCase.BookingRepo.GetSingle(ctx, entity.Id{ID: body.BookingID})
+	if h.HandleDbError(ctx, err, "Error getting booking") {
+		return
+	}
+
+	if !h.canPayForBooking(ctx, booking) {
+		return
+	}
+
+	if booking.Status == entity.BookingStatusCancelled {
+		h.ReturnError(ctx, config.ErrorConflict, "Booking is cancelled", http.StatusBadRequest)
+		return
+	}
+
+	// without a token an admin records money received offline, e.g. cash at the front desk
+	body.Status = entity.PaymentStatusPending
+	if body.Token == "" {
+		body.Status = entity.PaymentStatusSucceeded
+	}
+	body.ProviderRef = ""
+
+	payment, err = h.UseCase.PaymentRepo.Create(ctx, body)
+	if errors.Is(err, entity.ErrIdempotencyKeyReused) {
+		h.ReturnError(ctx, config.ErrorIdempotencyMismatch, "Idempotency-Key was already used for a different payment", http.StatusUnprocessableEntity)
+		return
+	}
+	if errors.Is(err, entity.ErrPaymentExceedsBalance) {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Amount exceeds the outstanding balance", http.StatusBadRequest)
+		return
+	}
+	if h.HandleDbError(ctx, err, "Error creating payment") {
+		return
+	}
+
+	if payment.Status == entity.PaymentStatusSucceeded {
+		ctx.JSON(201, payment)
+		return
+	}
+
+	h.chargePayment(ctx, payment, body.Token)
+}
+
+// canPayFor is canPayForBooking for a booking that is looked up by ID.
+func (h *Handler) canPayFor(ctx *gin.Context, bookingID string) bool {
+	booking, err := h.UseCase.BookingRepo.GetSingle(ctx, entity.Id{ID: bookingID})
+	if h.HandleDbError(ctx, err, "Error getting booking") {
+		return false
+	}
+
+	return h.canPayForBooking(ctx, booking)
+}
+
+// canPayForBooking reports whether the caller may pay for a booking, guests only for
+// their own and staff for those of their hotels, and writes the error response when not.
+func (h *Handler) canPayForBooking(ctx *gin.Context, booking entity.Booking) bool {
+	if ctx.GetHeader("user_role") == "user" && booking.UserID != ctx.GetHeader("sub") {
+		h.ReturnError(ctx, config.ErrorForbidden, "You can only pay for your own bookings", http.StatusForbidden)
+		return false
+	}
+
+	return h.canManageHotel(ctx, booking.HotelID)
+}
+
+// chargePayment charges a pending payment through the provider and writes the outcome.
+func (h *Handler) chargePayment(ctx *gin.Context, payment entity.Payment, token string) {
+	result, err := h.UseCase.PaymentProvider.Charge(ctx, entity.ChargeRequest{
+		PaymentID:      payment.ID,
+		BookingID:      payment.BookingID,
+		Amount:         payment.Amount,
+		Token:          token,
+		IdempotencyKey: payment.ID, // client keys are only unique per user
+	})
+	if errors.Is(err, entity.ErrPaymentProviderTimeout) {
+		h.ReturnError(ctx, config.ErrorPaymentTimeout, "Payment provider timed out, retry with the same Idempotency-Key", http.StatusGatewayTimeout)
+		return
+	}
+	if err != nil {
+		h.Logger.Error(err, "Error charging payment")
+		h.ReturnError(ctx, config.ErrorInternalServer, "Payment provider error", http.StatusBadGateway)
+		return
+	}
+
+	payment, err = h.UseCase.PaymentRepo.UpdateStatus(ctx, entity.Payment{
+		ID:          payment.ID,
+		Status:      result.Status,
+		ProviderRef: result.ProviderRef,
+	})
+	if h.HandleDbError(ctx, err, "Error updating payment status") {
+		return
+	}
+
+	if payment.Status == entity.PaymentStatusVoided {
+		if result.Status != entity.PaymentStatusFailed {
+			h.voidCharge(ctx, payment)
+		}
+		h.ReturnError(ctx, config.ErrorConflict, "Booking was cancelled while the payment was processed, the charge is voided", http.StatusConflict)
+		return
+	}
+
+	switch payment.Status {
+	case entity.PaymentStatusFailed:
+		h.ReturnError(ctx, config.ErrorPaymentDeclined, "Payment declined: "+result.FailureReason, http.StatusPaymentRequired)
+	case entity.PaymentStatusPending:
+		ctx.JSON(202, payment)
+	default:
+		ctx.JSON(201, payment)
+	}
+}
+
+// PaymentWebhook godoc
+// @Router /payment/webhook [post]
+// @Summary Payment provider webhook
+// @Description Receives asynchronous payment confirmations signed by the payment provider
+// @Tags payment
+// @Accept  json
+// @Produce  json
+// @Param X-Payment-Signature header string true "Provider signature of the payload"
+// @Success 200 {object} entity.SuccessResponse
+// @Failure 401 {object} entity.ErrorResponse
+func (h *Handler) PaymentWebhook(ctx *gin.Context) {
+	payload, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Invalid request body", 400)
+		return
+	}
+
+	event, err := h.UseCase.PaymentProvider.VerifyWebhook(ctx, payload, ctx.GetHeader(entity.PaymentSignatureHeader))
+	if err != nil {
+		h.Logger.Error(err, "Error verifying payment webhook")
+		h.ReturnError(ctx, config.ErrorUnauthorized, "Invalid webhook signature", http.StatusUnauthorized)
+		return
+	}
+
+	payment, err := h.UseCase.PaymentRepo.GetByProviderRef(ctx, event.ProviderRef)
+	if h.HandleDbError(ctx, err, "Error getting payment") {
+		return
+	}
+
+	// the booking was cancelled before the charge went through
+	if payment.Status == entity.PaymentStatusVoided && event.Status == entity.PaymentStatusSucceeded {
+		h.voidCharge(ctx, payment)
+	}
+
+	// providers retry webhooks, so events for settled payments are acknowledged and ignored
+	if payment.Status == entity.PaymentStatusPending {
+		_, err = h.UseCase.PaymentRepo.UpdateStatus(ctx, entity.Payment{
+			ID:     payment.ID,
+			Status: event.Status,
+		})
+		if h.HandleDbError(ctx, err, "Error updating payment status") {
+			return
+		}
+	}
+
+	ctx.JSON(200, entity.SuccessResponse{
+		Message: "Webhook processed",
+	})
+}
+
+// GetPayment godoc
+// @Router /payment/{id} [get]
+// @Summary Get a payment by ID
+// @Description Get a payment by ID
+// @Security BearerAuth
+// @Tags payment
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Payment ID"
+// @Success 200 {object} entity.Payment
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) GetPayment(ctx *gin.Context) {
+	var (
+		req entity.Id
+	)
+
+	req.ID = ctx.Param("id")
+
+	payment, err := h.UseCase.PaymentRepo.GetSingle(ctx, req)
+	if h.HandleDbError(ctx, err, "Error getting payment") {
+		return
+	}
+
+	booking, err := h.UseCase.BookingRepo.GetSingle(ctx, entity.Id{ID: payment.BookingID})
+	if h.HandleDbError(ctx, err, "Error getting booking") {
+		return
+	}
+
+	if ctx.GetHeader("user_role") == "user" && booking.UserID != ctx.GetHeader("sub") {
+		h.ReturnError(ctx, config.ErrorForbidden, "You can only view your own payments", http.StatusForbidden)
+		return
+	}
+
+	if !h.canManageHotel(ctx, booking.HotelID) {
+		return
+	}
+
+	ctx.JSON(200, payment)
+}
+
+// GetPayments godoc
+// @Router /payment/list [get]
+// @Summary Get a list of payments
+// @Description Get a list of payments. Guests must pass one of their own booking_id, admins and staff
+// @Description not assigned to every hotel one of a booking of their hotels.
+// @Security BearerAuth
+// @Tags payment
+// @Accept  json
+// @Produce  json
+// @Param page query number true "page"
+// @Param limit query number true "limit"
+// @Param booking_id query string false "booking_id"
+// @Param status query string false "status"
+// @Success 200 {object} entity.PaymentList
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) GetPayments(ctx *gin.Context) {
+	var (
+		req entity.GetListFilter
+	)
+
+	page := ctx.DefaultQuery("page", "1")
+	limit := ctx.DefaultQuery("limit", "10")
+	bookingId := ctx.DefaultQuery("booking_id", "")
+	status := ctx.DefaultQuery("status", "")
+
+	if ctx.GetHeader("user_role") == "user" {
+		booking, err := h.UseCase.BookingRepo.GetSingle(ctx, entity.Id{ID: bookingId})
+		if err != nil || booking.UserID != ctx.GetHeader("sub") {
+			h.ReturnError(ctx, config.ErrorForbidden, "You can only view payments of your own bookings", http.StatusForbidden)
+			return
+		}
+	}
+
+	hotelIds, all, err := h.hotelScope(ctx)
+	if h.HandleDbError(ctx, err, "Error getting hotel assignments") {
+		return
+	}
+
+	if !all {
+		booking, err := h.UseCase.BookingRepo.GetSingle(ctx, entity.Id{ID: bookingId})
+		if err != nil || !slices.Contains(hotelIds, booking.HotelID) {
+			h.ReturnError(ctx, config.ErrorForbidden, "You can only view payments of bookings of your hotels", http.StatusForbidden)
+			return
+		}
+	}
+
+	req.Page, _ = strconv.Atoi(page)
+	req.Limit, _ = strconv.Atoi(limit)
+
+	if bookingId != "" {
+		req.Filters = append(req.Filters, entity.Filter{
+			Column: "booking_id",
+			Type:   "eq",
+			Value:  bookingId,
+		})
+	}
+
+	if status != "" {
+		req.Filters = append(req.Filters, entity.Filter{
+			Column: "status",
+			Type:   "eq",
+			Value:  status,
+		})
+	}
+
+	req.OrderBy = append(req.OrderBy, entity.OrderBy{
+		Column: "created_at",
+		Order:  "desc",
+	})
+
+	payments, err := h.UseCase.PaymentRepo.GetList(ctx, req)
+	if h.HandleDbError(ctx, err, "Error getting payments") {
+		return
+	}
+
+	ctx.JSON(200, payments)
+}
+
+// GetBookingBalance godoc
+// @Router /booking/{id}/balance [get]
+// @Summary Get the balance of a booking
+// @Description Returns the total price, the paid amount and the outstanding balance of a booking
+// @Security BearerAuth
+// @Tags booking
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Booking ID"
+// @Success 200 {object} entity.BookingBalance
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) GetBookingBalance(ctx *gin.Context) {
+	booking, err := h.UseCase.BookingRepo.GetSingle(ctx, entity.Id{ID: ctx.Param("id")})
+	if h.HandleDbError(ctx, err, "Error getting booking") {
+		return
+	}
+
+	if ctx.GetHeader("user_role") == "user" && booking.UserID != ctx.GetHeader("sub") {
+		h.ReturnError(ctx, config.ErrorForbidden, "You can only view your own bookings", http.StatusForbidden)
+		return
+	}
+
+	if !h.canManageHotel(ctx, booking.HotelID) {
+		return
+	}
+
+	balance, err := h.UseCase.PaymentRepo.GetBalance(ctx, entity.Id{ID: booking.ID})
+	if h.HandleDbError(ctx, err, "Error getting booking balance") {
+		return
+	}
+
+	ctx.JSON(200, balance)
+}
@@ -0,0 +1,308 @@
+package handler
+
+import (
+	"net/http"
+	"regexp"
+	"slices"
+
+	"github.com/Avazbek-02/Online-Hotel-System/config"
+	"github.com/Avazbek-02/Online-Hotel-System/internal/entity"
+	"github.com/casbin/casbin/util"
+	"github.com/gin-gonic/gin"
+)
+
+// policyRoutes returns the registered routes a policy lets through, the same way the
+// matcher in config/rbac.conf compares them.
+func (h *Handler) policyRoutes(policy entity.Policy) []string {
+	action, err := regexp.Compile(policy.Action)
+	if err != nil {
+		return nil
+	}
+
+	var routes []string
+	for _, route := range h.Routes {
+		if util.KeyMatch(route.Path, policy.Object) && action.MatchString(route.Method) {
+			routes = append(routes, route.Method+" "+route.Path)
+		}
+	}
+
+	return routes
+}
+
+// policyChanged reloads the policy on this instance and announces the change to the others.
+func (h *Handler) policyChanged(ctx *gin.Context) bool {
+	err := h.Enforcer.Changed(ctx)
+	if err != nil {
+		h.Logger.Error(err, "Error reloading policy")
+		h.ReturnError(ctx, config.ErrorInternalServer, "The change was saved but the policy could not be reloaded", http.StatusInternalServerError)
+		return true
+	}
+
+	return false
+}
+
+// bindPolicy reads and validates a policy from the request body.
+func (h *Handler) bindPolicy(ctx *gin.Context) (entity.Policy, bool) {
+	var body entity.Policy
+
+	err := ctx.ShouldBindJSON(&body)
+	if err != nil {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Invalid request body", 400)
+		return entity.Policy{}, false
+	}
+
+	if body.Subject == "" || body.Object == "" || body.Action == "" {
+		h.ReturnError(ctx, config.ErrorBadRequest, "subject, object and action are required", http.StatusBadRequest)
+		return entity.Policy{}, false
+	}
+
+	if _, err = regexp.Compile(body.Action); err != nil {
+		h.ReturnError(ctx, config.ErrorBadRequest, "action must be a regular expression such as GET|POST", http.StatusBadRequest)
+		return entity.Policy{}, false
+	}
+
+	return body, true
+}
+
+// bindRoleInheritance reads and validates a role inheritance from the request body.
+func (h *Handler) bindRoleInheritance(ctx *gin.Context) (entity.RoleInheritance, bool) {
+	var body entity.RoleInheritance
+
+	err := ctx.ShouldBindJSON(&body)
+	if err != nil {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Invalid request body", 400)
+		return entity.RoleInheritance{}, false
+	}
+
+	if body.Role == "" || body.Parent == "" {
+		h.ReturnError(ctx, config.ErrorBadRequest, "role and parent are required", http.StatusBadRequest)
+		return entity.RoleInheritance{}, false
+	}
+
+	return body, true
+}
+
+// GetPolicies godoc
+// @Router /policy/list [get]
+// @Summary Get the access policies
+// @Description Get the policies currently enforced, with the routes each one lets through.
+// @Description Policies that match no registered route are marked stale. Superadmin only.
+// @Security BearerAuth
+// @Tags policy
+// @Accept  json
+// @Produce  json
+// @Param subject query string false "subject"
+// @Param stale query bool false "only stale policies"
+// @Success 200 {object} entity.PolicyList
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) GetPolicies(ctx *gin.Context) {
+	var (
+		response  = entity.PolicyList{Items: []entity.Policy{}}
+		subject   = ctx.Query("subject")
+		onlyStale = ctx.Query("stale") == "true"
+	)
+
+	for _, rule := range h.Enforcer.GetPolicy() {
+		if len(rule) < 3 || (subject != "" && rule[0] != subject) {
+			continue
+		}
+
+		policy := entity.Policy{
+			Subject: rule[0],
+			Object:  rule[1],
+			Action:  rule[2],
+		}
+		policy.Routes = h.policyRoutes(policy)
+		policy.Stale = len(policy.Routes) == 0
+
+		if onlyStale && !policy.Stale {
+			continue
+		}
+
+		response.Items = append(response.Items, policy)
+	}
+
+	response.Count = len(response.Items)
+	ctx.JSON(200, response)
+}
+
+// CreatePolicy godoc
+// @Router /policy [post]
+// @Summary Add an access policy
+// @Description Allow a role to call the routes matching object (e.g. /v1/booking/*) with the methods matching action (e.g. GET|POST).
+// @Description Takes effect on every instance. Superadmin only.
+// @Security BearerAuth
+// @Tags policy
+// @Accept  json
+// @Produce  json
+// @Param policy body entity.Policy true "Policy object"
+// @Success 201 {object} entity.Policy
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) CreatePolicy(ctx *gin.Context) {
+	body, ok := h.bindPolicy(ctx)
+	if !ok {
+		return
+	}
+
+	body.Routes = h.policyRoutes(body)
+	if len(body.Routes) == 0 {
+		h.ReturnError(ctx, config.ErrorBadRequest, "The policy matches no registered route", http.StatusBadRequest)
+		return
+	}
+
+	err := h.UseCase.PolicyRepo.CreatePolicy(ctx, body)
+	if h.HandleDbError(ctx, err, "Error creating policy") {
+		return
+	}
+
+	if h.policyChanged(ctx) {
+		return
+	}
+
+	ctx.JSON(201, body)
+}
+
+// DeletePolicy godoc
+// @Router /policy [delete]
+// @Summary Remove an access policy
+// @Description Remove a policy, stale ones included. Takes effect on every instance. Superadmin only.
+// @Security BearerAuth
+// @Tags policy
+// @Accept  json
+// @Produce  json
+// @Param policy body entity.Policy true "Policy object"
+// @Success 200 {object} entity.SuccessResponse
+// @Failure 404 {object} entity.ErrorResponse
+func (h *Handler) DeletePolicy(ctx *gin.Context) {
+	body, ok := h.bindPolicy(ctx)
+	if !ok {
+		return
+	}
+
+	err := h.UseCase.PolicyRepo.DeletePolicy(ctx, body)
+	if h.HandleDbError(ctx, err, "Error deleting policy") {
+		return
+	}
+
+	if h.policyChanged(ctx) {
+		return
+	}
+
+	ctx.JSON(200, entity.SuccessResponse{
+		Message: "Policy deleted successfully",
+	})
+}
+
+// GetRoleInheritances godoc
+// @Router /policy/role/list [get]
+// @Summary Get the role inheritance
+// @Description Get which roles inherit the policies of which other roles. Superadmin only.
+// @Security BearerAuth
+// @Tags policy
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} entity.RoleInheritanceList
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) GetRoleInheritances(ctx *gin.Context) {
+	response := entity.RoleInheritanceList{Items: []entity.RoleInheritance{}}
+
+	for _, rule := range h.Enforcer.GetGroupingPolicy() {
+		if len(rule) < 2 {
+			continue
+		}
+
+		response.Items = append(response.Items, entity.RoleInheritance{
+			Role:   rule[0],
+			Parent: rule[1],
+		})
+	}
+
+	response.Count = len(response.Items)
+	ctx.JSON(200, response)
+}
+
+// CreateRoleInheritance godoc
+// @Router /policy/role [post]
+// @Summary Make a role inherit another
+// @Description Give role every policy of parent. Takes effect on every instance. Superadmin only.
+// @Security BearerAuth
+// @Tags policy
+// @Accept  json
+// @Produce  json
+// @Param role body entity.RoleInheritance true "Role inheritance object"
+// @Success 201 {object} entity.RoleInheritance
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) CreateRoleInheritance(ctx *gin.Context) {
+	body, ok := h.bindRoleInheritance(ctx)
+	if !ok {
+		return
+	}
+
+	// parent already inheriting from role would make a cycle
+	if body.Role == body.Parent || slices.Contains(h.Enforcer.GetImplicitRolesForUser(body.Parent), body.Role) {
+		h.ReturnError(ctx, config.ErrorBadRequest, "A role can't inherit from a role that inherits from it", http.StatusBadRequest)
+		return
+	}
+
+	err := h.UseCase.PolicyRepo.CreateRoleInheritance(ctx, body)
+	if h.HandleDbError(ctx, err, "Error creating role inheritance") {
+		return
+	}
+
+	if h.policyChanged(ctx) {
+		return
+	}
+
+	ctx.JSON(201, body)
+}
+
+// DeleteRoleInheritance godoc
+// @Router /policy/role [delete]
+// @Summary Stop a role inheriting another
+// @Description Takes effect on every instance. Superadmin only.
+// @Security BearerAuth
+// @Tags policy
+// @Accept  json
+// @Produce  json
+// @Param role body entity.RoleInheritance true "Role inheritance object"
+// @Success 200 {object} entity.SuccessResponse
+// @Failure 404 {object} entity.ErrorResponse
+func (h *Handler) DeleteRoleInheritance(ctx *gin.Context) {
+	body, ok := h.bindRoleInheritance(ctx)
+	if !ok {
+		return
+	}
+
+	err := h.UseCase.PolicyRepo.DeleteRoleInheritance(ctx, body)
+	if h.HandleDbError(ctx, err, "Error deleting role inheritance") {
+		return
+	}
+
+	if h.policyChanged(ctx) {
+		return
+	}
+
+	ctx.JSON(200, entity.SuccessResponse{
+		Message: "Role inheritance deleted successfully",
+	})
+}
+
+// ReloadPolicies godoc
+// @Router /policy/reload [put]
+// @Summary Reload the access policy
+// @Description Reload the policy from the database on every instance, e.g. after editing casbin_rule by hand. Superadmin only.
+// @Security BearerAuth
+// @Tags policy
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} entity.SuccessResponse
+// @Failure 500 {object} entity.ErrorResponse
+func (h *Handler) ReloadPolicies(ctx *gin.Context) {
+	if h.policyChanged(ctx) {
+		return
+	}
+
+	ctx.JSON(200, entity.SuccessResponse{
+		Message: "Policy reloaded successfully",
+	})
+}
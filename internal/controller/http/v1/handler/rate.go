@@ -0,0 +1,494 @@
+package handler
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/Avazbek-02/Online-Hotel-System/config"
+	"github.com/Avazbek-02/Online-Hotel-System/internal/entity"
+	"github.com/gin-gonic/gin"
+)
+
+// maxQuoteNights keeps a quote, and so a stay, to at most a year.
+const maxQuoteNights = 366
+
+// priceNights prices every night of [checkIn, checkOut) from the rate calendar of a room type and
+// returns the minimum stay for arriving on checkIn. An override fixes the price of its night,
+// otherwise the season starting last, or the base price, is adjusted by the day-of-week modifier.
+func priceNights(calendar entity.RateCalendar, checkIn, checkOut time.Time) ([]entity.NightlyRate, int) {
+	overrides := make(map[string]entity.RateOverride, len(calendar.Overrides))
+	for _, override := range calendar.Overrides {
+		overrides[override.Date] = override
+	}
+
+	modifiers := make(map[int]float64, len(calendar.DayModifiers))
+	for _, modifier := range calendar.DayModifiers {
+		modifiers[modifier.Weekday] = modifier.Percent
+	}
+
+	var (
+		nights  []entity.NightlyRate
+		minStay = calendar.MinStay
+	)
+
+	for night := checkIn; night.Before(checkOut); night = night.AddDate(0, 0, 1) {
+		date := night.Format(time.DateOnly)
+		rate := entity.NightlyRate{Date: date, Price: calendar.BasePrice, Source: "base"}
+		nightMinStay := 0
+
+		var season *entity.RateSeason
+		for i := range calendar.Seasons {
+			s := &calendar.Seasons[i]
+			if s.StartDate <= date && date <= s.EndDate && (season == nil || s.StartDate > season.StartDate) {
+				season = s
+			}
+		}
+
+		if season != nil {
+			rate.Price, rate.Source, rate.Season = season.Price, "season", season.Name
+			nightMinStay = season.MinStay
+		}
+
+		override, ok := overrides[date]
+		if ok && override.Price > 0 {
+			rate.Price, rate.Source, rate.Season = override.Price, "override", ""
+		} else if percent := modifiers[int(night.Weekday())]; percent != 0 {
+			rate.Price *= 1 + percent/100
+			rate.DayModifier = percent
+		}
+		if ok && override.MinStay > 0 {
+			nightMinStay = override.MinStay
+		}
+
+		if night.Equal(checkIn) && nightMinStay > 0 {
+			minStay = nightMinStay
+		}
+
+		rate.Price = math.Round(rate.Price*100) / 100
+		nights = append(nights, rate)
+	}
+
+	return nights, minStay
+}
+
+// quoteStay prices a stay in a room type and writes the error response when it can't be booked
+// for that long.
+func (h *Handler) quoteStay(ctx *gin.Context, roomType entity.RoomType, checkIn, checkOut time.Time) (entity.PriceQuote, bool) {
+	nights := int(stayNights(checkIn, checkOut))
+	if nights > maxQuoteNights {
+		h.ReturnError(ctx, config.ErrorBadRequest, fmt.Sprintf("A stay can't be longer than %d nights", maxQuoteNights), http.StatusBadRequest)
+		return entity.PriceQuote{}, false
+	}
+
+	hotel, err := h.UseCase.HotelRepo.GetSingle(ctx, entity.Id{ID: roomType.HotelID})
+	if h.HandleDbError(ctx, err, "Error getting hotel") {
+		return entity.PriceQuote{}, false
+	}
+
+	calendar, err := h.UseCase.RateRepo.GetCalendar(ctx, entity.RateCalendarRequest{
+		RoomTypeID: roomType.ID,
+		From:       checkIn.Format(time.DateOnly),
+		To:         checkOut.Format(time.DateOnly),
+	})
+	if h.HandleDbError(ctx, err, "Error getting rates") {
+		return entity.PriceQuote{}, false
+	}
+
+	quote := entity.PriceQuote{
+		RoomTypeID:   roomType.ID,
+		CheckInDate:  checkIn.Format(time.DateOnly),
+		CheckOutDate: checkOut.Format(time.DateOnly),
+		Currency:     hotel.Currency,
+	}
+	quote.Nights, quote.MinStay = priceNights(calendar, checkIn, checkOut)
+
+	if nights < quote.MinStay {
+		h.ReturnError(ctx, config.ErrorBadRequest, fmt.Sprintf("Stays arriving on %s must be at least %d nights", quote.CheckInDate, quote.MinStay), http.StatusBadRequest)
+		return entity.PriceQuote{}, false
+	}
+
+	for _, night := range quote.Nights {
+		quote.Total += night.Price
+	}
+	quote.Total = math.Round(quote.Total*100) / 100
+
+	return quote, true
+}
+
+// canManageRoomType loads a room type and checks the caller may act on its hotel, writing the
+// error response when not.
+func (h *Handler) canManageRoomType(ctx *gin.Context, roomTypeID string) (entity.RoomType, bool) {
+	roomType, err := h.UseCase.RoomTypeRepo.GetSingle(ctx, entity.Id{ID: roomTypeID})
+	if h.HandleDbError(ctx, err, "Error getting room type") {
+		return entity.RoomType{}, false
+	}
+
+	return roomType, h.canManageHotel(ctx, roomType.HotelID)
+}
+
+// validateRateSeason checks the fields of a season that are set.
+func (h *Handler) validateRateSeason(ctx *gin.Context, body entity.RateSeason) bool {
+	for _, date := range []string{body.StartDate, body.EndDate} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse(time.DateOnly, date); err != nil {
+			h.ReturnError(ctx, config.ErrorBadRequest, "start_date and end_date must be in YYYY-MM-DD format", http.StatusBadRequest)
+			return false
+		}
+	}
+
+	if body.StartDate != "" && body.EndDate != "" && body.EndDate < body.StartDate {
+		h.ReturnError(ctx, config.ErrorBadRequest, "end_date can't be before start_date", http.StatusBadRequest)
+		return false
+	}
+
+	if body.Price < 0 || body.MinStay < 0 {
+		h.ReturnError(ctx, config.ErrorBadRequest, "price and min_stay can't be negative", http.StatusBadRequest)
+		return false
+	}
+
+	return true
+}
+
+// QuotePrice godoc
+// @Router /room-type/{id}/quote [get]
+// @Summary Quote the price of a stay
+// @Description Prices every night of the stay from the rate calendar of the room type and returns the total
+// @Description a booking made now would be charged. Fails when the stay is shorter than the minimum stay.
+// @Tags room-type
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Room type ID"
+// @Param check_in_date query string true "Check-in date (YYYY-MM-DD)"
+// @Param check_out_date query string true "Check-out date (YYYY-MM-DD)"
+// @Success 200 {object} entity.PriceQuote
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) QuotePrice(ctx *gin.Context) {
+	checkIn, checkOut, err := parseStayDates(ctx.Query("check_in_date"), ctx.Query("check_out_date"))
+	if err != nil {
+		h.ReturnError(ctx, config.ErrorBadRequest, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	roomType, err := h.UseCase.RoomTypeRepo.GetSingle(ctx, entity.Id{ID: ctx.Param("id")})
+	if h.HandleDbError(ctx, err, "Error getting room type") {
+		return
+	}
+
+	quote, ok := h.quoteStay(ctx, roomType, checkIn, checkOut)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(200, quote)
+}
+
+// GetRates godoc
+// @Router /room-type/{id}/rates [get]
+// @Summary Get the rate calendar of a room type
+// @Description Base price, minimum stay, seasons, day-of-week modifiers and overrides of a room type.
+// @Description With from and to only what applies to the nights in between is returned.
+// @Tags room-type
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Room type ID"
+// @Param from query string false "From date (YYYY-MM-DD)"
+// @Param to query string false "To date (YYYY-MM-DD), exclusive"
+// @Success 200 {object} entity.RateCalendar
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) GetRates(ctx *gin.Context) {
+	var (
+		req entity.RateCalendarRequest
+	)
+
+	req.RoomTypeID = ctx.Param("id")
+	req.From = ctx.Query("from")
+	req.To = ctx.Query("to")
+
+	if req.From != "" || req.To != "" {
+		if _, _, err := parseStayDates(req.From, req.To); err != nil {
+			h.ReturnError(ctx, config.ErrorBadRequest, "from and to must be YYYY-MM-DD dates with to after from", http.StatusBadRequest)
+			return
+		}
+	}
+
+	calendar, err := h.UseCase.RateRepo.GetCalendar(ctx, req)
+	if h.HandleDbError(ctx, err, "Error getting rates") {
+		return
+	}
+
+	ctx.JSON(200, calendar)
+}
+
+// CreateRateSeason godoc
+// @Router /room-type/{id}/seasons [post]
+// @Summary Add a season to a room type
+// @Description Sets the nightly price, and optionally the minimum stay, of a room type for a date range.
+// @Description Seasons may nest, the one starting last applies. Existing bookings keep their price.
+// @Security BearerAuth
+// @Tags room-type
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Room type ID"
+// @Param season body entity.RateSeason true "Season object"
+// @Success 201 {object} entity.RateSeason
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) CreateRateSeason(ctx *gin.Context) {
+	var (
+		body entity.RateSeason
+	)
+
+	err := ctx.ShouldBindJSON(&body)
+	if err != nil {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Invalid request body", 400)
+		return
+	}
+
+	body.RoomTypeID = ctx.Param("id")
+
+	if body.Name == "" || body.StartDate == "" || body.EndDate == "" || body.Price <= 0 {
+		h.ReturnError(ctx, config.ErrorBadRequest, "name, start_date, end_date and a positive price are required", http.StatusBadRequest)
+		return
+	}
+
+	if !h.validateRateSeason(ctx, body) {
+		return
+	}
+
+	if _, ok := h.canManageRoomType(ctx, body.RoomTypeID); !ok {
+		return
+	}
+
+	season, err := h.UseCase.RateRepo.CreateSeason(ctx, body)
+	if h.HandleDbError(ctx, err, "Error creating season") {
+		return
+	}
+
+	ctx.JSON(201, season)
+}
+
+// UpdateRateSeason godoc
+// @Router /room-type/{id}/seasons [put]
+// @Summary Update a season of a room type
+// @Description Update a season of a room type. Existing bookings keep their price.
+// @Security BearerAuth
+// @Tags room-type
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Room type ID"
+// @Param season body entity.RateSeason true "Season object"
+// @Success 200 {object} entity.RateSeason
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) UpdateRateSeason(ctx *gin.Context) {
+	var (
+		body entity.RateSeason
+	)
+
+	err := ctx.ShouldBindJSON(&body)
+	if err != nil {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Invalid request body", 400)
+		return
+	}
+
+	body.RoomTypeID = ctx.Param("id")
+
+	if !h.validateRateSeason(ctx, body) {
+		return
+	}
+
+	if _, ok := h.canManageRoomType(ctx, body.RoomTypeID); !ok {
+		return
+	}
+
+	season, err := h.UseCase.RateRepo.UpdateSeason(ctx, body)
+	if h.HandleDbError(ctx, err, "Error updating season") {
+		return
+	}
+
+	ctx.JSON(200, season)
+}
+
+// DeleteRateSeason godoc
+// @Router /room-type/{id}/seasons/{season_id} [delete]
+// @Summary Delete a season of a room type
+// @Description Delete a season of a room type. Existing bookings keep their price.
+// @Security BearerAuth
+// @Tags room-type
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Room type ID"
+// @Param season_id path string true "Season ID"
+// @Success 200 {object} entity.SuccessResponse
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) DeleteRateSeason(ctx *gin.Context) {
+	var (
+		req entity.RateSeason
+	)
+
+	req.RoomTypeID = ctx.Param("id")
+	req.ID = ctx.Param("season_id")
+
+	if _, ok := h.canManageRoomType(ctx, req.RoomTypeID); !ok {
+		return
+	}
+
+	err := h.UseCase.RateRepo.DeleteSeason(ctx, req)
+	if h.HandleDbError(ctx, err, "Error deleting season") {
+		return
+	}
+
+	ctx.JSON(200, entity.SuccessResponse{
+		Message: "Season deleted successfully",
+	})
+}
+
+// SetRateDayModifiers godoc
+// @Router /room-type/{id}/day-modifiers [put]
+// @Summary Set the day-of-week modifiers of a room type
+// @Description Replaces the day-of-week modifiers of a room type, e.g. +20 percent on Fridays (5) and Saturdays (6).
+// @Description Weekdays not listed are charged the season or base price. Existing bookings keep their price.
+// @Security BearerAuth
+// @Tags room-type
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Room type ID"
+// @Param day_modifiers body entity.RateDayModifierList true "Day-of-week modifiers"
+// @Success 200 {object} entity.RateDayModifierList
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) SetRateDayModifiers(ctx *gin.Context) {
+	var (
+		body entity.RateDayModifierList
+	)
+
+	err := ctx.ShouldBindJSON(&body)
+	if err != nil {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Invalid request body", 400)
+		return
+	}
+
+	body.RoomTypeID = ctx.Param("id")
+
+	seen := make(map[int]bool, len(body.Items))
+	for _, item := range body.Items {
+		if item.Weekday < 0 || item.Weekday > 6 || seen[item.Weekday] {
+			h.ReturnError(ctx, config.ErrorBadRequest, "Each weekday from 0 (Sunday) to 6 can be listed once", http.StatusBadRequest)
+			return
+		}
+		if item.Percent <= -100 {
+			h.ReturnError(ctx, config.ErrorBadRequest, "percent must be greater than -100", http.StatusBadRequest)
+			return
+		}
+
+		seen[item.Weekday] = true
+	}
+
+	if _, ok := h.canManageRoomType(ctx, body.RoomTypeID); !ok {
+		return
+	}
+
+	err = h.UseCase.RateRepo.SetDayModifiers(ctx, body)
+	if h.HandleDbError(ctx, err, "Error setting day modifiers") {
+		return
+	}
+
+	if body.Items == nil {
+		body.Items = []entity.RateDayModifier{}
+	}
+
+	ctx.JSON(200, body)
+}
+
+// SetRateOverride godoc
+// @Router /room-type/{id}/overrides [put]
+// @Summary Override the rate of a night
+// @Description Fixes the price and/or minimum stay of a room type for one night, replacing any override it had.
+// @Description A price of 0 keeps the calculated price. Existing bookings keep their price.
+// @Security BearerAuth
+// @Tags room-type
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Room type ID"
+// @Param override body entity.RateOverride true "Override object"
+// @Success 200 {object} entity.RateOverride
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) SetRateOverride(ctx *gin.Context) {
+	var (
+		body entity.RateOverride
+	)
+
+	err := ctx.ShouldBindJSON(&body)
+	if err != nil {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Invalid request body", 400)
+		return
+	}
+
+	body.RoomTypeID = ctx.Param("id")
+
+	if _, err = time.Parse(time.DateOnly, body.Date); err != nil {
+		h.ReturnError(ctx, config.ErrorBadRequest, "date must be in YYYY-MM-DD format", http.StatusBadRequest)
+		return
+	}
+
+	if body.Price < 0 || body.MinStay < 0 {
+		h.ReturnError(ctx, config.ErrorBadRequest, "price and min_stay can't be negative", http.StatusBadRequest)
+		return
+	}
+
+	if body.Price == 0 && body.MinStay == 0 {
+		h.ReturnError(ctx, config.ErrorBadRequest, "price or min_stay is required", http.StatusBadRequest)
+		return
+	}
+
+	if _, ok := h.canManageRoomType(ctx, body.RoomTypeID); !ok {
+		return
+	}
+
+	override, err := h.UseCase.RateRepo.SetOverride(ctx, body)
+	if h.HandleDbError(ctx, err, "Error setting override") {
+		return
+	}
+
+	ctx.JSON(200, override)
+}
+
+// DeleteRateOverride godoc
+// @Router /room-type/{id}/overrides/{date} [delete]
+// @Summary Remove the override of a night
+// @Description Remove the override of a night, its rate follows from the season and day-of-week modifiers again
+// @Security BearerAuth
+// @Tags room-type
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Room type ID"
+// @Param date path string true "Date (YYYY-MM-DD)"
+// @Success 200 {object} entity.SuccessResponse
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) DeleteRateOverride(ctx *gin.Context) {
+	var (
+		req entity.RateOverride
+	)
+
+	req.RoomTypeID = ctx.Param("id")
+	req.Date = ctx.Param("date")
+
+	if _, err := time.Parse(time.DateOnly, req.Date); err != nil {
+		h.ReturnError(ctx, config.ErrorBadRequest, "date must be in YYYY-MM-DD format", http.StatusBadRequest)
+		return
+	}
+
+	if _, ok := h.canManageRoomType(ctx, req.RoomTypeID); !ok {
+		return
+	}
+
+	err := h.UseCase.RateRepo.DeleteOverride(ctx, req)
+	if h.HandleDbError(ctx, err, "Error deleting override") {
+		return
+	}
+
+	ctx.JSON(200, entity.SuccessResponse{
+		Message: "Override deleted successfully",
+	})
+}
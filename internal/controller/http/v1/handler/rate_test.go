@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Avazbek-02/Online-Hotel-System/internal/entity"
+)
+
+func date(t *testing.T, value string) time.Time {
+	t.Helper()
+
+	d, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestPriceNights(t *testing.T) {
+	// 2026-12-23 is a Wednesday
+	winter := entity.RateSeason{Name: "winter", StartDate: "2026-12-01", EndDate: "2027-02-28", Price: 120, MinStay: 2}
+	christmas := entity.RateSeason{Name: "christmas", StartDate: "2026-12-24", EndDate: "2026-12-26", Price: 200, MinStay: 3}
+	friday := entity.RateDayModifier{Weekday: int(time.Friday), Percent: 25}
+
+	tests := []struct {
+		name     string
+		calendar entity.RateCalendar
+		checkIn  string
+		checkOut string
+		prices   []float64
+		sources  []string
+		minStay  int
+	}{
+		{
+			name:     "base price",
+			calendar: entity.RateCalendar{BasePrice: 100, MinStay: 1},
+			checkIn:  "2026-11-02",
+			checkOut: "2026-11-04",
+			prices:   []float64{100, 100},
+			sources:  []string{"base", "base"},
+			minStay:  1,
+		},
+		{
+			name:     "nested seasons, the later start wins",
+			calendar: entity.RateCalendar{BasePrice: 100, MinStay: 1, Seasons: []entity.RateSeason{winter, christmas}},
+			checkIn:  "2026-12-23",
+			checkOut: "2026-12-28",
+			prices:   []float64{120, 200, 200, 200, 120},
+			sources:  []string{"season", "season", "season", "season", "season"},
+			minStay:  2,
+		},
+		{
+			name:     "weekday modifier on a season price",
+			calendar: entity.RateCalendar{BasePrice: 100, MinStay: 1, Seasons: []entity.RateSeason{winter}, DayModifiers: []entity.RateDayModifier{friday}},
+			checkIn:  "2026-12-03",
+			checkOut: "2026-12-05",
+			prices:   []float64{120, 150},
+			sources:  []string{"season", "season"},
+			minStay:  2,
+		},
+		{
+			name: "override price suppresses the weekday modifier",
+			calendar: entity.RateCalendar{BasePrice: 100, MinStay: 1, DayModifiers: []entity.RateDayModifier{friday},
+				Overrides: []entity.RateOverride{{Date: "2026-11-06", Price: 90}}},
+			checkIn:  "2026-11-05",
+			checkOut: "2026-11-08",
+			prices:   []float64{100, 90, 100},
+			sources:  []string{"base", "override", "base"},
+			minStay:  1,
+		},
+		{
+			name: "override with only a minimum stay keeps the calculated price",
+			calendar: entity.RateCalendar{BasePrice: 100, MinStay: 1, Seasons: []entity.RateSeason{winter}, DayModifiers: []entity.RateDayModifier{friday},
+				Overrides: []entity.RateOverride{{Date: "2026-12-04", MinStay: 4}}},
+			checkIn:  "2026-12-04",
+			checkOut: "2026-12-06",
+			prices:   []float64{150, 120},
+			sources:  []string{"season", "season"},
+			minStay:  4,
+		},
+		{
+			name:     "minimum stay comes from the arrival night",
+			calendar: entity.RateCalendar{BasePrice: 100, MinStay: 1, Seasons: []entity.RateSeason{christmas}},
+			checkIn:  "2026-12-22",
+			checkOut: "2026-12-26",
+			prices:   []float64{100, 100, 200, 200},
+			sources:  []string{"base", "base", "season", "season"},
+			minStay:  1,
+		},
+		{
+			name: "prices are rounded to cents",
+			calendar: entity.RateCalendar{BasePrice: 99.99, MinStay: 1,
+				DayModifiers: []entity.RateDayModifier{{Weekday: int(time.Monday), Percent: 12.5}}},
+			checkIn:  "2026-11-02",
+			checkOut: "2026-11-03",
+			prices:   []float64{112.49},
+			sources:  []string{"base"},
+			minStay:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nights, minStay := priceNights(tt.calendar, date(t, tt.checkIn), date(t, tt.checkOut))
+
+			if minStay != tt.minStay {
+				t.Errorf("min stay = %d, want %d", minStay, tt.minStay)
+			}
+			if len(nights) != len(tt.prices) {
+				t.Fatalf("got %d nights, want %d", len(nights), len(tt.prices))
+			}
+
+			for i, night := range nights {
+				if night.Price != tt.prices[i] || night.Source != tt.sources[i] {
+					t.Errorf("night %s = %.2f from %s, want %.2f from %s", night.Date, night.Price, night.Source, tt.prices[i], tt.sources[i])
+				}
+			}
+		})
+	}
+}
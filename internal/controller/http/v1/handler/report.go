@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/Avazbek-02/Online-Hotel-System/config"
+	"github.com/Avazbek-02/Online-Hotel-System/internal/entity"
+	"github.com/gin-gonic/gin"
+)
+
+// maxReportDays keeps a report to a range the occupancy queries handle comfortably.
+const maxReportDays = 366
+
+// GetReportSummary godoc
+// @Router /report/summary [get]
+// @Summary Get the occupancy and revenue report
+// @Description Occupancy, room revenue, ADR, RevPAR and cash movements for the nights from `from` up to, not including, `to`.
+// @Description Defaults to the current month. Managers and admins only, for one of their hotels unless they are assigned to every hotel.
+// @Security BearerAuth
+// @Tags report
+// @Accept  json
+// @Produce  json
+// @Param hotel_id query string false "Hotel ID, every hotel when empty"
+// @Param from query string false "First night (YYYY-MM-DD)"
+// @Param to query string false "Day after the last night (YYYY-MM-DD)"
+// @Success 200 {object} entity.ReportSummary
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) GetReportSummary(ctx *gin.Context) {
+	now := time.Now()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	req := entity.ReportRequest{
+		HotelID: ctx.Query("hotel_id"),
+		From:    ctx.DefaultQuery("from", monthStart.Format(time.DateOnly)),
+		To:      ctx.DefaultQuery("to", monthStart.AddDate(0, 1, 0).Format(time.DateOnly)),
+	}
+
+	from, to, err := parseStayDates(req.From, req.To)
+	if err != nil {
+		h.ReturnError(ctx, config.ErrorBadRequest, "from and to must be dates in YYYY-MM-DD format, to after from", http.StatusBadRequest)
+		return
+	}
+
+	if stayNights(from, to) > maxReportDays {
+		h.ReturnError(ctx, config.ErrorBadRequest, "A report can cover at most a year", http.StatusBadRequest)
+		return
+	}
+
+	hotelIDs, all, err := h.hotelScope(ctx)
+	if h.HandleDbError(ctx, err, "Error getting hotel assignments") {
+		return
+	}
+
+	if !all && !slices.Contains(hotelIDs, req.HotelID) {
+		h.ReturnError(ctx, config.ErrorForbidden, "hotel_id must be one of your hotels", http.StatusForbidden)
+		return
+	}
+
+	report, err := h.UseCase.ReportRepo.Summary(ctx, req)
+	if h.HandleDbError(ctx, err, "Error getting report") {
+		return
+	}
+
+	ctx.JSON(200, report)
+}
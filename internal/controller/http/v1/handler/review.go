@@ -0,0 +1,231 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Avazbek-02/Online-Hotel-System/config"
+	"github.com/Avazbek-02/Online-Hotel-System/internal/entity"
+	"github.com/gin-gonic/gin"
+)
+
+// CreateReview godoc
+// @Router /review [post]
+// @Summary Create a room review
+// @Description Review a room for one of your own completed bookings. Only one review per booking is allowed.
+// @Security BearerAuth
+// @Tags review
+// @Accept  json
+// @Produce  json
+// @Param review body entity.RoomReview true "Review object"
+// @Success 201 {object} entity.RoomReview
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) CreateReview(ctx *gin.Context) {
+	var (
+		body entity.RoomReview
+	)
+
+	err := ctx.ShouldBindJSON(&body)
+	if err != nil {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Invalid request body", 400)
+		return
+	}
+
+	if body.Rating < 1 || body.Rating > 5 {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Rating must be between 1 and 5", http.StatusBadRequest)
+		return
+	}
+
+	booking, err := h.UseCase.BookingRepo.GetSingle(ctx, entity.Id{ID: body.BookingID})
+	if h.HandleDbError(ctx, err, "Error getting booking") {
+		return
+	}
+
+	body.UserID = ctx.GetHeader("sub")
+	if booking.UserID != body.UserID {
+		h.ReturnError(ctx, config.ErrorForbidden, "You can only review your own bookings", http.StatusForbidden)
+		return
+	}
+
+	if booking.Status != entity.BookingStatusCompleted {
+		h.ReturnError(ctx, config.ErrorForbidden, "You can only review a completed stay", http.StatusForbidden)
+		return
+	}
+
+	existing, err := h.UseCase.RoomReviewRepo.GetList(ctx, entity.GetListFilter{
+		Limit: 1,
+		Filters: []entity.Filter{
+			{Column: "booking_id", Type: "eq", Value: booking.ID},
+		},
+	})
+	if h.HandleDbError(ctx, err, "Error getting reviews") {
+		return
+	}
+
+	if existing.Count > 0 {
+		h.ReturnError(ctx, config.ErrorConflict, "You have already reviewed this stay", http.StatusConflict)
+		return
+	}
+
+	body.RoomID = booking.RoomID
+
+	review, err := h.UseCase.RoomReviewRepo.Create(ctx, body)
+	if h.HandleDbError(ctx, err, "Error creating review") {
+		return
+	}
+
+	ctx.JSON(201, review)
+}
+
+// GetReview godoc
+// @Router /review/{id} [get]
+// @Summary Get a review by ID
+// @Description Get a review by ID
+// @Tags review
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Review ID"
+// @Success 200 {object} entity.RoomReview
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) GetReview(ctx *gin.Context) {
+	var (
+		req entity.Id
+	)
+
+	req.ID = ctx.Param("id")
+
+	review, err := h.UseCase.RoomReviewRepo.GetSingle(ctx, req)
+	if h.HandleDbError(ctx, err, "Error getting review") {
+		return
+	}
+
+	ctx.JSON(200, review)
+}
+
+// GetReviews godoc
+// @Router /review/list [get]
+// @Summary Get a list of reviews
+// @Description Get a list of reviews
+// @Tags review
+// @Accept  json
+// @Produce  json
+// @Param page query number true "page"
+// @Param limit query number true "limit"
+// @Param room_id query string false "room_id"
+// @Param user_id query string false "user_id"
+// @Success 200 {object} entity.RoomReviewList
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) GetReviews(ctx *gin.Context) {
+	var (
+		req entity.GetListFilter
+	)
+
+	page := ctx.DefaultQuery("page", "1")
+	limit := ctx.DefaultQuery("limit", "10")
+
+	req.Page, _ = strconv.Atoi(page)
+	req.Limit, _ = strconv.Atoi(limit)
+
+	for _, column := range []string{"room_id", "user_id"} {
+		if value := ctx.Query(column); value != "" {
+			req.Filters = append(req.Filters, entity.Filter{
+				Column: column,
+				Type:   "eq",
+				Value:  value,
+			})
+		}
+	}
+
+	req.OrderBy = append(req.OrderBy, entity.OrderBy{
+		Column: "created_at",
+		Order:  "desc",
+	})
+
+	reviews, err := h.UseCase.RoomReviewRepo.GetList(ctx, req)
+	if h.HandleDbError(ctx, err, "Error getting reviews") {
+		return
+	}
+
+	ctx.JSON(200, reviews)
+}
+
+// UpdateReview godoc
+// @Router /review [put]
+// @Summary Update a review
+// @Description Update your own review
+// @Security BearerAuth
+// @Tags review
+// @Accept  json
+// @Produce  json
+// @Param review body entity.RoomReview true "Review object"
+// @Success 200 {object} entity.RoomReview
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) UpdateReview(ctx *gin.Context) {
+	var (
+		body entity.RoomReview
+	)
+
+	err := ctx.ShouldBindJSON(&body)
+	if err != nil {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Invalid request body", 400)
+		return
+	}
+
+	if body.Rating != 0 && (body.Rating < 1 || body.Rating > 5) {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Rating must be between 1 and 5", http.StatusBadRequest)
+		return
+	}
+
+	review, err := h.UseCase.RoomReviewRepo.GetSingle(ctx, entity.Id{ID: body.ID})
+	if h.HandleDbError(ctx, err, "Error getting review") {
+		return
+	}
+
+	if review.UserID != ctx.GetHeader("sub") {
+		h.ReturnError(ctx, config.ErrorForbidden, "You can only edit your own reviews", http.StatusForbidden)
+		return
+	}
+
+	review, err = h.UseCase.RoomReviewRepo.Update(ctx, entity.RoomReview{
+		ID:      review.ID,
+		Rating:  body.Rating,
+		Comment: body.Comment,
+	})
+	if h.HandleDbError(ctx, err, "Error updating review") {
+		return
+	}
+
+	ctx.JSON(200, review)
+}
+
+// DeleteReview godoc
+// @Router /review/{id} [delete]
+// @Summary Delete a review
+// @Description Delete a review. Guests can only delete their own reviews.
+// @Security BearerAuth
+// @Tags review
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Review ID"
+// @Success 200 {object} entity.SuccessResponse
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) DeleteReview(ctx *gin.Context) {
+	review, err := h.UseCase.RoomReviewRepo.GetSingle(ctx, entity.Id{ID: ctx.Param("id")})
+	if h.HandleDbError(ctx, err, "Error getting review") {
+		return
+	}
+
+	if ctx.GetHeader("user_role") == "user" && review.UserID != ctx.GetHeader("sub") {
+		h.ReturnError(ctx, config.ErrorForbidden, "You can only delete your own reviews", http.StatusForbidden)
+		return
+	}
+
+	err = h.UseCase.RoomReviewRepo.Delete(ctx, entity.Id{ID: review.ID})
+	if h.HandleDbError(ctx, err, "Error deleting review") {
+		return
+	}
+
+	ctx.JSON(200, entity.SuccessResponse{
+		Message: "Review deleted successfully",
+	})
+}
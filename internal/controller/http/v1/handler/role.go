@@ -0,0 +1,19 @@
+package handler
+
+import "github.com/Avazbek-02/Online-Hotel-System/internal/entity"
+
+// The route policy decides which endpoints a role reaches. The helpers below narrow what a
+// role may do on endpoints it shares with others.
+
+// isAdminRole reports whether a role runs the hotel, as opposed to guests and staff.
+func isAdminRole(role string) bool {
+	return role == entity.UserRoleAdmin || role == entity.UserRoleSuperadmin
+}
+
+// roomStatusesByRole lists the room statuses a role may set. A room becomes booked by
+// checking a guest in, so only admins set it by hand.
+var roomStatusesByRole = map[string][]string{
+	entity.UserRoleAdmin:        {entity.RoomStatusAvailable, entity.RoomStatusBooked, entity.RoomStatusCleaning, entity.RoomStatusMaintenance},
+	entity.UserRoleSuperadmin:   {entity.RoomStatusAvailable, entity.RoomStatusBooked, entity.RoomStatusCleaning, entity.RoomStatusMaintenance},
+	entity.UserRoleHousekeeping: {entity.RoomStatusAvailable, entity.RoomStatusCleaning, entity.RoomStatusMaintenance},
+}
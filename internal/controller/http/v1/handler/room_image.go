@@ -0,0 +1,430 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Avazbek-02/Online-Hotel-System/config"
+	"github.com/Avazbek-02/Online-Hotel-System/internal/entity"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/imageproc"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const maxImageSize = 20 << 20
+
+// attachRoomImages fills in the gallery of every room with a single query.
+func (h *Handler) attachRoomImages(ctx *gin.Context, rooms []entity.Room) error {
+	ids := make([]string, 0, len(rooms))
+	for _, room := range rooms {
+		ids = append(ids, room.ID)
+	}
+
+	images, err := h.UseCase.RoomImageRepo.GetByRooms(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range rooms {
+		rooms[i].Images = images[rooms[i].ID]
+		if rooms[i].Images == nil {
+			rooms[i].Images = []entity.RoomImage{}
+		}
+	}
+
+	return nil
+}
+
+// attachRoomTypeImages fills in the images of every room type from the galleries of its rooms with a single query.
+func (h *Handler) attachRoomTypeImages(ctx *gin.Context, roomTypes []entity.RoomType) error {
+	ids := make([]string, 0, len(roomTypes))
+	for _, roomType := range roomTypes {
+		ids = append(ids, roomType.ID)
+	}
+
+	images, err := h.UseCase.RoomImageRepo.GetByRoomTypes(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range roomTypes {
+		roomTypes[i].Images = images[roomTypes[i].ID]
+		if roomTypes[i].Images == nil {
+			roomTypes[i].Images = []entity.RoomImage{}
+		}
+	}
+
+	return nil
+}
+
+// readImage reads an uploaded file and renders its variants.
+func readImage(file *multipart.FileHeader) ([]imageproc.Image, error) {
+	if file.Size > maxImageSize {
+		return nil, errors.New("image is larger than 20 MB")
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return processImage(file.Filename, f)
+}
+
+// processImage renders the variants of an image. The declared type comes from the file
+// extension and must match the actual content.
+func processImage(fileName string, r io.Reader) ([]imageproc.Image, error) {
+	contentType, ok := storage.ContentType[strings.ToLower(filepath.Ext(fileName))]
+	if !ok {
+		return nil, errors.New("unsupported image type")
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, maxImageSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxImageSize {
+		return nil, errors.New("image is larger than 20 MB")
+	}
+
+	return imageproc.Process(data, contentType)
+}
+
+// incomingPrefix is where a user's presigned uploads land until they are imported.
+func incomingPrefix(userID string) string {
+	return storage.IncomingPrefix + userID + "/"
+}
+
+// storeRoomImage uploads the rendered variants of an image and adds it to the room's gallery.
+func (h *Handler) storeRoomImage(ctx *gin.Context, roomID string, images []imageproc.Image) (entity.RoomImage, error) {
+	objects, err := storage.UploadImage(ctx, h.Storage, "rooms/"+roomID+"/"+uuid.NewString(), images)
+	if err != nil {
+		return entity.RoomImage{}, err
+	}
+
+	body := entity.RoomImage{RoomID: roomID}
+	for _, object := range objects {
+		body.VariantObjects = append(body.VariantObjects, object.ObjectName)
+		switch object.Variant {
+		case "thumbnail":
+			body.ThumbnailURL = object.URL
+		case "medium":
+			body.MediumURL = object.URL
+		case "full":
+			body.ImageURL = object.URL
+			body.ObjectName = object.ObjectName
+		}
+	}
+
+	image, err := h.UseCase.RoomImageRepo.Create(ctx, body)
+	if err != nil {
+		h.deleteObjects(ctx, body.VariantObjects)
+		return entity.RoomImage{}, err
+	}
+
+	return image, nil
+}
+
+// deleteObjects removes objects from the bucket, logging failures.
+func (h *Handler) deleteObjects(ctx context.Context, objects []string) {
+	for _, object := range objects {
+		if err := h.Storage.Delete(ctx, object); err != nil {
+			h.Logger.Error(err, "Error deleting object "+object)
+		}
+	}
+}
+
+// getRoomImage loads an image, checks that it belongs to the room in the path and that the
+// caller manages the room.
+func (h *Handler) getRoomImage(ctx *gin.Context) (entity.RoomImage, bool) {
+	if !h.canManageRoom(ctx, ctx.Param("id")) {
+		return entity.RoomImage{}, false
+	}
+
+	image, err := h.UseCase.RoomImageRepo.GetSingle(ctx, entity.Id{ID: ctx.Param("image_id")})
+	if h.HandleDbError(ctx, err, "Error getting room image") {
+		return entity.RoomImage{}, false
+	}
+
+	if image.RoomID != ctx.Param("id") {
+		h.ReturnError(ctx, config.ErrorNotFound, "Image not found in this room", http.StatusNotFound)
+		return entity.RoomImage{}, false
+	}
+
+	return image, true
+}
+
+// UploadRoomImages godoc
+// @Router /room/{id}/images [post]
+// @Summary Upload room images
+// @Description Add one or more images to the end of a room's gallery (admin only). The first image of a room becomes its cover.
+// @Description Each image is stored as thumbnail, medium and full size JPEG without metadata.
+// @Security BearerAuth
+// @Tags room
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "Room ID"
+// @Param files formData file true "Image files to upload"
+// @Success 201 {object} entity.RoomImageList
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) UploadRoomImages(ctx *gin.Context) {
+	room, err := h.UseCase.RoomsRepo.GetSingle(ctx, entity.Id{ID: ctx.Param("id")})
+	if h.HandleDbError(ctx, err, "Error getting room") {
+		return
+	}
+
+	if !h.canManageHotel(ctx, room.HotelID) {
+		return
+	}
+
+	form, err := ctx.MultipartForm()
+	if err != nil {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Invalid multipart form", http.StatusBadRequest)
+		return
+	}
+
+	files := form.File["files"]
+	if len(files) == 0 {
+		h.ReturnError(ctx, config.ErrorBadRequest, "At least one file is required", http.StatusBadRequest)
+		return
+	}
+
+	// every file is validated and rendered before anything is stored, so one bad file fails the whole upload
+	processed := make([][]imageproc.Image, 0, len(files))
+	for _, file := range files {
+		images, err := readImage(file)
+		if err != nil {
+			h.ReturnError(ctx, config.ErrorBadRequest, file.Filename+": "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		processed = append(processed, images)
+	}
+
+	response := entity.RoomImageList{Items: []entity.RoomImage{}}
+	for _, images := range processed {
+		image, err := h.storeRoomImage(ctx, room.ID, images)
+		if h.HandleDbError(ctx, err, "Error storing room image") {
+			return
+		}
+
+		response.Items = append(response.Items, image)
+	}
+
+	response.Count = len(response.Items)
+	ctx.JSON(201, response)
+}
+
+// ImportRoomImages godoc
+// @Router /room/{id}/images/import [post]
+// @Summary Import room images uploaded through presigned URLs
+// @Description Add images the caller uploaded directly to storage through /user/upload-url to a room's gallery (admin only).
+// @Description They are validated and rendered like regular uploads, the uploaded originals are removed afterwards.
+// @Security BearerAuth
+// @Tags room
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Room ID"
+// @Param import body entity.RoomImageImportRequest true "Uploaded object names"
+// @Success 201 {object} entity.RoomImageList
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) ImportRoomImages(ctx *gin.Context) {
+	var (
+		body entity.RoomImageImportRequest
+	)
+
+	err := ctx.ShouldBindJSON(&body)
+	if err != nil || len(body.ObjectNames) == 0 {
+		h.ReturnError(ctx, config.ErrorBadRequest, "object_names is required", 400)
+		return
+	}
+
+	room, err := h.UseCase.RoomsRepo.GetSingle(ctx, entity.Id{ID: ctx.Param("id")})
+	if h.HandleDbError(ctx, err, "Error getting room") {
+		return
+	}
+
+	if !h.canManageHotel(ctx, room.HotelID) {
+		return
+	}
+
+	prefix := incomingPrefix(ctx.GetHeader("sub"))
+	for _, objectName := range body.ObjectNames {
+		if !strings.HasPrefix(objectName, prefix) || strings.Contains(objectName, "..") {
+			h.ReturnError(ctx, config.ErrorForbidden, "You can only import your own uploads", http.StatusForbidden)
+			return
+		}
+	}
+
+	processed := make([][]imageproc.Image, 0, len(body.ObjectNames))
+	for _, objectName := range body.ObjectNames {
+		object, err := h.Storage.Download(ctx, objectName)
+		if errors.Is(err, storage.ErrNotFound) {
+			h.ReturnError(ctx, config.ErrorNotFound, objectName+": upload not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			h.Logger.Error(err, "Error downloading uploaded image")
+			h.ReturnError(ctx, config.ErrorInternalServer, "Error reading uploaded image", http.StatusInternalServerError)
+			return
+		}
+
+		images, err := processImage(objectName, object)
+		object.Close()
+		if err != nil {
+			h.ReturnError(ctx, config.ErrorBadRequest, objectName+": "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		processed = append(processed, images)
+	}
+
+	response := entity.RoomImageList{Items: []entity.RoomImage{}}
+	for _, images := range processed {
+		image, err := h.storeRoomImage(ctx, room.ID, images)
+		if h.HandleDbError(ctx, err, "Error storing room image") {
+			return
+		}
+
+		response.Items = append(response.Items, image)
+	}
+
+	h.deleteObjects(ctx, body.ObjectNames)
+
+	response.Count = len(response.Items)
+	ctx.JSON(201, response)
+}
+
+// GetRoomImages godoc
+// @Router /room/{id}/images [get]
+// @Summary Get the images of a room
+// @Description Get the gallery of a room ordered by position
+// @Tags room
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Room ID"
+// @Success 200 {object} entity.RoomImageList
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) GetRoomImages(ctx *gin.Context) {
+	roomID := ctx.Param("id")
+
+	images, err := h.UseCase.RoomImageRepo.GetByRooms(ctx, []string{roomID})
+	if h.HandleDbError(ctx, err, "Error getting room images") {
+		return
+	}
+
+	response := entity.RoomImageList{Items: images[roomID], Count: len(images[roomID])}
+	if response.Items == nil {
+		response.Items = []entity.RoomImage{}
+	}
+
+	ctx.JSON(200, response)
+}
+
+// ReorderRoomImages godoc
+// @Router /room/{id}/images/order [put]
+// @Summary Reorder room images
+// @Description Set the gallery order of a room (admin only). Every image of the room must be listed exactly once.
+// @Security BearerAuth
+// @Tags room
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Room ID"
+// @Param order body entity.RoomImageOrderRequest true "Image IDs in the new order"
+// @Success 200 {object} entity.RoomImageList
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) ReorderRoomImages(ctx *gin.Context) {
+	var (
+		body entity.RoomImageOrderRequest
+	)
+
+	err := ctx.ShouldBindJSON(&body)
+	if err != nil {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Invalid request body", 400)
+		return
+	}
+
+	if !h.canManageRoom(ctx, ctx.Param("id")) {
+		return
+	}
+
+	err = h.UseCase.RoomImageRepo.Reorder(ctx, ctx.Param("id"), body.ImageIDs)
+	if errors.Is(err, entity.ErrInvalidImageOrder) {
+		h.ReturnError(ctx, config.ErrorBadRequest, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if h.HandleDbError(ctx, err, "Error reordering room images") {
+		return
+	}
+
+	h.GetRoomImages(ctx)
+}
+
+// SetRoomCoverImage godoc
+// @Router /room/{id}/images/{image_id}/cover [put]
+// @Summary Set the cover image of a room
+// @Description Mark an image as the cover of its room (admin only)
+// @Security BearerAuth
+// @Tags room
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Room ID"
+// @Param image_id path string true "Image ID"
+// @Success 200 {object} entity.RoomImage
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) SetRoomCoverImage(ctx *gin.Context) {
+	image, ok := h.getRoomImage(ctx)
+	if !ok {
+		return
+	}
+
+	image, err := h.UseCase.RoomImageRepo.SetCover(ctx, entity.Id{ID: image.ID})
+	if h.HandleDbError(ctx, err, "Error setting cover image") {
+		return
+	}
+
+	ctx.JSON(200, image)
+}
+
+// DeleteRoomImage godoc
+// @Router /room/{id}/images/{image_id} [delete]
+// @Summary Delete a room image
+// @Description Remove an image from a room's gallery and from the bucket (admin only)
+// @Security BearerAuth
+// @Tags room
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Room ID"
+// @Param image_id path string true "Image ID"
+// @Success 200 {object} entity.SuccessResponse
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) DeleteRoomImage(ctx *gin.Context) {
+	image, ok := h.getRoomImage(ctx)
+	if !ok {
+		return
+	}
+
+	image, err := h.UseCase.RoomImageRepo.Delete(ctx, entity.Id{ID: image.ID})
+	if h.HandleDbError(ctx, err, "Error deleting room image") {
+		return
+	}
+
+	// the row is gone, so a failure here only leaves orphaned objects behind
+	objects := image.VariantObjects
+	if image.ObjectName != "" && !slices.Contains(objects, image.ObjectName) {
+		objects = append(objects, image.ObjectName)
+	}
+	h.deleteObjects(ctx, objects)
+
+	ctx.JSON(200, entity.SuccessResponse{
+		Message: "Image deleted successfully",
+	})
+}
@@ -0,0 +1,253 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Avazbek-02/Online-Hotel-System/config"
+	"github.com/Avazbek-02/Online-Hotel-System/internal/entity"
+	"github.com/Avazbek-02/Online-Hotel-System/internal/usecase"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/imageproc"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/logger"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/storage"
+	"github.com/gin-gonic/gin"
+)
+
+const testStorageURL = "http://storage.test"
+
+// The fakes embed the repo interfaces, calling a method they don't override panics.
+
+type fakeRoomsRepo struct {
+	usecase.RoomsRepoI
+	room entity.Room
+}
+
+func (f *fakeRoomsRepo) GetSingle(ctx context.Context, req entity.Id) (entity.Room, error) {
+	return f.room, nil
+}
+
+type fakeHotelRepo struct {
+	usecase.HotelRepoI
+	hotelIDs []string
+}
+
+func (f *fakeHotelRepo) GetHotelIDsByStaff(ctx context.Context, req entity.Id) ([]string, error) {
+	return f.hotelIDs, nil
+}
+
+type fakeRoomImageRepo struct {
+	usecase.RoomImageRepoI
+	created []entity.RoomImage
+	err     error
+}
+
+func (f *fakeRoomImageRepo) Create(ctx context.Context, req entity.RoomImage) (entity.RoomImage, error) {
+	if f.err != nil {
+		return entity.RoomImage{}, f.err
+	}
+
+	req.IsCover = len(f.created) == 0
+	f.created = append(f.created, req)
+	return req, nil
+}
+
+func newImageTestHandler(images *fakeRoomImageRepo) (*Handler, *storage.Memory) {
+	store := storage.NewMemory(testStorageURL)
+
+	return &Handler{
+		Logger: logger.New("error"),
+		Config: &config.Config{},
+		UseCase: &usecase.UseCase{
+			RoomsRepo:     &fakeRoomsRepo{room: entity.Room{ID: "room-1", HotelID: "hotel-1"}},
+			HotelRepo:     &fakeHotelRepo{hotelIDs: []string{"hotel-1"}},
+			RoomImageRepo: images,
+		},
+		Storage: store,
+	}, store
+}
+
+// uploadRequest builds a multipart upload of the given files as the given role.
+func uploadRequest(t *testing.T, role string, files map[string][]byte) *http.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for name, data := range files {
+		part, err := form.CreateFormFile("files", name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write(data)
+	}
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/room/room-1/images", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("user_role", role)
+	req.Header.Set("sub", "user-1")
+	return req
+}
+
+func serveUpload(h *Handler, req *http.Request) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.POST("/v1/room/:id/images", h.UploadRoomImages)
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func testPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestUploadRoomImagesStoresVariants(t *testing.T) {
+	images := &fakeRoomImageRepo{}
+	h, store := newImageTestHandler(images)
+
+	w := serveUpload(h, uploadRequest(t, entity.UserRoleAdmin, map[string][]byte{
+		"a.png": testPNG(t, 400, 200),
+		"b.png": testPNG(t, 10, 10),
+	}))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body)
+	}
+
+	var response entity.RoomImageList
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Count != 2 || len(images.created) != 2 {
+		t.Fatalf("got %d images, %d created, want 2", response.Count, len(images.created))
+	}
+
+	if store.Len() != 2*len(imageproc.Variants) {
+		t.Errorf("stored %d objects, want %d", store.Len(), 2*len(imageproc.Variants))
+	}
+
+	for _, img := range images.created {
+		if len(img.VariantObjects) != len(imageproc.Variants) {
+			t.Errorf("image has %d variants, want %d", len(img.VariantObjects), len(imageproc.Variants))
+		}
+
+		for _, objectName := range img.VariantObjects {
+			object, ok := store.Object(objectName)
+			if !ok {
+				t.Errorf("%s wasn't stored", objectName)
+				continue
+			}
+			if object.ContentType != imageproc.ContentType || imageproc.Sniff(object.Data) != imageproc.ContentType {
+				t.Errorf("%s is stored as %s", objectName, object.ContentType)
+			}
+		}
+
+		for _, url := range []string{img.ImageURL, img.MediumURL, img.ThumbnailURL} {
+			if !strings.HasPrefix(url, testStorageURL+"/rooms/room-1/") {
+				t.Errorf("url %q isn't in the room's folder of the storage", url)
+			}
+		}
+	}
+}
+
+func TestUploadRoomImagesStoresNothing(t *testing.T) {
+	tests := []struct {
+		name   string
+		role   string
+		files  map[string][]byte
+		repo   *fakeRoomImageRepo
+		status int
+	}{
+		{
+			name:   "one file isn't an image",
+			role:   entity.UserRoleAdmin,
+			files:  map[string][]byte{"a.png": testPNG(t, 10, 10), "b.png": []byte("not an image")},
+			repo:   &fakeRoomImageRepo{},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "extension doesn't match the content",
+			role:   entity.UserRoleAdmin,
+			files:  map[string][]byte{"a.jpg": testPNG(t, 10, 10)},
+			repo:   &fakeRoomImageRepo{},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "staff of another hotel",
+			role:   entity.UserRoleManager,
+			files:  map[string][]byte{"a.png": testPNG(t, 10, 10)},
+			repo:   &fakeRoomImageRepo{},
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "saving the image fails",
+			role:   entity.UserRoleAdmin,
+			files:  map[string][]byte{"a.png": testPNG(t, 10, 10)},
+			repo:   &fakeRoomImageRepo{err: errors.New("connection refused")},
+			status: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, store := newImageTestHandler(tt.repo)
+			if tt.role == entity.UserRoleManager {
+				h.UseCase.HotelRepo = &fakeHotelRepo{hotelIDs: []string{"hotel-2"}}
+			}
+
+			w := serveUpload(h, uploadRequest(t, tt.role, tt.files))
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d, body %s", w.Code, tt.status, w.Body)
+			}
+
+			if store.Len() != 0 || len(tt.repo.created) != 0 {
+				t.Errorf("stored %d objects and %d images, want none", store.Len(), len(tt.repo.created))
+			}
+		})
+	}
+}
+
+func TestGetMedia(t *testing.T) {
+	h, _ := newImageTestHandler(&fakeRoomImageRepo{})
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/v1/media/*object", h.GetMedia)
+
+	tests := []struct {
+		path     string
+		status   int
+		location string
+	}{
+		{"/v1/media/rooms/room-1/a/full.jpg", http.StatusFound, testStorageURL + "/rooms/room-1/a/full.jpg?op=get"},
+		{"/v1/media/incoming/user-1/a.png", http.StatusNotFound, ""},
+		{"/v1/media/", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+		if w.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.path, w.Code, tt.status)
+		}
+		if location := w.Header().Get("Location"); location != tt.location {
+			t.Errorf("%s: redirects to %q, want %q", tt.path, location, tt.location)
+		}
+	}
+}
@@ -0,0 +1,329 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Avazbek-02/Online-Hotel-System/config"
+	"github.com/Avazbek-02/Online-Hotel-System/internal/entity"
+	"github.com/gin-gonic/gin"
+)
+
+// roomTypeOrderColumns are the columns GetRoomTypes allows sorting by.
+var roomTypeOrderColumns = map[string]bool{
+	"base_price": true,
+	"capacity":   true,
+	"created_at": true,
+}
+
+// validateRoomType checks the fields of a room type that are set.
+func (h *Handler) validateRoomType(ctx *gin.Context, body entity.RoomType) bool {
+	if body.Category != "" {
+		if _, ok := entity.RoomCategoryCapacity[body.Category]; !ok {
+			h.ReturnError(ctx, config.ErrorBadRequest, "Invalid room category", http.StatusBadRequest)
+			return false
+		}
+	}
+
+	if body.BasePrice < 0 || body.Capacity < 0 || body.Size < 0 || body.MinStay < 0 {
+		h.ReturnError(ctx, config.ErrorBadRequest, "base_price, capacity, size and min_stay can't be negative", http.StatusBadRequest)
+		return false
+	}
+
+	return true
+}
+
+// CreateRoomType godoc
+// @Router /room-type [post]
+// @Summary Create a new room type
+// @Description Create a room type in one of the admin's hotels. Capacity defaults to that of the category.
+// @Security BearerAuth
+// @Tags room-type
+// @Accept  json
+// @Produce  json
+// @Param room_type body entity.RoomType true "Room type object"
+// @Success 201 {object} entity.RoomType
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) CreateRoomType(ctx *gin.Context) {
+	var (
+		body entity.RoomType
+	)
+
+	err := ctx.ShouldBindJSON(&body)
+	if err != nil {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Invalid request body", 400)
+		return
+	}
+
+	if body.HotelID == "" || body.Name == "" || body.Category == "" {
+		h.ReturnError(ctx, config.ErrorBadRequest, "hotel_id, name and category are required", http.StatusBadRequest)
+		return
+	}
+
+	if !h.validateRoomType(ctx, body) {
+		return
+	}
+
+	if body.BasePrice <= 0 {
+		h.ReturnError(ctx, config.ErrorBadRequest, "base_price must be greater than zero", http.StatusBadRequest)
+		return
+	}
+
+	if body.Capacity == 0 {
+		body.Capacity = entity.RoomCategoryCapacity[body.Category]
+	}
+
+	if !h.canManageHotel(ctx, body.HotelID) {
+		return
+	}
+
+	roomType, err := h.UseCase.RoomTypeRepo.Create(ctx, body)
+	if h.HandleDbError(ctx, err, "Error creating room type") {
+		return
+	}
+
+	ctx.JSON(201, roomType)
+}
+
+// GetRoomType godoc
+// @Router /room-type/{id} [get]
+// @Summary Get a room type by ID
+// @Description Get a room type by ID
+// @Tags room-type
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Room type ID"
+// @Success 200 {object} entity.RoomType
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) GetRoomType(ctx *gin.Context) {
+	var (
+		req entity.Id
+	)
+
+	req.ID = ctx.Param("id")
+
+	roomType, err := h.UseCase.RoomTypeRepo.GetSingle(ctx, req)
+	if h.HandleDbError(ctx, err, "Error getting room type") {
+		return
+	}
+
+	roomTypes := []entity.RoomType{roomType}
+	if h.HandleDbError(ctx, h.attachRoomTypeImages(ctx, roomTypes), "Error getting room type images") {
+		return
+	}
+
+	ctx.JSON(200, roomTypes[0])
+}
+
+// GetRoomTypes godoc
+// @Router /room-type/list [get]
+// @Summary Get a list of room types
+// @Description Get a list of room types
+// @Tags room-type
+// @Accept  json
+// @Produce  json
+// @Param page query number true "page"
+// @Param limit query number true "limit"
+// @Param hotel_id query string false "hotel_id"
+// @Param type query string false "type"
+// @Param category query string false "category"
+// @Param order_by query string false "base_price, capacity or created_at"
+// @Param order query string false "asc or desc"
+// @Success 200 {object} entity.RoomTypeList
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) GetRoomTypes(ctx *gin.Context) {
+	var (
+		req entity.GetListFilter
+	)
+
+	page := ctx.DefaultQuery("page", "1")
+	limit := ctx.DefaultQuery("limit", "10")
+	orderBy := ctx.DefaultQuery("order_by", "base_price")
+	order := ctx.DefaultQuery("order", "asc")
+
+	req.Page, _ = strconv.Atoi(page)
+	req.Limit, _ = strconv.Atoi(limit)
+
+	for _, column := range []string{"hotel_id", "type", "category"} {
+		if value := ctx.Query(column); value != "" {
+			req.Filters = append(req.Filters, entity.Filter{
+				Column: column,
+				Type:   "eq",
+				Value:  value,
+			})
+		}
+	}
+
+	if !roomTypeOrderColumns[orderBy] {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Invalid order_by column", http.StatusBadRequest)
+		return
+	}
+
+	if order != "asc" && order != "desc" {
+		h.ReturnError(ctx, config.ErrorBadRequest, "order must be asc or desc", http.StatusBadRequest)
+		return
+	}
+
+	req.OrderBy = append(req.OrderBy, entity.OrderBy{
+		Column: orderBy,
+		Order:  order,
+	})
+
+	roomTypes, err := h.UseCase.RoomTypeRepo.GetList(ctx, req)
+	if h.HandleDbError(ctx, err, "Error getting room types") {
+		return
+	}
+
+	if h.HandleDbError(ctx, h.attachRoomTypeImages(ctx, roomTypes.Items), "Error getting room type images") {
+		return
+	}
+
+	ctx.JSON(200, roomTypes)
+}
+
+// UpdateRoomType godoc
+// @Router /room-type [put]
+// @Summary Update a room type
+// @Description Update a room type in one of the admin's hotels. Existing bookings keep the price they were made at.
+// @Security BearerAuth
+// @Tags room-type
+// @Accept  json
+// @Produce  json
+// @Param room_type body entity.RoomType true "Room type object"
+// @Success 200 {object} entity.RoomType
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) UpdateRoomType(ctx *gin.Context) {
+	var (
+		body entity.RoomType
+	)
+
+	err := ctx.ShouldBindJSON(&body)
+	if err != nil {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Invalid request body", 400)
+		return
+	}
+
+	if !h.validateRoomType(ctx, body) {
+		return
+	}
+
+	current, err := h.UseCase.RoomTypeRepo.GetSingle(ctx, entity.Id{ID: body.ID})
+	if h.HandleDbError(ctx, err, "Error getting room type") {
+		return
+	}
+
+	if !h.canManageHotel(ctx, current.HotelID) {
+		return
+	}
+
+	roomType, err := h.UseCase.RoomTypeRepo.Update(ctx, body)
+	if h.HandleDbError(ctx, err, "Error updating room type") {
+		return
+	}
+
+	ctx.JSON(200, roomType)
+}
+
+// DeleteRoomType godoc
+// @Router /room-type/{id} [delete]
+// @Summary Delete a room type
+// @Description Delete a room type of one of the admin's hotels that has no rooms or bookings
+// @Security BearerAuth
+// @Tags room-type
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Room type ID"
+// @Success 200 {object} entity.SuccessResponse
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) DeleteRoomType(ctx *gin.Context) {
+	var (
+		req entity.Id
+	)
+
+	req.ID = ctx.Param("id")
+
+	roomType, err := h.UseCase.RoomTypeRepo.GetSingle(ctx, req)
+	if h.HandleDbError(ctx, err, "Error getting room type") {
+		return
+	}
+
+	if !h.canManageHotel(ctx, roomType.HotelID) {
+		return
+	}
+
+	err = h.UseCase.RoomTypeRepo.Delete(ctx, req)
+	if h.HandleDbError(ctx, err, "Error deleting room type") {
+		return
+	}
+
+	ctx.JSON(200, entity.SuccessResponse{
+		Message: "Room type deleted successfully",
+	})
+}
+
+// SearchRoomTypes godoc
+// @Router /room-type/search [get]
+// @Summary Search available room types
+// @Description Returns room types with a room free for the whole stay that match the given filters, with the number of such rooms
+// @Description and the galleries of their rooms. Types whose minimum stay for the arrival date is longer than the stay are left out.
+// @Description The price range bounds the average nightly price of the stay from the rate calendar, results are sorted by the stay's total price.
+// @Tags room-type
+// @Accept  json
+// @Produce  json
+// @Param check_in_date query string true "Check-in date (YYYY-MM-DD)"
+// @Param check_out_date query string true "Check-out date (YYYY-MM-DD)"
+// @Param hotel_id query string false "hotel_id"
+// @Param guests query number false "guests"
+// @Param category query string false "category"
+// @Param type query string false "type"
+// @Param min_price query number false "Lowest average nightly price"
+// @Param max_price query number false "Highest average nightly price"
+// @Param page query number false "page"
+// @Param limit query number false "limit"
+// @Success 200 {object} entity.RoomTypeList
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) SearchRoomTypes(ctx *gin.Context) {
+	var (
+		req entity.RoomSearchRequest
+	)
+
+	req.CheckInDate = ctx.Query("check_in_date")
+	req.CheckOutDate = ctx.Query("check_out_date")
+	req.HotelID = ctx.Query("hotel_id")
+	req.Category = ctx.Query("category")
+	req.Type = ctx.Query("type")
+	req.Guests, _ = strconv.Atoi(ctx.DefaultQuery("guests", "0"))
+	req.MinPrice, _ = strconv.ParseFloat(ctx.DefaultQuery("min_price", "0"), 64)
+	req.MaxPrice, _ = strconv.ParseFloat(ctx.DefaultQuery("max_price", "0"), 64)
+	req.Page, _ = strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	req.Limit, _ = strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+
+	checkIn, checkOut, err := parseStayDates(req.CheckInDate, req.CheckOutDate)
+	if err != nil {
+		h.ReturnError(ctx, config.ErrorBadRequest, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// every night of the stay is checked against every room, so the stay is kept to what can be quoted
+	if stayNights(checkIn, checkOut) > maxQuoteNights {
+		h.ReturnError(ctx, config.ErrorBadRequest, fmt.Sprintf("A stay can't be longer than %d nights", maxQuoteNights), http.StatusBadRequest)
+		return
+	}
+
+	if req.MaxPrice > 0 && req.MinPrice > req.MaxPrice {
+		h.ReturnError(ctx, config.ErrorBadRequest, "min_price can't be greater than max_price", http.StatusBadRequest)
+		return
+	}
+
+	roomTypes, err := h.UseCase.RoomTypeRepo.Search(ctx, req)
+	if h.HandleDbError(ctx, err, "Error searching room types") {
+		return
+	}
+
+	if h.HandleDbError(ctx, h.attachRoomTypeImages(ctx, roomTypes.Items), "Error getting room type images") {
+		return
+	}
+
+	ctx.JSON(200, roomTypes)
+}
@@ -1 +1,324 @@
-package handler
\ No newline at end of file
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/Avazbek-02/Online-Hotel-System/config"
+	"github.com/Avazbek-02/Online-Hotel-System/internal/entity"
+	"github.com/gin-gonic/gin"
+)
+
+// roomOrderColumns are the columns GetRooms allows sorting by.
+var roomOrderColumns = map[string]bool{
+	"room_number": true,
+	"floor":       true,
+	"rating":      true,
+	"created_at":  true,
+}
+
+// CreateRoom godoc
+// @Router /room [post]
+// @Summary Create a new room
+// @Description Add a physical room of a room type in one of the admin's hotels
+// @Security BearerAuth
+// @Tags room
+// @Accept  json
+// @Produce  json
+// @Param room body entity.Room true "Room object"
+// @Success 201 {object} entity.Room
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) CreateRoom(ctx *gin.Context) {
+	var (
+		body entity.Room
+	)
+
+	err := ctx.ShouldBindJSON(&body)
+	if err != nil {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Invalid request body", 400)
+		return
+	}
+
+	if body.RoomTypeID == "" || body.RoomNumber == "" {
+		h.ReturnError(ctx, config.ErrorBadRequest, "room_type_id and room_number are required", http.StatusBadRequest)
+		return
+	}
+
+	if body.Status == "" || body.Status == "string" {
+		body.Status = "available"
+	}
+
+	roomType, err := h.UseCase.RoomTypeRepo.GetSingle(ctx, entity.Id{ID: body.RoomTypeID})
+	if h.HandleDbError(ctx, err, "Error getting room type") {
+		return
+	}
+
+	if !h.canManageHotel(ctx, roomType.HotelID) {
+		return
+	}
+
+	room, err := h.UseCase.RoomsRepo.Create(ctx, body)
+	if h.HandleDbError(ctx, err, "Error creating room") {
+		return
+	}
+
+	ctx.JSON(201, room)
+}
+
+// GetRoom godoc
+// @Router /room/{id} [get]
+// @Summary Get a room by ID
+// @Description Get a room by ID together with its rating histogram
+// @Tags room
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Room ID"
+// @Success 200 {object} entity.Room
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) GetRoom(ctx *gin.Context) {
+	var (
+		req entity.Id
+	)
+
+	req.ID = ctx.Param("id")
+
+	room, err := h.UseCase.RoomsRepo.GetSingle(ctx, req)
+	if h.HandleDbError(ctx, err, "Error getting room") {
+		return
+	}
+
+	room.RatingHistogram, err = h.UseCase.RoomReviewRepo.GetRatingHistogram(ctx, req)
+	if h.HandleDbError(ctx, err, "Error getting rating histogram") {
+		return
+	}
+
+	rooms := []entity.Room{room}
+	if h.HandleDbError(ctx, h.attachRoomImages(ctx, rooms), "Error getting room images") {
+		return
+	}
+
+	ctx.JSON(200, rooms[0])
+}
+
+// GetRooms godoc
+// @Router /room/list [get]
+// @Summary Get a list of rooms
+// @Description Get a list of rooms
+// @Tags room
+// @Accept  json
+// @Produce  json
+// @Param page query number true "page"
+// @Param limit query number true "limit"
+// @Param hotel_id query string false "hotel_id"
+// @Param room_type_id query string false "room_type_id"
+// @Param floor query number false "floor"
+// @Param status query string false "status"
+// @Param order_by query string false "room_number, floor, rating or created_at"
+// @Param order query string false "asc or desc"
+// @Success 200 {object} entity.RoomList
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) GetRooms(ctx *gin.Context) {
+	var (
+		req entity.GetListFilter
+	)
+
+	page := ctx.DefaultQuery("page", "1")
+	limit := ctx.DefaultQuery("limit", "10")
+	orderBy := ctx.DefaultQuery("order_by", "created_at")
+	order := ctx.DefaultQuery("order", "desc")
+
+	req.Page, _ = strconv.Atoi(page)
+	req.Limit, _ = strconv.Atoi(limit)
+
+	for _, column := range []string{"hotel_id", "room_type_id", "floor", "status"} {
+		if value := ctx.Query(column); value != "" {
+			req.Filters = append(req.Filters, entity.Filter{
+				Column: column,
+				Type:   "eq",
+				Value:  value,
+			})
+		}
+	}
+
+	if !roomOrderColumns[orderBy] {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Invalid order_by column", http.StatusBadRequest)
+		return
+	}
+
+	if order != "asc" && order != "desc" {
+		h.ReturnError(ctx, config.ErrorBadRequest, "order must be asc or desc", http.StatusBadRequest)
+		return
+	}
+
+	req.OrderBy = append(req.OrderBy, entity.OrderBy{
+		Column: orderBy,
+		Order:  order,
+	})
+
+	rooms, err := h.UseCase.RoomsRepo.GetList(ctx, req)
+	if h.HandleDbError(ctx, err, "Error getting rooms") {
+		return
+	}
+
+	if h.HandleDbError(ctx, h.attachRoomImages(ctx, rooms.Items), "Error getting room images") {
+		return
+	}
+
+	ctx.JSON(200, rooms)
+}
+
+// UpdateRoom godoc
+// @Router /room [put]
+// @Summary Update a room
+// @Description Update a room in one of the admin's hotels. It may change to another room type of the same hotel
+// @Description unless it holds a stay or the bookings of its current type still need it.
+// @Security BearerAuth
+// @Tags room
+// @Accept  json
+// @Produce  json
+// @Param room body entity.Room true "Room object"
+// @Success 200 {object} entity.Room
+// @Failure 400 {object} entity.ErrorResponse
+// @Failure 409 {object} entity.ErrorResponse
+func (h *Handler) UpdateRoom(ctx *gin.Context) {
+	var (
+		body entity.Room
+	)
+
+	err := ctx.ShouldBindJSON(&body)
+	if err != nil {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Invalid request body", 400)
+		return
+	}
+
+	current, err := h.UseCase.RoomsRepo.GetSingle(ctx, entity.Id{ID: body.ID})
+	if h.HandleDbError(ctx, err, "Error getting room") {
+		return
+	}
+
+	if !h.canManageHotel(ctx, current.HotelID) {
+		return
+	}
+
+	if body.RoomTypeID != "" && body.RoomTypeID != current.RoomTypeID {
+		roomType, err := h.UseCase.RoomTypeRepo.GetSingle(ctx, entity.Id{ID: body.RoomTypeID})
+		if h.HandleDbError(ctx, err, "Error getting room type") {
+			return
+		}
+
+		if roomType.HotelID != current.HotelID {
+			h.ReturnError(ctx, config.ErrorBadRequest, "The room type must be of the same hotel", http.StatusBadRequest)
+			return
+		}
+	}
+
+	room, err := h.UseCase.RoomsRepo.Update(ctx, body)
+	if h.handleRoomTaken(ctx, err) {
+		return
+	}
+	if h.HandleDbError(ctx, err, "Error updating room") {
+		return
+	}
+
+	ctx.JSON(200, room)
+}
+
+// UpdateRoomStatus godoc
+// @Router /room/{id}/status [put]
+// @Summary Update the status of a room
+// @Description Set a room available, cleaning or under maintenance. Only admins may mark it booked, check-in does that.
+// @Description A room can't go under maintenance while the bookings of its type still need it.
+// @Security BearerAuth
+// @Tags room
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Room ID"
+// @Param status body entity.RoomStatusRequest true "Room status"
+// @Success 200 {object} entity.Room
+// @Failure 400 {object} entity.ErrorResponse
+// @Failure 409 {object} entity.ErrorResponse
+func (h *Handler) UpdateRoomStatus(ctx *gin.Context) {
+	var (
+		body entity.RoomStatusRequest
+	)
+
+	err := ctx.ShouldBindJSON(&body)
+	if err != nil {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Invalid request body", 400)
+		return
+	}
+
+	if !slices.Contains(roomStatusesByRole[ctx.GetHeader("user_role")], body.Status) {
+		h.ReturnError(ctx, config.ErrorForbidden, "You can't set a room to this status", http.StatusForbidden)
+		return
+	}
+
+	if !h.canManageRoom(ctx, ctx.Param("id")) {
+		return
+	}
+
+	room, err := h.UseCase.RoomsRepo.Update(ctx, entity.Room{
+		ID:     ctx.Param("id"),
+		Status: body.Status,
+	})
+	if h.handleRoomTaken(ctx, err) {
+		return
+	}
+	if h.HandleDbError(ctx, err, "Error updating room status") {
+		return
+	}
+
+	ctx.JSON(200, room)
+}
+
+// DeleteRoom godoc
+// @Router /room/{id} [delete]
+// @Summary Delete a room
+// @Description Delete a room in one of the admin's hotels, unless the bookings of its type still need it
+// @Security BearerAuth
+// @Tags room
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Room ID"
+// @Success 200 {object} entity.SuccessResponse
+// @Failure 400 {object} entity.ErrorResponse
+// @Failure 409 {object} entity.ErrorResponse
+func (h *Handler) DeleteRoom(ctx *gin.Context) {
+	var (
+		req entity.Id
+	)
+
+	req.ID = ctx.Param("id")
+
+	if !h.canManageRoom(ctx, req.ID) {
+		return
+	}
+
+	err := h.UseCase.RoomsRepo.Delete(ctx, req)
+	if h.handleRoomTaken(ctx, err) {
+		return
+	}
+	if h.HandleDbError(ctx, err, "Error deleting room") {
+		return
+	}
+
+	ctx.JSON(200, entity.SuccessResponse{
+		Message: "Room deleted successfully",
+	})
+}
+
+// handleRoomTaken writes a conflict when a room can't leave its type because it is still needed.
+func (h *Handler) handleRoomTaken(ctx *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, entity.ErrRoomOccupied):
+		h.ReturnError(ctx, config.ErrorConflict, "The room holds a stay, it can't change room type", http.StatusConflict)
+	case errors.Is(err, entity.ErrRoomTypeOversold):
+		h.ReturnError(ctx, config.ErrorConflict, "The bookings of this room type need the room, it would be overbooked", http.StatusConflict)
+	default:
+		return false
+	}
+
+	return true
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"strconv"
+	"time"
 
 	"github.com/Avazbek-02/Online-Hotel-System/config"
 	"github.com/Avazbek-02/Online-Hotel-System/internal/entity"
@@ -150,3 +151,102 @@ func (h *Handler) DeleteSession(ctx *gin.Context) {
 		Message: "Session deleted successfully",
 	})
 }
+
+// GetMySessions godoc
+// @Router /session/me [get]
+// @Summary List my devices
+// @Description Lists the active, unexpired sessions of the signed in user, the one making the request is marked as current
+// @Security BearerAuth
+// @Tags session
+// @Accept  json
+// @Produce  json
+// @Param page query number true "page"
+// @Param limit query number true "limit"
+// @Success 200 {object} entity.SessionList
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) GetMySessions(ctx *gin.Context) {
+	var (
+		req entity.GetListFilter
+	)
+
+	page := ctx.DefaultQuery("page", "1")
+	limit := ctx.DefaultQuery("limit", "10")
+
+	req.Page, _ = strconv.Atoi(page)
+	req.Limit, _ = strconv.Atoi(limit)
+
+	req.Filters = append(req.Filters,
+		entity.Filter{
+			Column: "user_id",
+			Type:   "eq",
+			Value:  ctx.GetHeader("sub"),
+		},
+		entity.Filter{
+			Column: "is_active",
+			Type:   "eq",
+			Value:  "true",
+		},
+		entity.Filter{
+			Column: "expires_at",
+			Type:   "gt",
+			Value:  time.Now().Format(time.RFC3339),
+		},
+	)
+
+	sessions, err := h.UseCase.SessionRepo.GetList(ctx, req)
+	if h.HandleDbError(ctx, err, "Error getting sessions") {
+		return
+	}
+
+	for i := range sessions.Items {
+		sessions.Items[i].IsCurrent = sessions.Items[i].ID == ctx.GetHeader("session_id")
+	}
+
+	ctx.JSON(200, sessions)
+}
+
+// RevokeMySession godoc
+// @Router /session/me/{id} [delete]
+// @Summary Log out a device
+// @Description Ends one of the sessions of the signed in user
+// @Security BearerAuth
+// @Tags session
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Session ID"
+// @Success 200 {object} entity.SuccessResponse
+// @Failure 404 {object} entity.ErrorResponse
+func (h *Handler) RevokeMySession(ctx *gin.Context) {
+	err := h.UseCase.SessionRepo.Revoke(ctx, entity.Session{
+		ID:     ctx.Param("id"),
+		UserID: ctx.GetHeader("sub"),
+	})
+	if h.HandleDbError(ctx, err, "Error revoking session") {
+		return
+	}
+
+	ctx.JSON(200, entity.SuccessResponse{
+		Message: "Device logged out",
+	})
+}
+
+// RevokeMyOtherSessions godoc
+// @Router /session/me/others [delete]
+// @Summary Log out everywhere else
+// @Description Ends every session of the signed in user except the current one
+// @Security BearerAuth
+// @Tags session
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} entity.SuccessResponse
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) RevokeMyOtherSessions(ctx *gin.Context) {
+	err := h.UseCase.SessionRepo.RevokeByUser(ctx, ctx.GetHeader("sub"), ctx.GetHeader("session_id"))
+	if h.HandleDbError(ctx, err, "Error revoking sessions") {
+		return
+	}
+
+	ctx.JSON(200, entity.SuccessResponse{
+		Message: "Logged out of all other devices",
+	})
+}
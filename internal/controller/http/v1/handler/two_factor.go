@@ -0,0 +1,427 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Avazbek-02/Online-Hotel-System/config"
+	"github.com/Avazbek-02/Online-Hotel-System/internal/entity"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/etc"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/hash"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/otp"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/totp"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	otpTwoFactor       = "two-factor"
+	otpTwoFactorEnroll = "two-factor-enroll"
+	otpTwoFactorLogin  = "two-factor-login"
+
+	recoveryCodeCount     = 10
+	twoFactorChallengeTTL = 5 * time.Minute
+	totpSkew              = 1
+
+	// wrong codes a login challenge takes before the password has to be entered again
+	twoFactorChallengeFailures = 3
+)
+
+// requiresTwoFactor reports whether a role can't sign in without a second factor.
+func requiresTwoFactor(role string) bool {
+	return role == "admin" || role == "superadmin"
+}
+
+func twoFactorChallengeKey(challenge string) string {
+	return "2fa-challenge-" + hash.HashToken(challenge)
+}
+
+// enrollTwoFactor generates a new secret and recovery codes for a user. They only take
+// effect once a code from the authenticator app is confirmed.
+func (h *Handler) enrollTwoFactor(ctx *gin.Context, user entity.User) (entity.TwoFactorEnrollment, error) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return entity.TwoFactorEnrollment{}, err
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		if codes[i], err = etc.GenerateRecoveryCode(); err != nil {
+			return entity.TwoFactorEnrollment{}, err
+		}
+		hashes[i] = hash.HashToken(etc.NormalizeRecoveryCode(codes[i]))
+	}
+
+	err = h.UseCase.TwoFactorRepo.Enroll(ctx, entity.TwoFactor{
+		UserID: user.ID,
+		Secret: secret,
+	}, hashes)
+	if err != nil {
+		return entity.TwoFactorEnrollment{}, err
+	}
+
+	return entity.TwoFactorEnrollment{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(h.Config.App.Name, user.Email, secret),
+		RecoveryCodes:   codes,
+	}, nil
+}
+
+// checkSecondFactor verifies a code from the authenticator app or an unused recovery
+// code. Wrong guesses count towards the same lockout as emailed codes.
+func (h *Handler) checkSecondFactor(ctx *gin.Context, twoFactor entity.TwoFactor, code, recoveryCode string) error {
+	return h.OTP.VerifyFunc(ctx, otpTwoFactor, twoFactor.UserID, ctx.ClientIP(), func() (bool, error) {
+		if recoveryCode != "" {
+			return h.UseCase.TwoFactorRepo.UseRecoveryCode(ctx, twoFactor.UserID, hash.HashToken(etc.NormalizeRecoveryCode(recoveryCode)))
+		}
+
+		step, ok := totp.Validate(twoFactor.Secret, strings.TrimSpace(code), time.Now(), totpSkew)
+		if !ok {
+			return false, nil
+		}
+
+		return h.UseCase.TwoFactorRepo.UseStep(ctx, twoFactor.UserID, step)
+	})
+}
+
+// failTwoFactorChallenge counts a wrong code against a login challenge and drops the
+// challenge once it has taken too many, so guessing has to go through the password again.
+// It reports whether the challenge was dropped and a response written.
+func (h *Handler) failTwoFactorChallenge(ctx *gin.Context, challenge string, err error) bool {
+	if !errors.Is(err, otp.ErrInvalidCode) && !errors.Is(err, otp.ErrLocked) {
+		return false
+	}
+
+	failures, err := h.OTP.Fail(ctx, otpTwoFactorLogin, hash.HashToken(challenge))
+	if err != nil {
+		h.Logger.Error(err, "Error counting two-factor challenge failures")
+		return false
+	}
+
+	if failures < twoFactorChallengeFailures {
+		return false
+	}
+
+	if err = h.Redis.Del(ctx, twoFactorChallengeKey(challenge)); err != nil {
+		h.ReturnError(ctx, config.ErrorInternalServer, "Ooops, something went wrong", http.StatusInternalServerError)
+		return true
+	}
+
+	h.ReturnError(ctx, config.ErrorSessionExpired, "Too many incorrect codes, please log in again", http.StatusUnauthorized)
+	return true
+}
+
+// twoFactorChallenge starts the second step of a login. The challenge stands in for the
+// password on the second call, so it is single use and short-lived. A password alone
+// must not be enough to set up an authenticator, so users that still have to enroll are
+// emailed a code that LoginTwoFactorEnroll wants before it hands out a secret.
+func (h *Handler) twoFactorChallenge(ctx *gin.Context, user entity.User, twoFactor entity.TwoFactor, platform string) (entity.TwoFactorChallenge, error) {
+	challenge, err := etc.GenerateToken()
+	if err != nil {
+		return entity.TwoFactorChallenge{}, err
+	}
+
+	response := entity.TwoFactorChallenge{
+		TwoFactorRequired: true,
+		Challenge:         challenge,
+		ExpiresAt:         time.Now().Add(twoFactorChallengeTTL).UTC().Format(time.RFC3339),
+	}
+
+	if !twoFactor.Enabled {
+		// the code sent a moment ago is still valid during the cooldown
+		err = h.sendOtp(ctx, otpTwoFactorEnroll, user.ID, user.Email)
+		if err != nil && !errors.Is(err, otp.ErrCooldown) {
+			return entity.TwoFactorChallenge{}, err
+		}
+
+		response.EnrollmentRequired = true
+	}
+
+	err = h.Redis.Set(ctx, twoFactorChallengeKey(challenge), user.ID+" "+platform, int(twoFactorChallengeTTL.Seconds()))
+	if err != nil {
+		return entity.TwoFactorChallenge{}, err
+	}
+
+	return response, nil
+}
+
+// LoginTwoFactor godoc
+// @Router /auth/login/2fa [post]
+// @Summary Finish a login with a second factor
+// @Description Completes a login that returned a two-factor challenge, with a code from the authenticator app or a recovery code.
+// @Description If the login required enrollment, the first valid code of the authenticator set up with /auth/login/2fa/enroll
+// @Description also turns two-factor authentication on.
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Param body body entity.TwoFactorLoginRequest true "Challenge and code"
+// @Success 200 {object} entity.User
+// @Failure 400 {object} entity.ErrorResponse
+// @Failure 401 {object} entity.ErrorResponse
+// @Failure 429 {object} entity.ErrorResponse
+func (h *Handler) LoginTwoFactor(ctx *gin.Context) {
+	var (
+		body entity.TwoFactorLoginRequest
+	)
+
+	err := ctx.ShouldBindJSON(&body)
+	if err != nil || body.Challenge == "" {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Invalid request body", 400)
+		return
+	}
+
+	value, err := h.Redis.Get(ctx, twoFactorChallengeKey(body.Challenge))
+	if err != nil {
+		h.ReturnError(ctx, config.ErrorSessionExpired, "Login challenge is invalid or expired, please log in again", http.StatusUnauthorized)
+		return
+	}
+
+	userID, platform, _ := strings.Cut(value, " ")
+
+	twoFactor, err := h.UseCase.TwoFactorRepo.Get(ctx, entity.Id{ID: userID})
+	if h.HandleDbError(ctx, err, "Error getting two-factor settings") {
+		return
+	}
+
+	// recovery codes only stand in for an authenticator that was set up
+	if !twoFactor.Enabled {
+		if twoFactor.Secret == "" {
+			h.ReturnError(ctx, config.ErrorBadRequest, "Set up two-factor authentication with /auth/login/2fa/enroll first", http.StatusBadRequest)
+			return
+		}
+
+		body.RecoveryCode = ""
+	}
+
+	err = h.checkSecondFactor(ctx, twoFactor, body.Code, body.RecoveryCode)
+	if h.failTwoFactorChallenge(ctx, body.Challenge, err) || h.HandleOtpError(ctx, err) {
+		return
+	}
+
+	if err = h.Redis.Del(ctx, twoFactorChallengeKey(body.Challenge)); err != nil {
+		h.ReturnError(ctx, config.ErrorInternalServer, "Ooops, something went wrong", http.StatusInternalServerError)
+		return
+	}
+
+	if !twoFactor.Enabled {
+		err = h.UseCase.TwoFactorRepo.Enable(ctx, entity.Id{ID: userID})
+		if h.HandleDbError(ctx, err, "Error enabling two-factor authentication") {
+			return
+		}
+	}
+
+	user, err := h.UseCase.UserRepo.GetSingle(ctx, entity.UserSingleRequest{
+		ID: userID,
+	})
+	if h.HandleDbError(ctx, err, "Error getting user") {
+		return
+	}
+
+	session, tokens, err := h.startSession(ctx, user, platform, true)
+	if h.HandleDbError(ctx, err, "Error while creating new session") {
+		return
+	}
+
+	user.AccessToken = tokens.AccessToken
+	user.RefreshToken = tokens.RefreshToken
+
+	ctx.JSON(200, gin.H{
+		"user":    user,
+		"session": session,
+	})
+}
+
+// LoginTwoFactorEnroll godoc
+// @Router /auth/login/2fa/enroll [post]
+// @Summary Set up two-factor authentication during a login
+// @Description For a login that returned enrollment_required: the code emailed with the challenge proves the login is the
+// @Description account owner's, then a new secret and recovery codes are returned. The login is finished with /auth/login/2fa.
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Param body body entity.TwoFactorEnrollRequest true "Challenge and emailed code"
+// @Success 200 {object} entity.TwoFactorEnrollment
+// @Failure 400 {object} entity.ErrorResponse
+// @Failure 401 {object} entity.ErrorResponse
+// @Failure 429 {object} entity.ErrorResponse
+func (h *Handler) LoginTwoFactorEnroll(ctx *gin.Context) {
+	var (
+		body entity.TwoFactorEnrollRequest
+	)
+
+	err := ctx.ShouldBindJSON(&body)
+	if err != nil || body.Challenge == "" || body.EmailCode == "" {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Invalid request body", 400)
+		return
+	}
+
+	value, err := h.Redis.Get(ctx, twoFactorChallengeKey(body.Challenge))
+	if err != nil {
+		h.ReturnError(ctx, config.ErrorSessionExpired, "Login challenge is invalid or expired, please log in again", http.StatusUnauthorized)
+		return
+	}
+
+	userID, _, _ := strings.Cut(value, " ")
+
+	err = h.OTP.Verify(ctx, otpTwoFactorEnroll, userID, ctx.ClientIP(), strings.TrimSpace(body.EmailCode))
+	if h.failTwoFactorChallenge(ctx, body.Challenge, err) || h.HandleOtpError(ctx, err) {
+		return
+	}
+
+	user, err := h.UseCase.UserRepo.GetSingle(ctx, entity.UserSingleRequest{
+		ID: userID,
+	})
+	if h.HandleDbError(ctx, err, "Error getting user") {
+		return
+	}
+
+	enrollment, err := h.enrollTwoFactor(ctx, user)
+	if errors.Is(err, entity.ErrTwoFactorEnabled) {
+		h.ReturnError(ctx, config.ErrorConflict, "Two-factor authentication is already enabled", http.StatusConflict)
+		return
+	}
+	if h.HandleDbError(ctx, err, "Error enrolling two-factor authentication") {
+		return
+	}
+
+	ctx.JSON(200, enrollment)
+}
+
+// EnrollTwoFactor godoc
+// @Router /user/2fa [post]
+// @Summary Set up two-factor authentication
+// @Description Returns a new secret as a provisioning URI for authenticator apps and a set of recovery codes.
+// @Description Two-factor authentication is turned on once a code is confirmed.
+// @Security BearerAuth
+// @Tags user
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} entity.TwoFactorEnrollment
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) EnrollTwoFactor(ctx *gin.Context) {
+	user, err := h.UseCase.UserRepo.GetSingle(ctx, entity.UserSingleRequest{
+		ID: ctx.GetHeader("sub"),
+	})
+	if h.HandleDbError(ctx, err, "Error getting user") {
+		return
+	}
+
+	enrollment, err := h.enrollTwoFactor(ctx, user)
+	if errors.Is(err, entity.ErrTwoFactorEnabled) {
+		h.ReturnError(ctx, config.ErrorConflict, "Two-factor authentication is already enabled", http.StatusConflict)
+		return
+	}
+	if h.HandleDbError(ctx, err, "Error enrolling two-factor authentication") {
+		return
+	}
+
+	ctx.JSON(200, enrollment)
+}
+
+// ConfirmTwoFactor godoc
+// @Router /user/2fa/confirm [put]
+// @Summary Turn on two-factor authentication
+// @Description Confirms the enrollment with a code from the authenticator app
+// @Security BearerAuth
+// @Tags user
+// @Accept  json
+// @Produce  json
+// @Param body body entity.TwoFactorCodeRequest true "Code"
+// @Success 200 {object} entity.SuccessResponse
+// @Failure 400 {object} entity.ErrorResponse
+// @Failure 429 {object} entity.ErrorResponse
+func (h *Handler) ConfirmTwoFactor(ctx *gin.Context) {
+	var (
+		body entity.TwoFactorCodeRequest
+	)
+
+	err := ctx.ShouldBindJSON(&body)
+	if err != nil {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Invalid request body", 400)
+		return
+	}
+
+	twoFactor, err := h.UseCase.TwoFactorRepo.Get(ctx, entity.Id{ID: ctx.GetHeader("sub")})
+	if h.HandleDbError(ctx, err, "Error getting two-factor settings") {
+		return
+	}
+
+	if twoFactor.Enabled {
+		h.ReturnError(ctx, config.ErrorConflict, "Two-factor authentication is already enabled", http.StatusConflict)
+		return
+	}
+	if twoFactor.Secret == "" {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Set up two-factor authentication first", http.StatusBadRequest)
+		return
+	}
+
+	err = h.checkSecondFactor(ctx, twoFactor, body.Code, "")
+	if h.HandleOtpError(ctx, err) {
+		return
+	}
+
+	err = h.UseCase.TwoFactorRepo.Enable(ctx, entity.Id{ID: twoFactor.UserID})
+	if h.HandleDbError(ctx, err, "Error enabling two-factor authentication") {
+		return
+	}
+
+	ctx.JSON(200, entity.SuccessResponse{
+		Message: "Two-factor authentication enabled",
+	})
+}
+
+// DisableTwoFactor godoc
+// @Router /user/2fa [delete]
+// @Summary Turn off two-factor authentication
+// @Description Turns off two-factor authentication with a current code or a recovery code. Admins can't turn it off.
+// @Security BearerAuth
+// @Tags user
+// @Accept  json
+// @Produce  json
+// @Param body body entity.TwoFactorCodeRequest true "Code"
+// @Success 200 {object} entity.SuccessResponse
+// @Failure 400 {object} entity.ErrorResponse
+// @Failure 403 {object} entity.ErrorResponse
+// @Failure 429 {object} entity.ErrorResponse
+func (h *Handler) DisableTwoFactor(ctx *gin.Context) {
+	var (
+		body entity.TwoFactorCodeRequest
+	)
+
+	err := ctx.ShouldBindJSON(&body)
+	if err != nil {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Invalid request body", 400)
+		return
+	}
+
+	if requiresTwoFactor(ctx.GetHeader("user_role")) {
+		h.ReturnError(ctx, config.ErrorForbidden, "Two-factor authentication is mandatory for admins", http.StatusForbidden)
+		return
+	}
+
+	twoFactor, err := h.UseCase.TwoFactorRepo.Get(ctx, entity.Id{ID: ctx.GetHeader("sub")})
+	if h.HandleDbError(ctx, err, "Error getting two-factor settings") {
+		return
+	}
+
+	if !twoFactor.Enabled {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Two-factor authentication is not enabled", http.StatusBadRequest)
+		return
+	}
+
+	err = h.checkSecondFactor(ctx, twoFactor, body.Code, body.RecoveryCode)
+	if h.HandleOtpError(ctx, err) {
+		return
+	}
+
+	err = h.UseCase.TwoFactorRepo.Disable(ctx, entity.Id{ID: twoFactor.UserID})
+	if h.HandleDbError(ctx, err, "Error disabling two-factor authentication") {
+		return
+	}
+
+	ctx.JSON(200, entity.SuccessResponse{
+		Message: "Two-factor authentication disabled",
+	})
+}
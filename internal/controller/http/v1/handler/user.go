@@ -1,20 +1,30 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"net/mail"
+	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Avazbek-02/Online-Hotel-System/config"
 	"github.com/Avazbek-02/Online-Hotel-System/internal/entity"
 	"github.com/Avazbek-02/Online-Hotel-System/pkg/hash"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/storage"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
+const minPasswordLength = 8
+
 // CreateUser godoc
 // @Router /user [post]
 // @Summary Create a new user
-// @Description Create a new user
+// @Description Create a new user. Only a superadmin can set the role and status, other accounts are created as active guests.
 // @Security BearerAuth
 // @Tags user
 // @Accept  json
@@ -33,6 +43,18 @@ func (h *Handler) CreateUser(ctx *gin.Context) {
 		return
 	}
 
+	// only a superadmin picks the role and status of a new account
+	if ctx.GetHeader("user_role") != entity.UserRoleSuperadmin {
+		body.UserRole = ""
+		body.UserStatus = ""
+	}
+	if body.UserRole == "" {
+		body.UserRole = entity.UserRoleUser
+	}
+	if body.UserStatus == "" {
+		body.UserStatus = "active"
+	}
+
 	body.Password_hash, err = hash.HashPassword(body.Password_hash)
 	if err != nil {
 		h.ReturnError(ctx, config.ErrorBadRequest, "Error hashing password", 400)
@@ -50,7 +72,7 @@ func (h *Handler) CreateUser(ctx *gin.Context) {
 // GetUser godoc
 // @Router /user/{id} [get]
 // @Summary Get a user by ID
-// @Description Get a user by ID
+// @Description Get a user by ID. Staff can only get themselves and the guests who booked at one of their hotels.
 // @Security BearerAuth
 // @Tags user
 // @Accept  json
@@ -69,6 +91,33 @@ func (h *Handler) GetUser(ctx *gin.Context) {
 	if h.HandleDbError(ctx, err, "Error getting user") {
 		return
 	}
+
+	// staff see their guests, not other staff
+	switch ctx.GetHeader("user_role") {
+	case entity.UserRoleReceptionist, entity.UserRoleHousekeeping, entity.UserRoleManager:
+		if user.ID == ctx.GetHeader("sub") {
+			break
+		}
+
+		hotelIds, _, err := h.hotelScope(ctx)
+		if h.HandleDbError(ctx, err, "Error getting hotel assignments") {
+			return
+		}
+
+		var guest bool
+		if user.UserRole == entity.UserRoleUser {
+			guest, err = h.isHotelUser(ctx, user, hotelIds)
+			if h.HandleDbError(ctx, err, "Error getting bookings") {
+				return
+			}
+		}
+
+		if !guest {
+			h.ReturnError(ctx, config.ErrorForbidden, "You can only see guests of your hotels", http.StatusForbidden)
+			return
+		}
+	}
+
 	user.Password_hash = " "
 	ctx.JSON(200, user)
 }
@@ -76,7 +125,7 @@ func (h *Handler) GetUser(ctx *gin.Context) {
 // GetUsers godoc
 // @Router /user/list [get]
 // @Summary Get a list of users
-// @Description Get a list of users
+// @Description Get a list of users. Staff and admins only get the guests who booked at one of their hotels and the staff assigned to them.
 // @Security BearerAuth
 // @Tags user
 // @Accept  json
@@ -120,6 +169,24 @@ func (h *Handler) GetUsers(ctx *gin.Context) {
 		Order:  "desc",
 	})
 
+	// guests only ever see their own account
+	if ctx.GetHeader("user_role") == entity.UserRoleUser {
+		h.ReturnError(ctx, config.ErrorForbidden, "You can't list users", http.StatusForbidden)
+		return
+	}
+
+	hotelIds, all, err := h.hotelScope(ctx)
+	if h.HandleDbError(ctx, err, "Error getting hotel assignments") {
+		return
+	}
+	if !all {
+		req.Filters = append(req.Filters, entity.Filter{
+			Column: "hotel_id",
+			Type:   "in",
+			Values: hotelIds,
+		})
+	}
+
 	users, err := h.UseCase.UserRepo.GetList(ctx, req)
 	if h.HandleDbError(ctx, err, "Error getting users") {
 		return
@@ -131,7 +198,8 @@ func (h *Handler) GetUsers(ctx *gin.Context) {
 // UpdateUser godoc
 // @Router /user [put]
 // @Summary Update a user
-// @Description Update a user
+// @Description Update a user. Guests and staff can only change their own profile fields, the email and password have their own endpoints.
+// @Description Admins can also block staff and guests of their hotels, only a superadmin can change roles.
 // @Security BearerAuth
 // @Tags user
 // @Accept  json
@@ -150,19 +218,33 @@ func (h *Handler) UpdateUser(ctx *gin.Context) {
 		return
 	}
 
-	if ctx.GetHeader("user_type") == "user" {
-		body.ID = ctx.GetHeader("sub")
+	// credentials are never changed here, see ChangePassword and ChangeEmail
+	update := entity.User{
+		ID:       body.ID,
+		FullName: body.FullName,
+		UserName: body.UserName,
+		Phone:    body.Phone,
+		Gender:   body.Gender,
 	}
 
-	if body.Password_hash != "" {
-		body.Password_hash, err = hash.HashPassword(body.Password_hash)
-		if err != nil {
-			h.ReturnError(ctx, config.ErrorBadRequest, "Error hashing password", 400)
+	// guests and staff only edit their own profile
+	switch ctx.GetHeader("user_role") {
+	case entity.UserRoleSuperadmin:
+		update.UserStatus = body.UserStatus
+		update.UserRole = body.UserRole
+	case entity.UserRoleAdmin:
+		if update.ID == "" {
+			update.ID = ctx.GetHeader("sub")
+		}
+		if update.ID != ctx.GetHeader("sub") && !h.canManageUser(ctx, update.ID) {
 			return
 		}
+		update.UserStatus = body.UserStatus
+	default:
+		update.ID = ctx.GetHeader("sub")
 	}
 
-	user, err := h.UseCase.UserRepo.Update(ctx, body)
+	user, err := h.UseCase.UserRepo.Update(ctx, update)
 	if h.HandleDbError(ctx, err, "Error updating user") {
 		return
 	}
@@ -170,10 +252,235 @@ func (h *Handler) UpdateUser(ctx *gin.Context) {
 	ctx.JSON(200, user)
 }
 
+// ChangePassword godoc
+// @Router /user/password [put]
+// @Summary Change your password
+// @Description Change the password of the signed in user. The current password is required.
+// @Security BearerAuth
+// @Tags user
+// @Accept  json
+// @Produce  json
+// @Param body body entity.ChangePasswordRequest true "Passwords"
+// @Success 200 {object} entity.SuccessResponse
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) ChangePassword(ctx *gin.Context) {
+	var (
+		body entity.ChangePasswordRequest
+	)
+
+	err := ctx.ShouldBindJSON(&body)
+	if err != nil {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Invalid request body", 400)
+		return
+	}
+
+	if len(body.NewPassword) < minPasswordLength {
+		h.ReturnError(ctx, config.ErrorInvalidPass, fmt.Sprintf("Password must be at least %d characters", minPasswordLength), http.StatusBadRequest)
+		return
+	}
+
+	userID := ctx.GetHeader("sub")
+
+	passwordHash, err := h.UseCase.UserRepo.GetPasswordHash(ctx, entity.Id{ID: userID})
+	if h.HandleDbError(ctx, err, "Error getting user") {
+		return
+	}
+
+	if !hash.CheckPasswordHash(body.OldPassword, passwordHash) {
+		h.ReturnError(ctx, config.ErrorInvalidPass, "Incorrect password", http.StatusBadRequest)
+		return
+	}
+
+	passwordHash, err = hash.HashPassword(body.NewPassword)
+	if err != nil {
+		h.ReturnError(ctx, config.ErrorInternalServer, "Error hashing password", http.StatusInternalServerError)
+		return
+	}
+
+	err = h.UseCase.UserRepo.UpdatePassword(ctx, entity.User{
+		ID:            userID,
+		Password_hash: passwordHash,
+	})
+	if h.HandleDbError(ctx, err, "Error updating password") {
+		return
+	}
+
+	if body.LogoutOtherSessions {
+		err = h.UseCase.SessionRepo.RevokeByUser(ctx, userID, ctx.GetHeader("session_id"))
+		if h.HandleDbError(ctx, err, "Error revoking sessions") {
+			return
+		}
+	}
+
+	ctx.JSON(200, entity.SuccessResponse{
+		Message: "Password changed successfully",
+	})
+}
+
+// ChangeEmail godoc
+// @Router /user/email [post]
+// @Summary Request an email change
+// @Description Sends a code to the new address. The email changes once the code is confirmed.
+// @Security BearerAuth
+// @Tags user
+// @Accept  json
+// @Produce  json
+// @Param body body entity.ChangeEmailRequest true "New email"
+// @Success 200 {object} entity.SuccessResponse
+// @Failure 400 {object} entity.ErrorResponse
+// @Failure 429 {object} entity.ErrorResponse
+func (h *Handler) ChangeEmail(ctx *gin.Context) {
+	var (
+		body entity.ChangeEmailRequest
+	)
+
+	err := ctx.ShouldBindJSON(&body)
+	if err != nil {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Invalid request body", 400)
+		return
+	}
+
+	if _, err = mail.ParseAddress(body.NewEmail); err != nil {
+		h.ReturnError(ctx, config.ErrorInvalidEmail, "Invalid email address", http.StatusBadRequest)
+		return
+	}
+
+	_, err = h.UseCase.UserRepo.GetSingle(ctx, entity.UserSingleRequest{
+		Email: body.NewEmail,
+	})
+	if err == nil {
+		h.ReturnError(ctx, config.ErrorConflict, "Email is already in use", http.StatusConflict)
+		return
+	}
+
+	err = h.sendOtp(ctx, otpChangeEmail, emailChangeSubject(ctx.GetHeader("sub"), body.NewEmail), body.NewEmail)
+	if h.HandleOtpError(ctx, err) {
+		return
+	}
+
+	ctx.JSON(200, entity.SuccessResponse{
+		Message: "A confirmation code has been sent to the new email address",
+	})
+}
+
+// ConfirmEmailChange godoc
+// @Router /user/email/confirm [put]
+// @Summary Confirm an email change
+// @Description Confirms the new email with the code sent to it
+// @Security BearerAuth
+// @Tags user
+// @Accept  json
+// @Produce  json
+// @Param body body entity.ConfirmEmailChangeRequest true "New email and code"
+// @Success 200 {object} entity.User
+// @Failure 400 {object} entity.ErrorResponse
+// @Failure 429 {object} entity.ErrorResponse
+func (h *Handler) ConfirmEmailChange(ctx *gin.Context) {
+	var (
+		body entity.ConfirmEmailChangeRequest
+	)
+
+	err := ctx.ShouldBindJSON(&body)
+	if err != nil {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Invalid request body", 400)
+		return
+	}
+
+	userID := ctx.GetHeader("sub")
+
+	err = h.OTP.Verify(ctx, otpChangeEmail, emailChangeSubject(userID, body.NewEmail), ctx.ClientIP(), body.Otp)
+	if h.HandleOtpError(ctx, err) {
+		return
+	}
+
+	user, err := h.UseCase.UserRepo.Update(ctx, entity.User{
+		ID:    userID,
+		Email: body.NewEmail,
+	})
+	if h.HandleDbError(ctx, err, "Error updating email") {
+		return
+	}
+
+	if body.LogoutOtherSessions {
+		err = h.UseCase.SessionRepo.RevokeByUser(ctx, userID, ctx.GetHeader("session_id"))
+		if h.HandleDbError(ctx, err, "Error revoking sessions") {
+			return
+		}
+	}
+
+	ctx.JSON(200, user)
+}
+
+// emailChangeSubject binds an email change code to both the user and the new address.
+func emailChangeSubject(userID, email string) string {
+	return userID + ":" + strings.ToLower(email)
+}
+
+// canManageUser reports whether the caller may change or delete another user and writes
+// the error response when not. Superadmins manage everyone, admins the staff assigned to one
+// of their hotels and the guests who booked at one of them.
+func (h *Handler) canManageUser(ctx *gin.Context, userID string) bool {
+	if ctx.GetHeader("user_role") == entity.UserRoleSuperadmin {
+		return true
+	}
+
+	user, err := h.UseCase.UserRepo.GetSingle(ctx, entity.UserSingleRequest{ID: userID})
+	if h.HandleDbError(ctx, err, "Error getting user") {
+		return false
+	}
+
+	hotelIds, _, err := h.hotelScope(ctx)
+	if h.HandleDbError(ctx, err, "Error getting hotel assignments") {
+		return false
+	}
+
+	linked, err := h.isHotelUser(ctx, user, hotelIds)
+	if h.HandleDbError(ctx, err, "Error getting hotel assignments") {
+		return false
+	}
+
+	if !linked {
+		h.ReturnError(ctx, config.ErrorForbidden, "You can only manage staff and guests of your hotels", http.StatusForbidden)
+		return false
+	}
+
+	return true
+}
+
+// isHotelUser reports whether a user is a guest who booked at one of the hotels or staff
+// assigned to one of them. Admins and superadmins are neither.
+func (h *Handler) isHotelUser(ctx *gin.Context, user entity.User, hotelIds []string) (bool, error) {
+	switch user.UserRole {
+	case entity.UserRoleSuperadmin, entity.UserRoleAdmin:
+		return false, nil
+	case entity.UserRoleUser:
+		bookings, err := h.UseCase.BookingRepo.GetList(ctx, entity.GetListFilter{
+			Page:  1,
+			Limit: 1,
+			Filters: []entity.Filter{
+				{Column: "user_id", Type: "eq", Value: user.ID},
+				{Column: "hotel_id", Type: "in", Values: hotelIds},
+			},
+		})
+		if err != nil {
+			return false, err
+		}
+		return bookings.Count > 0, nil
+	default:
+		staffHotelIds, err := h.UseCase.HotelRepo.GetHotelIDsByStaff(ctx, entity.Id{ID: user.ID})
+		if err != nil {
+			return false, err
+		}
+		return slices.ContainsFunc(staffHotelIds, func(id string) bool {
+			return slices.Contains(hotelIds, id)
+		}), nil
+	}
+}
+
 // DeleteUser godoc
 // @Router /user/{id} [delete]
 // @Summary Delete a user
-// @Description Delete a user
+// @Description Delete a user. Guests can only delete themselves, admins staff and guests of their hotels.
 // @Security BearerAuth
 // @Tags user
 // @Accept  json
@@ -188,8 +495,10 @@ func (h *Handler) DeleteUser(ctx *gin.Context) {
 
 	req.ID = ctx.Param("id")
 
-	if ctx.GetHeader("user_type") == "user" {
+	if ctx.GetHeader("user_role") == entity.UserRoleUser {
 		req.ID = ctx.GetHeader("sub")
+	} else if req.ID != ctx.GetHeader("sub") && !h.canManageUser(ctx, req.ID) {
+		return
 	}
 
 	err := h.UseCase.UserRepo.Delete(ctx, req)
@@ -205,7 +514,7 @@ func (h *Handler) DeleteUser(ctx *gin.Context) {
 // UploadImage godoc
 // @Router /user/upload [post]
 // @Summary Upload an image to MinIO
-// @Description Upload an image to MinIO without saving data to the database
+// @Description Upload an image to MinIO without saving data to the database. It is stored as thumbnail, medium and full size JPEG.
 // @Security BearerAuth
 // @Tags upload
 // @Accept multipart/form-data
@@ -220,21 +529,85 @@ func (h *Handler) UploadImage(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, err)
 		return
 	}
-	filename := uuid.New().String() + "-" + file.Filename
 
-	tempPath := "/tmp/" + filename
-	if err := c.SaveUploadedFile(file, tempPath); err != nil {
-		c.JSON(http.StatusInternalServerError, err)
+	images, err := readImage(file)
+	if err != nil {
+		h.ReturnError(c, config.ErrorBadRequest, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	minioURL, err := h.MinIO.Upload(filename, tempPath)
+	objects, err := storage.UploadImage(c, h.Storage, "uploads/"+uuid.New().String(), images)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, err)
 		return
 	}
 
-	c.JSON(200, gin.H{"worked": minioURL})
+	variants := make(map[string]string, len(objects))
+	for _, object := range objects {
+		variants[object.Variant] = object.URL
+	}
+
+	c.JSON(200, gin.H{"worked": variants["full"], "variants": variants})
+}
+
+// CreateUploadURL godoc
+// @Router /user/upload-url [post]
+// @Summary Get a presigned upload URL
+// @Description Returns a short-lived URL to PUT a file straight into storage, bypassing the API server.
+// @Description The upload must send the returned headers, which fix its size. Uploads that are never imported expire after a day.
+// @Description The returned object_name is then passed to an import endpoint such as /room/{id}/images/import.
+// @Security BearerAuth
+// @Tags upload
+// @Accept json
+// @Produce json
+// @Param upload body entity.UploadURLRequest true "File to upload"
+// @Success 200 {object} entity.UploadURLResponse
+// @Failure 400 {object} entity.ErrorResponse
+func (h *Handler) CreateUploadURL(ctx *gin.Context) {
+	var (
+		body entity.UploadURLRequest
+	)
+
+	err := ctx.ShouldBindJSON(&body)
+	if err != nil {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Invalid request body", 400)
+		return
+	}
+
+	ext := strings.ToLower(filepath.Ext(body.FileName))
+	contentType, ok := storage.ContentType[ext]
+	if !ok {
+		h.ReturnError(ctx, config.ErrorBadRequest, "Unsupported image type", http.StatusBadRequest)
+		return
+	}
+
+	if body.Size <= 0 || body.Size > maxImageSize {
+		h.ReturnError(ctx, config.ErrorBadRequest, "size must be between 1 byte and 20 MB", http.StatusBadRequest)
+		return
+	}
+
+	objectName := incomingPrefix(ctx.GetHeader("sub")) + uuid.NewString() + ext
+
+	uploadURL, expiresAt, err := h.Storage.PresignedPutURL(ctx, objectName, body.Size, contentType)
+	if errors.Is(err, storage.ErrNotSupported) {
+		h.ReturnError(ctx, config.ErrorNotImplemented, "Direct uploads are not available, use multipart upload instead", http.StatusNotImplemented)
+		return
+	}
+	if err != nil {
+		h.Logger.Error(err, "Error presigning upload URL")
+		h.ReturnError(ctx, config.ErrorInternalServer, "Error creating upload URL", http.StatusInternalServerError)
+		return
+	}
+
+	ctx.JSON(200, entity.UploadURLResponse{
+		ObjectName: objectName,
+		UploadURL:  uploadURL,
+		Headers: map[string]string{
+			"Content-Length": strconv.FormatInt(body.Size, 10),
+			"Content-Type":   contentType,
+		},
+		ExpiresAt: expiresAt.Format(time.RFC3339),
+	})
 }
 
 // // SetUserAvatar godoc
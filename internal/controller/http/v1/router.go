@@ -4,7 +4,6 @@ package v1
 import (
 	"net/http"
 
-	"github.com/casbin/casbin"
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	swaggerFiles "github.com/swaggo/files"
@@ -15,8 +14,10 @@ import (
 	_ "github.com/Avazbek-02/Online-Hotel-System/docs"
 	"github.com/Avazbek-02/Online-Hotel-System/internal/controller/http/v1/handler"
 	"github.com/Avazbek-02/Online-Hotel-System/internal/usecase"
-	minio "github.com/Avazbek-02/Online-Hotel-System/pkg/MinIO"
 	"github.com/Avazbek-02/Online-Hotel-System/pkg/logger"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/otp"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/rbac"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/storage"
 	rediscache "github.com/golanguzb70/redis-cache"
 )
 
@@ -31,14 +32,13 @@ import (
 // @securityDefinitions.apikey BearerAuth
 // @in header
 // @name Authorization
-func NewRouter(engine *gin.Engine, l *logger.Logger, config *config.Config, useCase *usecase.UseCase, redis rediscache.RedisCache, minio *minio.MinIO) {
+func NewRouter(engine *gin.Engine, l *logger.Logger, config *config.Config, useCase *usecase.UseCase, redis rediscache.RedisCache, store storage.Storage, otpStore *otp.Store, enforcer *rbac.Enforcer) {
 	engine.Use(gin.Logger())
 	engine.Use(gin.Recovery())
 
-	handlerV1 := handler.NewHandler(l, config, useCase, redis,minio)
+	handlerV1 := handler.NewHandler(l, config, useCase, redis, store, otpStore, enforcer)
 
-	e := casbin.NewEnforcer("config/rbac.conf", "config/policy.csv")
-	engine.Use(handlerV1.AuthMiddleware(e))
+	engine.Use(handlerV1.AuthMiddleware(enforcer))
 
 	url := ginSwagger.URL("swagger/doc.json")
 	engine.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, url))
@@ -47,30 +47,174 @@ func NewRouter(engine *gin.Engine, l *logger.Logger, config *config.Config, useC
 
 	engine.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
+	// the local filesystem backend has nothing else serving its files
+	if local, ok := store.(*storage.Local); ok {
+		engine.Static(config.Storage.LocalPath, local.Root())
+	}
+
 	v1 := engine.Group("/v1")
 
+	v1.GET("/media/*object", handlerV1.GetMedia)
+
 	user := v1.Group("/user")
 	{
 		user.POST("/", handlerV1.CreateUser)
 		user.GET("/list", handlerV1.GetUsers)
 		user.GET("/:id", handlerV1.GetUser)
 		user.PUT("/", handlerV1.UpdateUser)
+		user.PUT("/password", handlerV1.ChangePassword)
+		user.POST("/email", handlerV1.ChangeEmail)
+		user.PUT("/email/confirm", handlerV1.ConfirmEmailChange)
+		user.POST("/2fa", handlerV1.EnrollTwoFactor)
+		user.PUT("/2fa/confirm", handlerV1.ConfirmTwoFactor)
+		user.DELETE("/2fa", handlerV1.DisableTwoFactor)
 		user.DELETE("/:id", handlerV1.DeleteUser)
+		user.POST("/upload-url", handlerV1.CreateUploadURL)
 	}
 
 	session := v1.Group("/session")
 	{
+		session.GET("/me", handlerV1.GetMySessions)
+		session.DELETE("/me/others", handlerV1.RevokeMyOtherSessions)
+		session.DELETE("/me/:id", handlerV1.RevokeMySession)
 		session.GET("/list", handlerV1.GetSessions)
 		session.GET("/:id", handlerV1.GetSession)
 		session.PUT("/", handlerV1.UpdateSession)
 		session.DELETE("/:id", handlerV1.DeleteSession)
 	}
 
+	room := v1.Group("/room")
+	{
+		room.POST("/", handlerV1.CreateRoom)
+		room.GET("/list", handlerV1.GetRooms)
+		room.GET("/:id", handlerV1.GetRoom)
+		room.PUT("/", handlerV1.UpdateRoom)
+		room.DELETE("/:id", handlerV1.DeleteRoom)
+		room.PUT("/:id/status", handlerV1.UpdateRoomStatus)
+		room.POST("/:id/images", handlerV1.UploadRoomImages)
+		room.POST("/:id/images/import", handlerV1.ImportRoomImages)
+		room.GET("/:id/images", handlerV1.GetRoomImages)
+		room.PUT("/:id/images/order", handlerV1.ReorderRoomImages)
+		room.PUT("/:id/images/:image_id/cover", handlerV1.SetRoomCoverImage)
+		room.DELETE("/:id/images/:image_id", handlerV1.DeleteRoomImage)
+	}
+
+	booking := v1.Group("/booking")
+	{
+		booking.POST("/", handlerV1.CreateBooking)
+		booking.GET("/list", handlerV1.GetBookings)
+		booking.GET("/:id", handlerV1.GetBooking)
+		booking.PUT("/", handlerV1.UpdateBooking)
+		booking.PUT("/:id/cancel", handlerV1.CancelBooking)
+		booking.GET("/:id/balance", handlerV1.GetBookingBalance)
+		booking.PUT("/:id/check-in", handlerV1.CheckInBooking)
+		booking.PUT("/:id/check-out", handlerV1.CheckOutBooking)
+		booking.DELETE("/:id", handlerV1.DeleteBooking)
+	}
+
+	payment := v1.Group("/payment")
+	{
+		payment.POST("/", handlerV1.CreatePayment)
+		payment.POST("/webhook", handlerV1.PaymentWebhook)
+		payment.GET("/list", handlerV1.GetPayments)
+		payment.GET("/:id", handlerV1.GetPayment)
+	}
+
+	refund := v1.Group("/refund")
+	{
+		refund.GET("/list", handlerV1.GetRefunds)
+		refund.PUT("/:id/settle", handlerV1.SettleRefund)
+	}
+
+	cancellationPolicy := v1.Group("/cancellation-policy")
+	{
+		cancellationPolicy.POST("/", handlerV1.CreateCancellationPolicy)
+		cancellationPolicy.GET("/list", handlerV1.GetCancellationPolicies)
+		cancellationPolicy.GET("/:id", handlerV1.GetCancellationPolicy)
+		cancellationPolicy.PUT("/", handlerV1.UpdateCancellationPolicy)
+		cancellationPolicy.DELETE("/:id", handlerV1.DeleteCancellationPolicy)
+	}
+
+	complaint := v1.Group("/complaint")
+	{
+		complaint.POST("/", handlerV1.CreateComplaint)
+		complaint.GET("/list", handlerV1.GetComplaints)
+		complaint.GET("/:id", handlerV1.GetComplaint)
+		complaint.PUT("/:id/assign", handlerV1.AssignComplaint)
+		complaint.PUT("/:id/status", handlerV1.UpdateComplaintStatus)
+		complaint.POST("/:id/message", handlerV1.AddComplaintMessage)
+		complaint.DELETE("/:id", handlerV1.DeleteComplaint)
+	}
+
+	review := v1.Group("/review")
+	{
+		review.POST("/", handlerV1.CreateReview)
+		review.GET("/list", handlerV1.GetReviews)
+		review.GET("/:id", handlerV1.GetReview)
+		review.PUT("/", handlerV1.UpdateReview)
+		review.DELETE("/:id", handlerV1.DeleteReview)
+	}
+
 	auth := v1.Group("/auth")
 	{
 		auth.POST("/logout", handlerV1.Logout)
 		auth.POST("/register", handlerV1.Register)
 		auth.POST("/verify-email", handlerV1.VerifyEmail)
+		auth.POST("/resend-otp", handlerV1.ResendOtp)
 		auth.POST("/login", handlerV1.Login)
+		auth.POST("/login/2fa", handlerV1.LoginTwoFactor)
+		auth.POST("/login/2fa/enroll", handlerV1.LoginTwoFactorEnroll)
+		auth.POST("/refresh", handlerV1.RefreshToken)
+		auth.POST("/forgot-password", handlerV1.ForgotPassword)
+		auth.POST("/reset-password", handlerV1.ResetPassword)
+	}
+
+	roomType := v1.Group("/room-type")
+	{
+		roomType.POST("/", handlerV1.CreateRoomType)
+		roomType.GET("/list", handlerV1.GetRoomTypes)
+		roomType.GET("/search", handlerV1.SearchRoomTypes)
+		roomType.GET("/:id", handlerV1.GetRoomType)
+		roomType.PUT("/", handlerV1.UpdateRoomType)
+		roomType.DELETE("/:id", handlerV1.DeleteRoomType)
+		roomType.GET("/:id/quote", handlerV1.QuotePrice)
+		roomType.GET("/:id/rates", handlerV1.GetRates)
+		roomType.POST("/:id/seasons", handlerV1.CreateRateSeason)
+		roomType.PUT("/:id/seasons", handlerV1.UpdateRateSeason)
+		roomType.DELETE("/:id/seasons/:season_id", handlerV1.DeleteRateSeason)
+		roomType.PUT("/:id/day-modifiers", handlerV1.SetRateDayModifiers)
+		roomType.PUT("/:id/overrides", handlerV1.SetRateOverride)
+		roomType.DELETE("/:id/overrides/:date", handlerV1.DeleteRateOverride)
+	}
+
+	hotel := v1.Group("/hotel")
+	{
+		hotel.POST("/", handlerV1.CreateHotel)
+		hotel.GET("/list", handlerV1.GetHotels)
+		hotel.GET("/:id", handlerV1.GetHotel)
+		hotel.PUT("/", handlerV1.UpdateHotel)
+		hotel.DELETE("/:id", handlerV1.DeleteHotel)
+		hotel.GET("/:id/staff", handlerV1.GetHotelStaff)
+		hotel.POST("/:id/staff", handlerV1.AddHotelStaff)
+		hotel.DELETE("/:id/staff/:user_id", handlerV1.RemoveHotelStaff)
+	}
+
+	report := v1.Group("/report")
+	{
+		report.GET("/summary", handlerV1.GetReportSummary)
 	}
+
+	policy := v1.Group("/policy")
+	{
+		policy.GET("/list", handlerV1.GetPolicies)
+		policy.POST("/", handlerV1.CreatePolicy)
+		policy.DELETE("/", handlerV1.DeletePolicy)
+		policy.GET("/role/list", handlerV1.GetRoleInheritances)
+		policy.POST("/role", handlerV1.CreateRoleInheritance)
+		policy.DELETE("/role", handlerV1.DeleteRoleInheritance)
+		policy.PUT("/reload", handlerV1.ReloadPolicies)
+	}
+
+	// every route is registered by now
+	handlerV1.Routes = engine.Routes()
 }
\ No newline at end of file
@@ -0,0 +1,48 @@
+package entity
+
+import "errors"
+
+const (
+	BookingStatusPending   = "pending"
+	BookingStatusConfirmed = "confirmed"
+	BookingStatusCheckedIn = "checked_in"
+	BookingStatusCancelled = "cancelled"
+	BookingStatusCompleted = "completed"
+)
+
+var (
+	// ErrBookingNotConfirmed is returned when checking in a booking that isn't paid and confirmed.
+	ErrBookingNotConfirmed = errors.New("booking is not confirmed")
+	// ErrBookingNotCheckedIn is returned when checking out a booking whose guest hasn't checked in.
+	ErrBookingNotCheckedIn = errors.New("booking is not checked in")
+	// ErrNoRoomAvailable is returned when checking in and no clean room of the booked type is free.
+	ErrNoRoomAvailable = errors.New("no room of the booked type is ready")
+	// ErrRoomNotBookable is returned when checking a guest into a room of another type or one that isn't ready.
+	ErrRoomNotBookable = errors.New("room can't take this booking")
+)
+
+type Booking struct {
+	ID           string  `json:"id"`
+	UserID       string  `json:"user_id"`
+	RoomTypeID   string  `json:"room_type_id"`
+	RoomID       string  `json:"room_id"`        // Physical room, assigned at check-in
+	HotelID      string  `json:"hotel_id"`       // Hotel of the room type, set by the server
+	CheckInDate  string  `json:"check_in_date"`  // YYYY-MM-DD
+	CheckOutDate string  `json:"check_out_date"` // YYYY-MM-DD
+	Status       string  `json:"status"`         // pending, confirmed, checked_in, cancelled, completed
+	TotalPrice   float64 `json:"total_price"`    // Price of the whole stay, fixed when the booking is made
+	CheckedInAt  string  `json:"checked_in_at"`
+	CheckedOutAt string  `json:"checked_out_at"`
+	CreatedAt    string  `json:"created_at"`
+	UpdatedAt    string  `json:"updated_at"`
+}
+
+type BookingList struct {
+	Items []Booking `json:"bookings"`
+	Count int       `json:"count"`
+}
+
+type CheckInRequest struct {
+	BookingID string `json:"-"`
+	RoomID    string `json:"room_id"` // Room to put the guest in, any ready room of the booked type when empty
+}
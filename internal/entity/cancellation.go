@@ -0,0 +1,61 @@
+package entity
+
+import "errors"
+
+var (
+	// ErrBookingNotCancellable is returned when a booking is already cancelled, checked in or completed.
+	ErrBookingNotCancellable = errors.New("booking can't be cancelled")
+	// ErrRefundNotPending is returned when a refund was already paid back or has failed.
+	ErrRefundNotPending = errors.New("refund is not pending")
+)
+
+const (
+	RefundStatusPending   = "pending"
+	RefundStatusSucceeded = "succeeded"
+	RefundStatusFailed    = "failed"
+)
+
+type CancellationPolicy struct {
+	ID                    string `json:"id"`
+	HotelID               string `json:"hotel_id"`
+	RoomCategory          string `json:"room_category"`
+	FreeCancellationHours int    `json:"free_cancellation_hours"` // Full refund if cancelled at least this many hours before check-in
+	LateRefundPercent     int    `json:"late_refund_percent"`     // Share of the paid amount refunded after that
+	NonRefundable         bool   `json:"non_refundable"`          // Nothing is refunded regardless of timing
+	CreatedAt             string `json:"created_at"`
+	UpdatedAt             string `json:"updated_at"`
+}
+
+type CancellationPolicyList struct {
+	Items []CancellationPolicy `json:"cancellation_policies"`
+	Count int                  `json:"count"`
+}
+
+type Refund struct {
+	ID          string  `json:"id"`
+	PaymentID   string  `json:"payment_id"`
+	BookingID   string  `json:"booking_id"`
+	Amount      float64 `json:"amount"`
+	Status      string  `json:"status"` // pending, succeeded, failed
+	ProviderRef string  `json:"provider_ref"`
+	Reason      string  `json:"reason"`
+	CreatedAt   string  `json:"created_at"`
+	UpdatedAt   string  `json:"updated_at"`
+}
+
+type RefundList struct {
+	Items []Refund `json:"refunds"`
+	Count int      `json:"count"`
+}
+
+type CancelBookingRequest struct {
+	BookingID     string
+	RefundPercent float64 // Share of what was paid that is refunded
+}
+
+type CancelBookingResponse struct {
+	Booking        Booking   `json:"booking"`
+	RefundAmount   float64   `json:"refund_amount"`
+	Refunds        []Refund  `json:"refunds"`
+	VoidedPayments []Payment `json:"voided_payments"` // Payments still pending when the booking was cancelled
+}
@@ -0,0 +1,70 @@
+package entity
+
+import "errors"
+
+// ErrComplaintStatusChanged is returned when a complaint left the expected status before it could be moved.
+var ErrComplaintStatusChanged = errors.New("complaint status changed")
+
+const (
+	ComplaintStatusPending    = "pending"
+	ComplaintStatusInProgress = "in_progress"
+	ComplaintStatusResolved   = "resolved"
+	ComplaintStatusRejected   = "rejected"
+)
+
+// ComplaintTransitions lists the statuses a complaint may move to from each status.
+// Resolved and rejected complaints are closed.
+var ComplaintTransitions = map[string][]string{
+	ComplaintStatusPending:    {ComplaintStatusInProgress},
+	ComplaintStatusInProgress: {ComplaintStatusResolved, ComplaintStatusRejected},
+}
+
+// CanTransitionComplaint reports whether a complaint in status from may be moved to status to.
+func CanTransitionComplaint(from, to string) bool {
+	for _, status := range ComplaintTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+type Complaint struct {
+	ID         string             `json:"id"`
+	UserID     string             `json:"user_id"`
+	BookingID  string             `json:"booking_id"`
+	HotelID    string             `json:"hotel_id"` // Hotel of the booking
+	AssigneeID string             `json:"assignee_id"`
+	Message    string             `json:"message"`
+	Status     string             `json:"status"` // pending, in_progress, resolved, rejected
+	ResolvedAt string             `json:"resolved_at"`
+	Messages   []ComplaintMessage `json:"messages,omitempty"`
+	CreatedAt  string             `json:"created_at"`
+	UpdatedAt  string             `json:"updated_at"`
+}
+
+type ComplaintList struct {
+	Items []Complaint `json:"complaints"`
+	Count int         `json:"count"`
+}
+
+type ComplaintMessage struct {
+	ID          string `json:"id"`
+	ComplaintID string `json:"complaint_id"`
+	UserID      string `json:"user_id"`
+	Message     string `json:"message"`
+	CreatedAt   string `json:"created_at"`
+}
+
+type ComplaintMessageList struct {
+	Items []ComplaintMessage `json:"messages"`
+	Count int                `json:"count"`
+}
+
+type ComplaintAssignRequest struct {
+	AssigneeID string `json:"assignee_id"`
+}
+
+type ComplaintStatusRequest struct {
+	Status string `json:"status"`
+}
@@ -0,0 +1,34 @@
+package entity
+
+type Hotel struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Address   string   `json:"address"`
+	Latitude  float64  `json:"latitude"`
+	Longitude float64  `json:"longitude"`
+	Timezone  string   `json:"timezone"` // IANA name, e.g. Asia/Tashkent. Stay dates are in this timezone
+	Currency  string   `json:"currency"` // ISO 4217 code, e.g. USD. Prices of the hotel's rooms are in it
+	Amenities []string `json:"amenities"`
+	CreatedAt string   `json:"created_at"`
+	UpdatedAt string   `json:"updated_at"`
+}
+
+type HotelList struct {
+	Items []Hotel `json:"hotels"`
+	Count int     `json:"count"`
+}
+
+// HotelStaff assigns an admin or staff member to a hotel they manage.
+type HotelStaff struct {
+	HotelID   string `json:"hotel_id"`
+	UserID    string `json:"user_id"`
+	FullName  string `json:"fullname"`
+	Email     string `json:"email"`
+	UserRole  string `json:"user_role"`
+	CreatedAt string `json:"created_at"`
+}
+
+type HotelStaffList struct {
+	Items []HotelStaff `json:"staff"`
+	Count int          `json:"count"`
+}
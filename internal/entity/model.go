@@ -10,9 +10,10 @@ type OrderBy struct {
 }
 
 type Filter struct {
-	Column string `json:"column"`
-	Type   string `json:"type"` // eq, ne, gt, gte, lt, lte, search
-	Value  string `json:"value"`
+	Column string   `json:"column"`
+	Type   string   `json:"type"` // eq, ne, gt, gte, lt, lte, search, in
+	Value  string   `json:"value"`
+	Values []string `json:"values"` // for in
 }
 
 type GetListFilter struct {
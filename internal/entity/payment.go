@@ -0,0 +1,89 @@
+package entity
+
+import "errors"
+
+var (
+	// ErrPaymentExceedsBalance is returned when a payment is larger than what is still owed on a booking.
+	ErrPaymentExceedsBalance = errors.New("amount exceeds the outstanding balance")
+	// ErrIdempotencyKeyReused is returned when a user's idempotency key was already used for a different payment.
+	ErrIdempotencyKeyReused = errors.New("idempotency key was already used for a different payment")
+)
+
+const (
+	PaymentTypeDeposit = "deposit"
+	PaymentTypePayment = "payment"
+
+	PaymentStatusPending   = "pending"
+	PaymentStatusSucceeded = "succeeded"
+	PaymentStatusFailed    = "failed"
+	PaymentStatusRefunded  = "refunded"
+	PaymentStatusVoided    = "voided" // Still pending when its booking was cancelled
+)
+
+type Payment struct {
+	ID             string  `json:"id"`
+	BookingID      string  `json:"booking_id"`
+	UserID         string  `json:"user_id"` // Who made the payment, idempotency keys are scoped to them
+	Amount         float64 `json:"amount"`
+	Type           string  `json:"type"`   // deposit, payment
+	Status         string  `json:"status"` // pending, succeeded, failed, refunded, voided
+	IdempotencyKey string  `json:"idempotency_key"`
+	ProviderRef    string  `json:"provider_ref"`    // Charge reference at the payment provider
+	Token          string  `json:"token,omitempty"` // Payment method token, passed to the provider and never stored
+	PaymentDate    string  `json:"payment_date"`
+	CreatedAt      string  `json:"created_at"`
+	UpdatedAt      string  `json:"updated_at"`
+}
+
+type PaymentList struct {
+	Items []Payment `json:"payments"`
+	Count int       `json:"count"`
+}
+
+type BookingBalance struct {
+	BookingID   string  `json:"booking_id"`
+	TotalPrice  float64 `json:"total_price"`
+	Paid        float64 `json:"paid"`        // Sum of succeeded payments, including those refunded since
+	Pending     float64 `json:"pending"`     // Sum of payments still waiting for the provider
+	Outstanding float64 `json:"outstanding"` // What is left to pay after succeeded payments
+	Refunded    float64 `json:"refunded"`    // Sum of refunds issued or still being processed
+}
+
+// PaymentSignatureHeader carries the provider's signature of a webhook payload.
+const PaymentSignatureHeader = "X-Payment-Signature"
+
+// ErrPaymentProviderTimeout is returned when the payment provider doesn't answer in time.
+// The outcome of the charge is unknown, so the payment stays pending.
+var ErrPaymentProviderTimeout = errors.New("payment provider timed out")
+
+type ChargeRequest struct {
+	PaymentID      string  `json:"payment_id"`
+	BookingID      string  `json:"booking_id"`
+	UserID         string  `json:"user_id"` // Who made the payment, idempotency keys are scoped to them
+	Amount         float64 `json:"amount"`
+	Token          string  `json:"token"` // Payment method token issued by the provider
+	IdempotencyKey string  `json:"idempotency_key"`
+}
+
+type ChargeResult struct {
+	ProviderRef   string `json:"provider_ref"`
+	Status        string `json:"status"` // succeeded, pending (confirmed later by webhook), failed
+	FailureReason string `json:"failure_reason"`
+}
+
+type RefundRequest struct {
+	ProviderRef    string  `json:"provider_ref"`
+	Amount         float64 `json:"amount"`
+	IdempotencyKey string  `json:"idempotency_key"`
+}
+
+type RefundResult struct {
+	ProviderRef string `json:"provider_ref"`
+	Status      string `json:"status"` // succeeded, failed
+}
+
+type PaymentWebhookEvent struct {
+	ProviderRef   string `json:"provider_ref"`
+	Status        string `json:"status"`
+	FailureReason string `json:"failure_reason"`
+}
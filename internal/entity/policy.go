@@ -0,0 +1,27 @@
+package entity
+
+// Policy allows a role to call the routes matching Object with the methods matching Action.
+// Object is a keyMatch pattern such as /v1/booking/*, Action a regex such as GET|POST.
+type Policy struct {
+	Subject string   `json:"subject"`
+	Object  string   `json:"object"`
+	Action  string   `json:"action"`
+	Routes  []string `json:"routes,omitempty"`
+	Stale   bool     `json:"stale"`
+}
+
+type PolicyList struct {
+	Items []Policy `json:"policies"`
+	Count int      `json:"count"`
+}
+
+// RoleInheritance makes Role inherit every policy of Parent.
+type RoleInheritance struct {
+	Role   string `json:"role"`
+	Parent string `json:"parent"`
+}
+
+type RoleInheritanceList struct {
+	Items []RoleInheritance `json:"roles"`
+	Count int               `json:"count"`
+}
@@ -0,0 +1,71 @@
+package entity
+
+// RateSeason sets the nightly price of a room type for the nights from StartDate through EndDate.
+type RateSeason struct {
+	ID         string  `json:"id"`
+	RoomTypeID string  `json:"room_type_id"`
+	Name       string  `json:"name"`
+	StartDate  string  `json:"start_date"` // YYYY-MM-DD, first night of the season
+	EndDate    string  `json:"end_date"`   // YYYY-MM-DD, last night of the season
+	Price      float64 `json:"price"`
+	MinStay    int     `json:"min_stay"` // Nights for arrivals in the season, 0 keeps that of the room type
+	CreatedAt  string  `json:"created_at"`
+	UpdatedAt  string  `json:"updated_at"`
+}
+
+// RateDayModifier raises or lowers the price of a weekday, e.g. 20 on Fridays and Saturdays.
+type RateDayModifier struct {
+	Weekday int     `json:"weekday"` // 0 is Sunday
+	Percent float64 `json:"percent"`
+}
+
+type RateDayModifierList struct {
+	RoomTypeID string            `json:"-"`
+	Items      []RateDayModifier `json:"day_modifiers"`
+}
+
+// RateOverride fixes the price of a single night. Day-of-week modifiers don't apply to it.
+type RateOverride struct {
+	RoomTypeID string  `json:"room_type_id"`
+	Date       string  `json:"date"`     // YYYY-MM-DD
+	Price      float64 `json:"price"`    // 0 keeps the calculated price
+	MinStay    int     `json:"min_stay"` // Nights for arrivals on the date, 0 keeps the season's or room type's
+	CreatedAt  string  `json:"created_at"`
+	UpdatedAt  string  `json:"updated_at"`
+}
+
+// RateCalendarRequest selects the rates of a room type for the nights from From up to, not including, To.
+type RateCalendarRequest struct {
+	RoomTypeID string `json:"room_type_id"`
+	From       string `json:"from"` // YYYY-MM-DD
+	To         string `json:"to"`   // YYYY-MM-DD
+}
+
+// RateCalendar is everything that prices the nights of a room type in a date range.
+type RateCalendar struct {
+	RoomTypeID   string            `json:"room_type_id"`
+	BasePrice    float64           `json:"base_price"`
+	MinStay      int               `json:"min_stay"`
+	Seasons      []RateSeason      `json:"seasons"`
+	DayModifiers []RateDayModifier `json:"day_modifiers"`
+	Overrides    []RateOverride    `json:"overrides"`
+}
+
+type NightlyRate struct {
+	Date        string  `json:"date"`
+	Price       float64 `json:"price"`
+	Source      string  `json:"source"`                 // base, season or override
+	Season      string  `json:"season,omitempty"`       // Name of the season the price comes from
+	DayModifier float64 `json:"day_modifier,omitempty"` // Percent applied for the weekday
+}
+
+// PriceQuote is the price of a stay night by night. Bookings keep the total they were made at.
+type PriceQuote struct {
+	RoomTypeID   string        `json:"room_type_id"`
+	CheckInDate  string        `json:"check_in_date"`
+	CheckOutDate string        `json:"check_out_date"`
+	Currency     string        `json:"currency"`
+	MinStay      int           `json:"min_stay"`
+	Nights       []NightlyRate `json:"nights"`
+	Total        float64       `json:"total"`
+}
@@ -0,0 +1,25 @@
+package entity
+
+type ReportRequest struct {
+	HotelID string `json:"hotel_id"` // Empty for every hotel
+	From    string `json:"from"`     // YYYY-MM-DD
+	To      string `json:"to"`       // YYYY-MM-DD, exclusive
+}
+
+// ReportSummary describes how the hotel did over a period. Stays are counted by the nights
+// that fall inside the period, so a stay crossing its edges contributes only part of its price.
+type ReportSummary struct {
+	HotelID             string         `json:"hotel_id"`
+	From                string         `json:"from"`
+	To                  string         `json:"to"`
+	Rooms               int            `json:"rooms"`
+	RoomNightsAvailable int            `json:"room_nights_available"`
+	RoomNightsSold      int            `json:"room_nights_sold"`
+	OccupancyRate       float64        `json:"occupancy_rate"`     // Sold room nights over available ones, 0-1
+	RoomRevenue         float64        `json:"room_revenue"`       // Booked price of the nights sold
+	AverageDailyRate    float64        `json:"average_daily_rate"` // Room revenue per night sold
+	RevPAR              float64        `json:"revpar"`             // Room revenue per available room night
+	PaymentsReceived    float64        `json:"payments_received"`  // Succeeded payments made in the period
+	RefundsPaid         float64        `json:"refunds_paid"`       // Succeeded refunds made in the period
+	BookingsByStatus    map[string]int `json:"bookings_by_status"` // Stays in the period per booking status
+}
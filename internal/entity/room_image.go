@@ -0,0 +1,28 @@
+package entity
+
+import "errors"
+
+// ErrInvalidImageOrder is returned when a new gallery order doesn't list every image of the room exactly once.
+var ErrInvalidImageOrder = errors.New("image_ids must list every image of the room exactly once")
+
+type RoomImage struct {
+	ID             string   `json:"id"`
+	RoomID         string   `json:"room_id"`
+	ImageURL       string   `json:"image_url"`     // Full size variant
+	ThumbnailURL   string   `json:"thumbnail_url"` // For lists
+	MediumURL      string   `json:"medium_url"`    // For room detail on mobile
+	ObjectName     string   `json:"-"`             // Key of the full size object in the bucket
+	VariantObjects []string `json:"-"`             // Keys of every stored variant
+	Position       int      `json:"position"`
+	IsCover        bool     `json:"is_cover"`
+	CreatedAt      string   `json:"created_at"`
+}
+
+type RoomImageList struct {
+	Items []RoomImage `json:"images"`
+	Count int         `json:"count"`
+}
+
+type RoomImageOrderRequest struct {
+	ImageIDs []string `json:"image_ids"` // Every image of the room, in the new order
+}
@@ -4,6 +4,7 @@ type RoomReview struct {
 	ID        string  `json:"id"`         // UUID
 	UserID    string  `json:"user_id"`    // Foydalanuvchi identifikatori (UUID)
 	RoomID    string  `json:"room_id"`    // Xona identifikatori (UUID)
+	BookingID string  `json:"booking_id"` // Yakunlangan bron identifikatori (UUID)
 	Rating    float64 `json:"rating"`     // Reyting qiymati
 	Comment   string  `json:"comment"`    // Fikr-mulohaza
 	CreatedAt string  `json:"created_at"` // Yaratilgan vaqt (timestamp)
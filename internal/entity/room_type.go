@@ -0,0 +1,51 @@
+package entity
+
+import "errors"
+
+var (
+	// ErrRoomTypeUnavailable is returned when every room of a type is taken on some night of a stay.
+	ErrRoomTypeUnavailable = errors.New("no room of this type is free for the whole stay")
+	// ErrRoomTypeOversold is returned when taking a room out of its type would leave fewer rooms than are booked on some night.
+	ErrRoomTypeOversold = errors.New("room type would have fewer rooms than are booked")
+	// ErrRoomOccupied is returned when moving a room that holds a stay to another type.
+	ErrRoomOccupied = errors.New("room holds a stay")
+)
+
+// RoomType is what guests search for and book. Physical rooms of the type are assigned at check-in.
+type RoomType struct {
+	ID               string      `json:"id"`
+	HotelID          string      `json:"hotel_id"`
+	Name             string      `json:"name"`
+	Type             string      `json:"type"`              // room_type: economy, standard, comfort, deluxe or suite
+	Category         string      `json:"category"`          // room_category: single, double, 3xroom, 4xroom or 5xroom
+	Capacity         int         `json:"capacity"`          // Guests it sleeps, defaults to that of the category
+	BedConfiguration string      `json:"bed_configuration"` // e.g. "1 king" or "2 twin"
+	Size             float64     `json:"size"`              // Floor area in square meters
+	Amenities        []string    `json:"amenities"`
+	BasePrice        float64     `json:"base_price"` // Nightly price in the hotel's currency unless the rate calendar sets another
+	MinStay          int         `json:"min_stay"`   // Nights a stay must last unless the rate calendar sets another
+	Description      string      `json:"description"`
+	Images           []RoomImage `json:"images"`                // Galleries of its rooms, every room's cover first
+	Available        int         `json:"available,omitempty"`   // Rooms free for the whole stay, only in search results
+	TotalPrice       float64     `json:"total_price,omitempty"` // Price of the whole stay from the rate calendar, only in search results
+	CreatedAt        string      `json:"created_at"`
+	UpdatedAt        string      `json:"updated_at"`
+}
+
+type RoomTypeList struct {
+	Items []RoomType `json:"room_types"`
+	Count int        `json:"count"`
+}
+
+type RoomSearchRequest struct {
+	HotelID      string  `json:"hotel_id"`
+	CheckInDate  string  `json:"check_in_date"`  // YYYY-MM-DD
+	CheckOutDate string  `json:"check_out_date"` // YYYY-MM-DD
+	Guests       int     `json:"guests"`
+	Category     string  `json:"category"`
+	Type         string  `json:"type"`
+	MinPrice     float64 `json:"min_price"`
+	MaxPrice     float64 `json:"max_price"`
+	Page         int     `json:"page"`
+	Limit        int     `json:"limit"`
+}
@@ -1,18 +1,44 @@
 package entity
 
+const (
+	RoomStatusAvailable   = "available"
+	RoomStatusBooked      = "booked" // a guest is checked in
+	RoomStatusCleaning    = "cleaning"
+	RoomStatusMaintenance = "maintenance"
+)
+
+// Room is a physical room. What it sells, its price and capacity, comes from its room type.
 type Room struct {
 	ID           string  `json:"id"`
-	Type         string  `json:"type"`         // room_type (Enum: e.g., "single", "double", etc.)
-	Category     string  `json:"category"`     // room_category (Enum: e.g., "standard", "deluxe", etc.)
+	HotelID      string  `json:"hotel_id"` // Hotel of the room type, set by the server
+	RoomTypeID   string  `json:"room_type_id"`
+	RoomNumber   string  `json:"room_number"` // Unique within the hotel, e.g. "204"
+	Floor        int     `json:"floor"`
 	Status       string  `json:"status"`       // room_status (Enum: e.g., "available", "occupied", etc.)
-	Price        float64 `json:"price"`        // Decimal value
 	Availability bool    `json:"availability"` // True (available) or False (unavailable)
-	Rating       float64 `json:"rating"`       // Average rating
+	Rating       float64 `json:"rating"`       // Average rating, recalculated from room_reviews
+	ReviewCount  int     `json:"review_count"` // Number of reviews
 	CreatedAt    string  `json:"created_at"`   // Timestamp
 	UpdatedAt    string  `json:"updated_at"`   // Timestamp
+
+	RatingHistogram map[int]int `json:"rating_histogram,omitempty"` // Review count per star (1-5), only on room detail
+	Images          []RoomImage `json:"images"`                     // Gallery ordered by position
+}
+
+type RoomStatusRequest struct {
+	Status string `json:"status"` // available, booked, cleaning or maintenance
 }
 
 type RoomList struct {
 	Items []Room `json:"rooms"`
 	Count int    `json:"count"`
 }
+
+// RoomCategoryCapacity is the number of guests each room_category sleeps, the default capacity of a room type.
+var RoomCategoryCapacity = map[string]int{
+	"single": 1,
+	"double": 2,
+	"3xroom": 3,
+	"4xroom": 4,
+	"5xroom": 5,
+}
@@ -1,5 +1,7 @@
 package entity
 
+import "errors"
+
 type Session struct {
 	ID           string `json:"id"`
 	UserID       string `json:"user_id"`
@@ -9,11 +11,32 @@ type Session struct {
 	ExpiresAt    string `json:"expires_at"`
 	Platform     string `json:"platform"`
 	LastActiveAt string `json:"last_active_at"`
+	IsCurrent    bool   `json:"is_current"`
 	CreatedAt    string `json:"created_at"`
 	UpdatedAt    string `json:"updated_at"`
+
+	// TwoFactorVerified records that the sign-in passed a second factor. Admin tokens are only refreshed for such sessions.
+	TwoFactorVerified bool `json:"two_factor_verified"`
 }
 
 type SessionList struct {
 	Items []Session `json:"sessions"`
 	Count int       `json:"count"`//sdf
 }
+
+var (
+	// ErrInvalidRefreshToken is returned for unknown or expired refresh tokens and for revoked sessions.
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrRefreshTokenReused is returned when a refresh token that was already rotated is presented again.
+	// The session it belongs to is revoked, since either the client or an attacker holds a stolen copy.
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+)
+
+type RefreshToken struct {
+	ID        string `json:"id"`
+	SessionID string `json:"session_id"`
+	TokenHash string `json:"-"`
+	ExpiresAt string `json:"expires_at"`
+	UsedAt    string `json:"used_at"`
+	CreatedAt string `json:"created_at"`
+}
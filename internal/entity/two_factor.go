@@ -0,0 +1,44 @@
+package entity
+
+import "errors"
+
+// ErrTwoFactorEnabled is returned when enrolling a user who already has two-factor authentication on.
+var ErrTwoFactorEnabled = errors.New("two-factor authentication is already enabled")
+
+type TwoFactor struct {
+	UserID   string `json:"user_id"`
+	Secret   string `json:"-"`
+	Enabled  bool   `json:"enabled"`
+	LastStep int64  `json:"-"`
+}
+
+type TwoFactorEnrollment struct {
+	Secret          string   `json:"secret"`
+	ProvisioningURI string   `json:"provisioning_uri"`
+	RecoveryCodes   []string `json:"recovery_codes"`
+}
+
+// TwoFactorChallenge is returned by Login instead of tokens when a second factor is needed.
+// Users that still have to enroll are emailed a code to enroll with.
+type TwoFactorChallenge struct {
+	TwoFactorRequired  bool   `json:"two_factor_required"`
+	Challenge          string `json:"challenge"`
+	ExpiresAt          string `json:"expires_at"`
+	EnrollmentRequired bool   `json:"enrollment_required"`
+}
+
+type TwoFactorEnrollRequest struct {
+	Challenge string `json:"challenge"`
+	EmailCode string `json:"email_code"`
+}
+
+type TwoFactorLoginRequest struct {
+	Challenge    string `json:"challenge"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
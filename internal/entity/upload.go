@@ -0,0 +1,17 @@
+package entity
+
+type UploadURLRequest struct {
+	FileName string `json:"file_name"` // Only the extension is used, it decides the content type
+	Size     int64  `json:"size"`      // Bytes in the file, at most 20 MB
+}
+
+type UploadURLResponse struct {
+	ObjectName string            `json:"object_name"` // Pass this back once the upload has finished
+	UploadURL  string            `json:"upload_url"`  // PUT the file body here
+	Headers    map[string]string `json:"headers"`     // Send these with the PUT, they are part of the signature
+	ExpiresAt  string            `json:"expires_at"`
+}
+
+type RoomImageImportRequest struct {
+	ObjectNames []string `json:"object_names"` // Objects uploaded through presigned URLs
+}
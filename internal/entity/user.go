@@ -1,5 +1,14 @@
 package entity
 
+const (
+	UserRoleUser         = "user"
+	UserRoleAdmin        = "admin"
+	UserRoleSuperadmin   = "superadmin"
+	UserRoleReceptionist = "receptionist" // checks guests in and out, takes payments at the desk
+	UserRoleHousekeeping = "housekeeping" // updates the status of rooms
+	UserRoleManager      = "manager"      // sees bookings, payments and reports
+)
+
 type User struct {
 	ID            string `json:"id"`
 	FullName      string `json:"fullname"`
@@ -12,6 +21,7 @@ type User struct {
 	UserStatus    string `json:"user_status"`
 	Gender        string `json:"gender"`
 	AccessToken   string `json:"access_token"`
+	RefreshToken  string `json:"refresh_token,omitempty"`
 	CreatedAt     string `json:"created_at"`
 	UpdatedAt     string `json:"updated_at"`
 }
@@ -26,3 +36,19 @@ type UserList struct {
 	Items []User `json:"users"`
 	Count int    `json:"count"`
 }
+
+type ChangePasswordRequest struct {
+	OldPassword         string `json:"old_password"`
+	NewPassword         string `json:"new_password"`
+	LogoutOtherSessions bool   `json:"logout_other_sessions"`
+}
+
+type ChangeEmailRequest struct {
+	NewEmail string `json:"new_email"`
+}
+
+type ConfirmEmailChangeRequest struct {
+	NewEmail            string `json:"new_email"`
+	Otp                 string `json:"otp"`
+	LogoutOtherSessions bool   `json:"logout_other_sessions"`
+}
@@ -3,8 +3,10 @@ package usecase
 
 import (
 	"context"
+	"time"
 
 	"github.com/Avazbek-02/Online-Hotel-System/internal/entity"
+	"github.com/casbin/casbin/persist"
 )
 
 
@@ -13,6 +15,8 @@ type (
 	UserRepoI interface {
 		Create(ctx context.Context, req entity.User) (entity.User, error)
 		GetSingle(ctx context.Context, req entity.UserSingleRequest) (entity.User, error)
+		GetPasswordHash(ctx context.Context, req entity.Id) (string, error)
+		UpdatePassword(ctx context.Context, req entity.User) error
 		GetList(ctx context.Context, req entity.GetListFilter) (entity.UserList, error)
 		Update(ctx context.Context, req entity.User) (entity.User, error)
 		Delete(ctx context.Context, req entity.Id) error
@@ -26,6 +30,11 @@ type (
 		Update(ctx context.Context, req entity.Session) (entity.Session, error)
 		Delete(ctx context.Context, req entity.Id) error
 		UpdateField(ctx context.Context, req entity.UpdateFieldRequest) (entity.RowsEffected, error)
+		Touch(ctx context.Context, req entity.Session, interval time.Duration) error
+		Revoke(ctx context.Context, req entity.Session) error
+		RevokeByUser(ctx context.Context, userID, keepSessionID string) error
+		CreateRefreshToken(ctx context.Context, req entity.RefreshToken) (entity.RefreshToken, error)
+		RotateRefreshToken(ctx context.Context, tokenHash string, next entity.RefreshToken) (entity.Session, error)
 	}
 
 	RoomsRepoI interface {
@@ -36,11 +45,141 @@ type (
 		Delete(ctx context.Context, req entity.Id) error
 	}
 
+	// RoomTypeRepo -.
+	RoomTypeRepoI interface {
+		Create(ctx context.Context, req entity.RoomType) (entity.RoomType, error)
+		GetSingle(ctx context.Context, req entity.Id) (entity.RoomType, error)
+		GetList(ctx context.Context, req entity.GetListFilter) (entity.RoomTypeList, error)
+		Update(ctx context.Context, req entity.RoomType) (entity.RoomType, error)
+		Delete(ctx context.Context, req entity.Id) error
+		Search(ctx context.Context, req entity.RoomSearchRequest) (entity.RoomTypeList, error)
+	}
+
+	// RateRepo -.
+	RateRepoI interface {
+		GetCalendar(ctx context.Context, req entity.RateCalendarRequest) (entity.RateCalendar, error)
+		CreateSeason(ctx context.Context, req entity.RateSeason) (entity.RateSeason, error)
+		UpdateSeason(ctx context.Context, req entity.RateSeason) (entity.RateSeason, error)
+		DeleteSeason(ctx context.Context, req entity.RateSeason) error
+		SetDayModifiers(ctx context.Context, req entity.RateDayModifierList) error
+		SetOverride(ctx context.Context, req entity.RateOverride) (entity.RateOverride, error)
+		DeleteOverride(ctx context.Context, req entity.RateOverride) error
+	}
+
 	RoomReviewRepoI interface {
 		Create(ctx context.Context, req entity.RoomReview) (entity.RoomReview, error)
 		GetSingle(ctx context.Context, req entity.Id) (entity.RoomReview, error)
 		GetList(ctx context.Context, req entity.GetListFilter) (entity.RoomReviewList, error)
 		Update(ctx context.Context, req entity.RoomReview) (entity.RoomReview, error)
 		Delete(ctx context.Context, req entity.Id) error
+		GetRatingHistogram(ctx context.Context, req entity.Id) (map[int]int, error)
+	}
+
+	// RoomImageRepo -.
+	RoomImageRepoI interface {
+		Create(ctx context.Context, req entity.RoomImage) (entity.RoomImage, error)
+		GetSingle(ctx context.Context, req entity.Id) (entity.RoomImage, error)
+		GetByRooms(ctx context.Context, roomIDs []string) (map[string][]entity.RoomImage, error)
+		GetByRoomTypes(ctx context.Context, roomTypeIDs []string) (map[string][]entity.RoomImage, error)
+		Reorder(ctx context.Context, roomID string, imageIDs []string) error
+		SetCover(ctx context.Context, req entity.Id) (entity.RoomImage, error)
+		Delete(ctx context.Context, req entity.Id) (entity.RoomImage, error)
+	}
+
+	// BookingRepo -.
+	BookingRepoI interface {
+		Create(ctx context.Context, req entity.Booking) (entity.Booking, error)
+		GetSingle(ctx context.Context, req entity.Id) (entity.Booking, error)
+		GetList(ctx context.Context, req entity.GetListFilter) (entity.BookingList, error)
+		Update(ctx context.Context, req entity.Booking) (entity.Booking, error)
+		Cancel(ctx context.Context, req entity.CancelBookingRequest) (entity.CancelBookingResponse, error)
+		CheckIn(ctx context.Context, req entity.CheckInRequest) (entity.Booking, error)
+		CheckOut(ctx context.Context, req entity.Id) (entity.Booking, error)
+		Delete(ctx context.Context, req entity.Id) error
+	}
+
+	// PaymentRepo -.
+	PaymentRepoI interface {
+		Create(ctx context.Context, req entity.Payment) (entity.Payment, error)
+		GetSingle(ctx context.Context, req entity.Id) (entity.Payment, error)
+		GetByIdempotencyKey(ctx context.Context, req entity.Payment) (entity.Payment, error)
+		GetByProviderRef(ctx context.Context, ref string) (entity.Payment, error)
+		UpdateStatus(ctx context.Context, req entity.Payment) (entity.Payment, error)
+		GetList(ctx context.Context, req entity.GetListFilter) (entity.PaymentList, error)
+		GetBalance(ctx context.Context, req entity.Id) (entity.BookingBalance, error)
+	}
+
+	// CancellationPolicyRepo -.
+	CancellationPolicyRepoI interface {
+		Create(ctx context.Context, req entity.CancellationPolicy) (entity.CancellationPolicy, error)
+		GetSingle(ctx context.Context, req entity.Id) (entity.CancellationPolicy, error)
+		GetByCategory(ctx context.Context, req entity.CancellationPolicy) (entity.CancellationPolicy, error)
+		GetList(ctx context.Context, req entity.GetListFilter) (entity.CancellationPolicyList, error)
+		Update(ctx context.Context, req entity.CancellationPolicy) (entity.CancellationPolicy, error)
+		Delete(ctx context.Context, req entity.Id) error
+	}
+
+	// RefundRepo -.
+	RefundRepoI interface {
+		Create(ctx context.Context, req entity.Refund) (entity.Refund, error)
+		GetSingle(ctx context.Context, req entity.Id) (entity.Refund, error)
+		GetList(ctx context.Context, req entity.GetListFilter) (entity.RefundList, error)
+		UpdateStatus(ctx context.Context, req entity.Refund) (entity.Refund, error)
+	}
+
+	// ComplaintRepo -.
+	ComplaintRepoI interface {
+		Create(ctx context.Context, req entity.Complaint) (entity.Complaint, error)
+		GetSingle(ctx context.Context, req entity.Id) (entity.Complaint, error)
+		GetList(ctx context.Context, req entity.GetListFilter) (entity.ComplaintList, error)
+		Assign(ctx context.Context, req entity.Complaint) (entity.Complaint, error)
+		UpdateStatus(ctx context.Context, id, from, to string) (entity.Complaint, error)
+		Delete(ctx context.Context, req entity.Id) error
+		AddMessage(ctx context.Context, req entity.ComplaintMessage) (entity.ComplaintMessage, error)
+		GetMessages(ctx context.Context, req entity.Id) (entity.ComplaintMessageList, error)
+	}
+
+	// TwoFactorRepo -.
+	TwoFactorRepoI interface {
+		Get(ctx context.Context, req entity.Id) (entity.TwoFactor, error)
+		Enroll(ctx context.Context, req entity.TwoFactor, recoveryCodeHashes []string) error
+		Enable(ctx context.Context, req entity.Id) error
+		Disable(ctx context.Context, req entity.Id) error
+		UseStep(ctx context.Context, userID string, step int64) (bool, error)
+		UseRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error)
+	}
+
+	// ReportRepo -.
+	ReportRepoI interface {
+		Summary(ctx context.Context, req entity.ReportRequest) (entity.ReportSummary, error)
+	}
+
+	// HotelRepo -.
+	HotelRepoI interface {
+		Create(ctx context.Context, req entity.Hotel) (entity.Hotel, error)
+		GetSingle(ctx context.Context, req entity.Id) (entity.Hotel, error)
+		GetList(ctx context.Context, req entity.GetListFilter) (entity.HotelList, error)
+		Update(ctx context.Context, req entity.Hotel) (entity.Hotel, error)
+		Delete(ctx context.Context, req entity.Id) error
+		AddStaff(ctx context.Context, req entity.HotelStaff) error
+		RemoveStaff(ctx context.Context, req entity.HotelStaff) error
+		GetStaff(ctx context.Context, req entity.Id) (entity.HotelStaffList, error)
+		GetHotelIDsByStaff(ctx context.Context, req entity.Id) ([]string, error)
+	}
+
+	// PolicyRepo -.
+	PolicyRepoI interface {
+		persist.Adapter
+		CreatePolicy(ctx context.Context, req entity.Policy) error
+		DeletePolicy(ctx context.Context, req entity.Policy) error
+		CreateRoleInheritance(ctx context.Context, req entity.RoleInheritance) error
+		DeleteRoleInheritance(ctx context.Context, req entity.RoleInheritance) error
+	}
+
+	// PaymentProvider charges and refunds through a payment gateway.
+	PaymentProvider interface {
+		Charge(ctx context.Context, req entity.ChargeRequest) (entity.ChargeResult, error)
+		Refund(ctx context.Context, req entity.RefundRequest) (entity.RefundResult, error)
+		VerifyWebhook(ctx context.Context, payload []byte, signature string) (entity.PaymentWebhookEvent, error)
 	}
 )
@@ -1,7 +1,10 @@
 package usecase
 
 import (
+	"fmt"
+
 	"github.com/Avazbek-02/Online-Hotel-System/config"
+	"github.com/Avazbek-02/Online-Hotel-System/internal/usecase/provider"
 	"github.com/Avazbek-02/Online-Hotel-System/internal/usecase/repo"
 	"github.com/Avazbek-02/Online-Hotel-System/pkg/logger"
 	"github.com/Avazbek-02/Online-Hotel-System/pkg/postgres"
@@ -9,18 +12,62 @@ import (
 
 // UseCase -.
 type UseCase struct {
-	UserRepo       UserRepoI
-	SessionRepo    SessionRepoI
-	RoomsRepo      RoomsRepoI
-	RoomReviewRepo RoomReviewRepoI
+	UserRepo               UserRepoI
+	SessionRepo            SessionRepoI
+	RoomsRepo              RoomsRepoI
+	RoomTypeRepo           RoomTypeRepoI
+	RateRepo               RateRepoI
+	RoomReviewRepo         RoomReviewRepoI
+	BookingRepo            BookingRepoI
+	PaymentRepo            PaymentRepoI
+	PaymentProvider        PaymentProvider
+	CancellationPolicyRepo CancellationPolicyRepoI
+	RefundRepo             RefundRepoI
+	ComplaintRepo          ComplaintRepoI
+	RoomImageRepo          RoomImageRepoI
+	TwoFactorRepo          TwoFactorRepoI
+	PolicyRepo             PolicyRepoI
+	ReportRepo             ReportRepoI
+	HotelRepo              HotelRepoI
 }
 
 // New -.
 func New(pg *postgres.Postgres, config *config.Config, logger *logger.Logger) *UseCase {
 	return &UseCase{
-		UserRepo:       repo.NewUserRepo(pg, config, logger),
-		SessionRepo:    repo.NewSessionRepo(pg, config, logger),
-		RoomsRepo:      repo.NewRoomsRepo(pg, config, logger),
-		RoomReviewRepo: repo.NewRoomReviewRepo(pg, config, logger),
+		UserRepo:               repo.NewUserRepo(pg, config, logger),
+		SessionRepo:            repo.NewSessionRepo(pg, config, logger),
+		RoomsRepo:              repo.NewRoomsRepo(pg, config, logger),
+		RoomTypeRepo:           repo.NewRoomTypeRepo(pg, config, logger),
+		RateRepo:               repo.NewRateRepo(pg, config, logger),
+		RoomReviewRepo:         repo.NewRoomReviewRepo(pg, config, logger),
+		BookingRepo:            repo.NewBookingRepo(pg, config, logger),
+		PaymentRepo:            repo.NewPaymentRepo(pg, config, logger),
+		PaymentProvider:        newPaymentProvider(config, logger),
+		CancellationPolicyRepo: repo.NewCancellationPolicyRepo(pg, config, logger),
+		RefundRepo:             repo.NewRefundRepo(pg, config, logger),
+		ComplaintRepo:          repo.NewComplaintRepo(pg, config, logger),
+		RoomImageRepo:          repo.NewRoomImageRepo(pg, config, logger),
+		TwoFactorRepo:          repo.NewTwoFactorRepo(pg, config, logger),
+		PolicyRepo:             repo.NewPolicyRepo(pg, config, logger),
+		ReportRepo:             repo.NewReportRepo(pg, config, logger),
+		HotelRepo:              repo.NewHotelRepo(pg, config, logger),
+	}
+}
+
+func newPaymentProvider(config *config.Config, logger *logger.Logger) PaymentProvider {
+	switch config.Payment.Provider {
+	case "fake":
+		opts := []provider.Option{provider.WebhookURL(config.Payment.WebhookURL)}
+		if config.Payment.WebhookDelay > 0 {
+			opts = append(opts, provider.WebhookDelay(config.Payment.WebhookDelay))
+		}
+		if config.Payment.Timeout > 0 {
+			opts = append(opts, provider.Timeout(config.Payment.Timeout))
+		}
+
+		return provider.NewFake(config.Payment.WebhookSecret, logger, opts...)
+	default:
+		logger.Fatal(fmt.Errorf("usecase - New - unknown payment provider: %q", config.Payment.Provider))
+		return nil
 	}
 }
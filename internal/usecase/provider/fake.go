@@ -0,0 +1,204 @@
+// Package provider implements payment providers behind usecase.PaymentProvider.
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Avazbek-02/Online-Hotel-System/internal/entity"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// Test tokens understood by the fake gateway. Any other token is charged successfully.
+const (
+	TokenSuccess = "tok_success"
+	TokenDecline = "tok_decline"
+	TokenTimeout = "tok_timeout"
+	TokenDelayed = "tok_delayed"
+)
+
+const (
+	_defaultWebhookDelay = 5 * time.Second
+	_defaultTimeout      = 30 * time.Second
+)
+
+// Fake is an in-process payment gateway for tests and local development.
+type Fake struct {
+	secret       string
+	logger       logger.Interface
+	webhookURL   string
+	webhookDelay time.Duration
+	timeout      time.Duration
+	client       *http.Client
+	onWebhook    func(payload []byte, signature string)
+
+	mu      sync.Mutex
+	charges map[string]entity.ChargeResult // by idempotency key
+	refunds map[string]entity.RefundResult // by idempotency key
+	amounts map[string]float64             // refundable amount by provider ref
+}
+
+// NewFake -.
+func NewFake(secret string, logger logger.Interface, opts ...Option) *Fake {
+	f := &Fake{
+		secret:       secret,
+		logger:       logger,
+		webhookDelay: _defaultWebhookDelay,
+		timeout:      _defaultTimeout,
+		client:       &http.Client{Timeout: 10 * time.Second},
+		charges:      map[string]entity.ChargeResult{},
+		refunds:      map[string]entity.RefundResult{},
+		amounts:      map[string]float64{},
+	}
+
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	return f
+}
+
+// Charge simulates charging req.Token. Repeating a charge with the same idempotency key
+// returns the first result without charging again.
+func (f *Fake) Charge(ctx context.Context, req entity.ChargeRequest) (entity.ChargeResult, error) {
+	if req.Token == TokenTimeout {
+		select {
+		case <-ctx.Done():
+		case <-time.After(f.timeout):
+		}
+		return entity.ChargeResult{}, entity.ErrPaymentProviderTimeout
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if result, ok := f.charges[req.IdempotencyKey]; ok && req.IdempotencyKey != "" {
+		return result, nil
+	}
+
+	result := entity.ChargeResult{
+		ProviderRef: "fake_ch_" + uuid.NewString(),
+		Status:      entity.PaymentStatusSucceeded,
+	}
+
+	switch req.Token {
+	case TokenDecline:
+		result.Status = entity.PaymentStatusFailed
+		result.FailureReason = "card_declined"
+	case TokenDelayed:
+		result.Status = entity.PaymentStatusPending
+		f.amounts[result.ProviderRef] = req.Amount
+		go f.confirmLater(entity.PaymentWebhookEvent{
+			ProviderRef: result.ProviderRef,
+			Status:      entity.PaymentStatusSucceeded,
+		})
+	default:
+		f.amounts[result.ProviderRef] = req.Amount
+	}
+
+	if req.IdempotencyKey != "" {
+		f.charges[req.IdempotencyKey] = result
+	}
+
+	return result, nil
+}
+
+// Refund simulates refunding part or all of a previous charge.
+func (f *Fake) Refund(ctx context.Context, req entity.RefundRequest) (entity.RefundResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if result, ok := f.refunds[req.IdempotencyKey]; ok && req.IdempotencyKey != "" {
+		return result, nil
+	}
+
+	refundable, ok := f.amounts[req.ProviderRef]
+	if !ok {
+		return entity.RefundResult{}, fmt.Errorf("fake provider - Refund - unknown charge %s", req.ProviderRef)
+	}
+
+	result := entity.RefundResult{
+		ProviderRef: "fake_re_" + uuid.NewString(),
+		Status:      entity.PaymentStatusSucceeded,
+	}
+
+	if req.Amount > refundable {
+		result.Status = entity.PaymentStatusFailed
+	} else {
+		f.amounts[req.ProviderRef] = refundable - req.Amount
+	}
+
+	if req.IdempotencyKey != "" {
+		f.refunds[req.IdempotencyKey] = result
+	}
+
+	return result, nil
+}
+
+// VerifyWebhook checks the HMAC-SHA256 signature of a webhook payload and decodes it.
+func (f *Fake) VerifyWebhook(ctx context.Context, payload []byte, signature string) (entity.PaymentWebhookEvent, error) {
+	var event entity.PaymentWebhookEvent
+
+	if !hmac.Equal([]byte(f.sign(payload)), []byte(signature)) {
+		return event, errors.New("fake provider - VerifyWebhook - invalid signature")
+	}
+
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return event, fmt.Errorf("fake provider - VerifyWebhook - json.Unmarshal: %w", err)
+	}
+
+	return event, nil
+}
+
+func (f *Fake) sign(payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(f.secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (f *Fake) confirmLater(event entity.PaymentWebhookEvent) {
+	time.Sleep(f.webhookDelay)
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		f.logger.Error("fake provider - confirmLater - json.Marshal: %s", err)
+		return
+	}
+
+	signature := f.sign(payload)
+
+	if f.onWebhook != nil {
+		f.onWebhook(payload, signature)
+		return
+	}
+
+	if f.webhookURL == "" {
+		f.logger.Error("fake provider - confirmLater - no webhook url, dropping event for %s", event.ProviderRef)
+		return
+	}
+
+	req, err := http.NewRequest(http.MethodPost, f.webhookURL, bytes.NewReader(payload))
+	if err != nil {
+		f.logger.Error("fake provider - confirmLater - http.NewRequest: %s", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(entity.PaymentSignatureHeader, signature)
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		f.logger.Error("fake provider - confirmLater - webhook delivery: %s", err)
+		return
+	}
+	resp.Body.Close()
+}
@@ -0,0 +1,128 @@
+package provider
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Avazbek-02/Online-Hotel-System/internal/entity"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/logger"
+)
+
+const testSecret = "whsec_test"
+
+func TestFakeDelayedChargeWebhookRoundTrip(t *testing.T) {
+	type delivery struct {
+		payload   []byte
+		signature string
+	}
+	received := make(chan delivery, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("reading webhook: %v", err)
+		}
+		received <- delivery{payload: payload, signature: r.Header.Get(entity.PaymentSignatureHeader)}
+	}))
+	defer server.Close()
+
+	fake := NewFake(testSecret, logger.New("error"), WebhookURL(server.URL), WebhookDelay(time.Millisecond))
+
+	result, err := fake.Charge(context.Background(), entity.ChargeRequest{
+		PaymentID:      "payment-1",
+		Amount:         100,
+		Token:          TokenDelayed,
+		IdempotencyKey: "payment-1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != entity.PaymentStatusPending {
+		t.Fatalf("delayed charge status = %s, want pending", result.Status)
+	}
+
+	var got delivery
+	select {
+	case got = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+
+	event, err := fake.VerifyWebhook(context.Background(), got.payload, got.signature)
+	if err != nil {
+		t.Fatalf("VerifyWebhook: %v", err)
+	}
+	if event.ProviderRef != result.ProviderRef || event.Status != entity.PaymentStatusSucceeded {
+		t.Errorf("webhook event = %+v, want succeeded for %s", event, result.ProviderRef)
+	}
+
+	if _, err = fake.VerifyWebhook(context.Background(), got.payload, "bad signature"); err == nil {
+		t.Error("VerifyWebhook accepted a bad signature")
+	}
+
+	other := NewFake("another secret", logger.New("error"))
+	if _, err = other.VerifyWebhook(context.Background(), got.payload, got.signature); err == nil {
+		t.Error("VerifyWebhook accepted a payload signed with another secret")
+	}
+}
+
+func TestFakeChargeIsIdempotent(t *testing.T) {
+	fake := NewFake(testSecret, logger.New("error"))
+	req := entity.ChargeRequest{Amount: 50, Token: TokenSuccess, IdempotencyKey: "payment-2"}
+
+	first, err := fake.Charge(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.Token = TokenDecline
+	second, err := fake.Charge(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first != second {
+		t.Errorf("repeated charge = %+v, want the first result %+v", second, first)
+	}
+}
+
+func TestFakeChargeDeclineAndRefund(t *testing.T) {
+	fake := NewFake(testSecret, logger.New("error"))
+
+	declined, err := fake.Charge(context.Background(), entity.ChargeRequest{Amount: 50, Token: TokenDecline, IdempotencyKey: "payment-3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if declined.Status != entity.PaymentStatusFailed {
+		t.Errorf("declined charge status = %s, want failed", declined.Status)
+	}
+
+	charge, err := fake.Charge(context.Background(), entity.ChargeRequest{Amount: 50, Token: TokenSuccess, IdempotencyKey: "payment-4"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key    string
+		amount float64
+		status string
+	}{
+		{"refund-1", 30, entity.PaymentStatusSucceeded},
+		{"refund-2", 30, entity.PaymentStatusFailed}, // only 20 is left
+		{"refund-3", 20, entity.PaymentStatusSucceeded},
+	}
+
+	for _, tt := range tests {
+		result, err := fake.Refund(context.Background(), entity.RefundRequest{ProviderRef: charge.ProviderRef, Amount: tt.amount, IdempotencyKey: tt.key})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Status != tt.status {
+			t.Errorf("refund %s of %.2f status = %s, want %s", tt.key, tt.amount, result.Status, tt.status)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package provider
+
+import (
+	"net/http"
+	"time"
+)
+
+// Option -.
+type Option func(*Fake)
+
+// WebhookURL sets where delayed confirmations are posted to.
+func WebhookURL(url string) Option {
+	return func(f *Fake) {
+		f.webhookURL = url
+	}
+}
+
+// WebhookDelay sets how long a delayed charge stays pending before its webhook is sent.
+func WebhookDelay(delay time.Duration) Option {
+	return func(f *Fake) {
+		f.webhookDelay = delay
+	}
+}
+
+// Timeout sets how long a charge with TokenTimeout hangs before failing.
+func Timeout(timeout time.Duration) Option {
+	return func(f *Fake) {
+		f.timeout = timeout
+	}
+}
+
+// HTTPClient sets the client used to deliver webhooks.
+func HTTPClient(client *http.Client) Option {
+	return func(f *Fake) {
+		f.client = client
+	}
+}
+
+// OnWebhook delivers webhooks to fn instead of posting them over HTTP.
+func OnWebhook(fn func(payload []byte, signature string)) Option {
+	return func(f *Fake) {
+		f.onWebhook = fn
+	}
+}
@@ -0,0 +1,514 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/Avazbek-02/Online-Hotel-System/config"
+	"github.com/Avazbek-02/Online-Hotel-System/internal/entity"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/logger"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/postgres"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+type BookingRepo struct {
+	pg     *postgres.Postgres
+	config *config.Config
+	logger *logger.Logger
+}
+
+func NewBookingRepo(pg *postgres.Postgres, config *config.Config, logger *logger.Logger) *BookingRepo {
+	return &BookingRepo{
+		pg:     pg,
+		config: config,
+		logger: logger,
+	}
+}
+
+const bookingColumns = `id, user_id, room_type_id, room_id, hotel_id, check_in_date, check_out_date, status, total_price, checked_in_at, checked_out_at, created_at, updated_at`
+
+func scanBooking(row pgx.Row, item *entity.Booking) error {
+	var (
+		checkInDate, checkOutDate time.Time
+		roomID                    sql.NullString
+		checkedInAt, checkedOutAt sql.NullTime
+		createdAt, updatedAt      time.Time
+	)
+
+	err := row.Scan(&item.ID, &item.UserID, &item.RoomTypeID, &roomID, &item.HotelID, &checkInDate, &checkOutDate, &item.Status, &item.TotalPrice, &checkedInAt, &checkedOutAt, &createdAt, &updatedAt)
+	if err != nil {
+		return err
+	}
+
+	item.RoomID = roomID.String
+	item.CheckInDate = checkInDate.Format(time.DateOnly)
+	item.CheckOutDate = checkOutDate.Format(time.DateOnly)
+	if checkedInAt.Valid {
+		item.CheckedInAt = checkedInAt.Time.Format(time.RFC3339)
+	}
+	if checkedOutAt.Valid {
+		item.CheckedOutAt = checkedOutAt.Time.Format(time.RFC3339)
+	}
+	item.CreatedAt = createdAt.Format(time.RFC3339)
+	item.UpdatedAt = updatedAt.Format(time.RFC3339)
+	return nil
+}
+
+// reserveRoomType locks a room type, so bookings of it are made one at a time, and returns
+// ErrRoomTypeUnavailable when none of its rooms is free on every night of the stay.
+func (r *BookingRepo) reserveRoomType(ctx context.Context, tx pgx.Tx, roomTypeID, checkInDate, checkOutDate, excludeBookingID string) error {
+	var id string
+	err := tx.QueryRow(ctx, "SELECT id FROM room_types WHERE id = $1 FOR UPDATE", roomTypeID).Scan(&id)
+	if err != nil {
+		return err
+	}
+
+	// counted after the lock is held, so bookings committed while waiting for it are seen
+	query, args, err := r.pg.Builder.
+		Select().
+		Column(freeRooms("t.id", checkInDate, checkOutDate, excludeBookingID)).
+		From("room_types t").
+		Where("t.id = ?", roomTypeID).ToSql()
+	if err != nil {
+		return err
+	}
+
+	var free int
+	err = tx.QueryRow(ctx, query, args...).Scan(&free)
+	if err != nil {
+		return err
+	}
+
+	if free <= 0 {
+		return entity.ErrRoomTypeUnavailable
+	}
+
+	return nil
+}
+
+// Create books a stay against a room type. It returns ErrRoomTypeUnavailable when the type
+// is sold out on some night of the stay. The room is assigned at check-in.
+func (r *BookingRepo) Create(ctx context.Context, req entity.Booking) (entity.Booking, error) {
+	req.ID = uuid.NewString()
+
+	tx, err := r.pg.Pool.Begin(ctx)
+	if err != nil {
+		return entity.Booking{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	err = r.reserveRoomType(ctx, tx, req.RoomTypeID, req.CheckInDate, req.CheckOutDate, "")
+	if err != nil {
+		return entity.Booking{}, err
+	}
+
+	query, args, err := r.pg.Builder.Insert("bookings").
+		Columns(`id, user_id, room_type_id, hotel_id, check_in_date, check_out_date, status, total_price`).
+		Values(req.ID, req.UserID, req.RoomTypeID, roomTypeHotelID(req.RoomTypeID), req.CheckInDate, req.CheckOutDate, req.Status, req.TotalPrice).ToSql()
+	if err != nil {
+		return entity.Booking{}, err
+	}
+
+	_, err = tx.Exec(ctx, query, args...)
+	if err != nil {
+		return entity.Booking{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return entity.Booking{}, err
+	}
+
+	return r.GetSingle(ctx, entity.Id{ID: req.ID})
+}
+
+func (r *BookingRepo) GetSingle(ctx context.Context, req entity.Id) (entity.Booking, error) {
+	var response entity.Booking
+
+	if req.ID == "" {
+		return entity.Booking{}, fmt.Errorf("GetSingle - invalid request")
+	}
+
+	query, args, err := r.pg.Builder.Select(bookingColumns).From("bookings").Where("id = ?", req.ID).ToSql()
+	if err != nil {
+		return entity.Booking{}, err
+	}
+
+	err = scanBooking(r.pg.Pool.QueryRow(ctx, query, args...), &response)
+	if err != nil {
+		return entity.Booking{}, err
+	}
+
+	return response, nil
+}
+
+func (r *BookingRepo) GetList(ctx context.Context, req entity.GetListFilter) (entity.BookingList, error) {
+	response := entity.BookingList{}
+
+	queryBuilder := r.pg.Builder.Select(bookingColumns).From("bookings")
+
+	queryBuilder, where := PrepareGetListQuery(queryBuilder, req)
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return response, err
+	}
+
+	rows, err := r.pg.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return response, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item entity.Booking
+		if err = scanBooking(rows, &item); err != nil {
+			return response, err
+		}
+
+		response.Items = append(response.Items, item)
+	}
+
+	countQuery, args, err := r.pg.Builder.Select("COUNT(1)").From("bookings").Where(where).ToSql()
+	if err != nil {
+		return response, err
+	}
+
+	err = r.pg.Pool.QueryRow(ctx, countQuery, args...).Scan(&response.Count)
+	if err != nil {
+		return response, err
+	}
+
+	return response, nil
+}
+
+// Update changes a booking. A new room type or new dates are checked against the rooms of the
+// type like Create does, leaving the booking itself out. The room is only set by CheckIn, and
+// the status only by Cancel, CheckIn, CheckOut and payments, so req.Status is ignored.
+func (r *BookingRepo) Update(ctx context.Context, req entity.Booking) (entity.Booking, error) {
+	updateFields := make(map[string]interface{})
+
+	if req.RoomTypeID != "" && req.RoomTypeID != "string" {
+		updateFields["room_type_id"] = req.RoomTypeID
+		updateFields["hotel_id"] = roomTypeHotelID(req.RoomTypeID)
+	}
+	if req.CheckInDate != "" && req.CheckInDate != "string" {
+		updateFields["check_in_date"] = req.CheckInDate
+	}
+	if req.CheckOutDate != "" && req.CheckOutDate != "string" {
+		updateFields["check_out_date"] = req.CheckOutDate
+	}
+	if req.TotalPrice != 0 {
+		updateFields["total_price"] = req.TotalPrice
+	}
+
+	if len(updateFields) == 0 {
+		return entity.Booking{}, errors.New("no fields to update")
+	}
+
+	updateFields["updated_at"] = "now()"
+
+	tx, err := r.pg.Pool.Begin(ctx)
+	if err != nil {
+		return entity.Booking{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	_, typeChanged := updateFields["room_type_id"]
+	_, checkInChanged := updateFields["check_in_date"]
+	_, checkOutChanged := updateFields["check_out_date"]
+
+	if typeChanged || checkInChanged || checkOutChanged {
+		var current entity.Booking
+		err = scanBooking(tx.QueryRow(ctx, `SELECT `+bookingColumns+` FROM bookings WHERE id = $1 FOR UPDATE`, req.ID), &current)
+		if err != nil {
+			return entity.Booking{}, err
+		}
+
+		if typeChanged {
+			current.RoomTypeID = req.RoomTypeID
+		}
+		if checkInChanged {
+			current.CheckInDate = req.CheckInDate
+		}
+		if checkOutChanged {
+			current.CheckOutDate = req.CheckOutDate
+		}
+
+		if current.Status != entity.BookingStatusCancelled {
+			err = r.reserveRoomType(ctx, tx, current.RoomTypeID, current.CheckInDate, current.CheckOutDate, req.ID)
+			if err != nil {
+				return entity.Booking{}, err
+			}
+		}
+	}
+
+	query, args, err := r.pg.Builder.Update("bookings").SetMap(updateFields).Where("id = ?", req.ID).ToSql()
+	if err != nil {
+		return entity.Booking{}, err
+	}
+
+	_, err = tx.Exec(ctx, query, args...)
+	if err != nil {
+		return entity.Booking{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return entity.Booking{}, err
+	}
+
+	return r.GetSingle(ctx, entity.Id{ID: req.ID})
+}
+
+// Cancel marks a booking cancelled and, in the same transaction, voids its pending payments
+// and records pending refunds of req.RefundPercent of what is left of its succeeded payments,
+// spread over them oldest first. Paying the refunds out is left to the caller, so a failing
+// provider can't leave a cancelled booking without them. The status is checked in the update
+// itself, so of two concurrent cancellations only one succeeds and the other gets ErrBookingNotCancellable.
+func (r *BookingRepo) Cancel(ctx context.Context, req entity.CancelBookingRequest) (entity.CancelBookingResponse, error) {
+	response := entity.CancelBookingResponse{Refunds: []entity.Refund{}, VoidedPayments: []entity.Payment{}}
+
+	tx, err := r.pg.Pool.Begin(ctx)
+	if err != nil {
+		return response, err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE bookings SET status = $1, cancelled_at = now(), updated_at = now()
+		WHERE id = $2 AND status NOT IN ($1, $3, $4)`,
+		entity.BookingStatusCancelled, req.BookingID, entity.BookingStatusCompleted, entity.BookingStatusCheckedIn)
+	if err != nil {
+		return response, err
+	}
+
+	if tag.RowsAffected() == 0 {
+		if _, err = r.GetSingle(ctx, entity.Id{ID: req.BookingID}); err != nil {
+			return response, err
+		}
+		return response, entity.ErrBookingNotCancellable
+	}
+
+	rows, err := tx.Query(ctx, `
+		UPDATE payments SET status = $1, updated_at = now()
+		WHERE booking_id = $2 AND status = $3
+		RETURNING `+paymentColumns, entity.PaymentStatusVoided, req.BookingID, entity.PaymentStatusPending)
+	if err != nil {
+		return response, err
+	}
+
+	for rows.Next() {
+		var item entity.Payment
+		if err = scanPayment(rows, &item); err != nil {
+			rows.Close()
+			return response, err
+		}
+
+		response.VoidedPayments = append(response.VoidedPayments, item)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return response, err
+	}
+
+	if req.RefundPercent > 0 {
+		response.Refunds, response.RefundAmount, err = r.createRefunds(ctx, tx, req)
+		if err != nil {
+			return response, err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return response, err
+	}
+
+	response.Booking, err = r.GetSingle(ctx, entity.Id{ID: req.BookingID})
+	return response, err
+}
+
+// createRefunds writes the pending refunds of a booking being cancelled.
+func (r *BookingRepo) createRefunds(ctx context.Context, tx pgx.Tx, req entity.CancelBookingRequest) ([]entity.Refund, float64, error) {
+	type refundable struct {
+		paymentID string
+		amount    float64
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT p.id, p.amount - COALESCE((SELECT SUM(f.amount) FROM refunds f WHERE f.payment_id = p.id AND f.status <> $3), 0)
+		FROM payments p
+		WHERE p.booking_id = $1 AND p.status = $2
+		ORDER BY p.created_at`, req.BookingID, entity.PaymentStatusSucceeded, entity.RefundStatusFailed)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var (
+		payments []refundable
+		paid     float64
+	)
+	for rows.Next() {
+		var item refundable
+		if err = rows.Scan(&item.paymentID, &item.amount); err != nil {
+			rows.Close()
+			return nil, 0, err
+		}
+
+		payments = append(payments, item)
+		paid += item.amount
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	refunds := []entity.Refund{}
+	total := math.Round(paid*req.RefundPercent) / 100
+	remaining := total
+	reason := "Booking cancelled, " + strconv.FormatFloat(req.RefundPercent, 'f', -1, 64) + "% refund"
+
+	for _, payment := range payments {
+		amount := math.Min(remaining, payment.amount)
+		if amount <= 0 {
+			continue
+		}
+		remaining = math.Round((remaining-amount)*100) / 100
+
+		var refund entity.Refund
+		err = scanRefund(tx.QueryRow(ctx, `
+			INSERT INTO refunds (id, payment_id, booking_id, amount, status, reason)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING `+refundColumns,
+			uuid.NewString(), payment.paymentID, req.BookingID, amount, entity.RefundStatusPending, reason), &refund)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		refunds = append(refunds, refund)
+	}
+
+	return refunds, total, nil
+}
+
+// readyRoomQuery picks a clean room of the booking's type that no other stay holds for its dates.
+// The room is locked and rooms other check-ins hold are skipped, so two of them can't pick the same one.
+const readyRoomQuery = `
+	SELECT r.id FROM rooms r
+	JOIN bookings b ON b.id = $1
+	WHERE r.room_type_id = b.room_type_id AND r.status = 'available'
+	  AND NOT EXISTS (
+		SELECT 1 FROM bookings o
+		WHERE o.room_id = r.id AND o.id <> b.id AND o.status <> 'cancelled'
+		  AND daterange(o.check_in_date, o.check_out_date, '[)') && daterange(b.check_in_date, b.check_out_date, '[)')
+	  )
+	ORDER BY r.floor, r.room_number
+	LIMIT 1
+	FOR UPDATE OF r SKIP LOCKED`
+
+// CheckIn moves a confirmed booking to checked_in, puts the guest in a room and marks it occupied.
+// The room is req.RoomID, the one the booking already has or else any ready room of the booked type.
+// It returns ErrBookingNotConfirmed when the booking is in any other status, ErrRoomNotBookable
+// when that room is of another type or not ready and ErrNoRoomAvailable when no room is ready.
+func (r *BookingRepo) CheckIn(ctx context.Context, req entity.CheckInRequest) (entity.Booking, error) {
+	return r.moveStay(ctx, req.BookingID, req.RoomID, entity.BookingStatusConfirmed, entity.BookingStatusCheckedIn, "checked_in_at",
+		entity.RoomStatusBooked, entity.ErrBookingNotConfirmed)
+}
+
+// CheckOut completes a checked in booking and hands its room over to housekeeping.
+// It returns ErrBookingNotCheckedIn when the booking is in any other status.
+func (r *BookingRepo) CheckOut(ctx context.Context, req entity.Id) (entity.Booking, error) {
+	return r.moveStay(ctx, req.ID, "", entity.BookingStatusCheckedIn, entity.BookingStatusCompleted, "checked_out_at",
+		entity.RoomStatusCleaning, entity.ErrBookingNotCheckedIn)
+}
+
+// moveStay changes the booking status from one to another, stamps the given column and sets
+// the room status in one transaction. The booking is locked before its status is checked, so
+// of two concurrent moves only one succeeds. A guest checking in gets roomID, else the room the
+// booking already has or a ready room of its type, and whichever it is must still be ready.
+func (r *BookingRepo) moveStay(ctx context.Context, bookingID, roomID, from, to, stampColumn, roomStatus string, errWrongStatus error) (entity.Booking, error) {
+	tx, err := r.pg.Pool.Begin(ctx)
+	if err != nil {
+		return entity.Booking{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		status   string
+		assigned sql.NullString
+	)
+	err = tx.QueryRow(ctx, `SELECT status, room_id FROM bookings WHERE id = $1 FOR UPDATE`, bookingID).Scan(&status, &assigned)
+	if err != nil {
+		return entity.Booking{}, err
+	}
+
+	if status != from {
+		return entity.Booking{}, errWrongStatus
+	}
+
+	if to == entity.BookingStatusCheckedIn {
+		if roomID == "" && assigned.Valid {
+			roomID = assigned.String
+		}
+
+		if roomID != "" {
+			var ready bool
+			err = tx.QueryRow(ctx, `
+				SELECT r.room_type_id = b.room_type_id AND r.status = $3
+				FROM rooms r, bookings b
+				WHERE r.id = $1 AND b.id = $2
+				FOR UPDATE OF r`, roomID, bookingID, entity.RoomStatusAvailable).Scan(&ready)
+			if err != nil {
+				return entity.Booking{}, err
+			}
+
+			if !ready {
+				return entity.Booking{}, entity.ErrRoomNotBookable
+			}
+		} else {
+			err = tx.QueryRow(ctx, readyRoomQuery, bookingID).Scan(&roomID)
+			if err == pgx.ErrNoRows {
+				return entity.Booking{}, entity.ErrNoRoomAvailable
+			}
+			if err != nil {
+				return entity.Booking{}, err
+			}
+		}
+	} else if assigned.Valid {
+		roomID = assigned.String
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE bookings SET status = $1, `+stampColumn+` = now(), updated_at = now(),
+			room_id = COALESCE(NULLIF($3, '')::uuid, room_id)
+		WHERE id = $2`, to, bookingID, roomID)
+	if err != nil {
+		return entity.Booking{}, err
+	}
+
+	if roomID != "" {
+		_, err = tx.Exec(ctx, `UPDATE rooms SET status = $1, updated_at = now() WHERE id = $2`, roomStatus, roomID)
+		if err != nil {
+			return entity.Booking{}, err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return entity.Booking{}, err
+	}
+
+	return r.GetSingle(ctx, entity.Id{ID: bookingID})
+}
+
+func (r *BookingRepo) Delete(ctx context.Context, req entity.Id) error {
+	query, args, err := r.pg.Builder.Delete("bookings").Where("id = ?", req.ID).ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.pg.Pool.Exec(ctx, query, args...)
+	return err
+}
@@ -0,0 +1,171 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Avazbek-02/Online-Hotel-System/config"
+	"github.com/Avazbek-02/Online-Hotel-System/internal/entity"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/logger"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/postgres"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+type CancellationPolicyRepo struct {
+	pg     *postgres.Postgres
+	config *config.Config
+	logger *logger.Logger
+}
+
+func NewCancellationPolicyRepo(pg *postgres.Postgres, config *config.Config, logger *logger.Logger) *CancellationPolicyRepo {
+	return &CancellationPolicyRepo{
+		pg:     pg,
+		config: config,
+		logger: logger,
+	}
+}
+
+const cancellationPolicyColumns = `id, hotel_id, room_category, free_cancellation_hours, late_refund_percent, non_refundable, created_at, updated_at`
+
+func scanCancellationPolicy(row pgx.Row, item *entity.CancellationPolicy) error {
+	var createdAt, updatedAt time.Time
+
+	err := row.Scan(&item.ID, &item.HotelID, &item.RoomCategory, &item.FreeCancellationHours, &item.LateRefundPercent, &item.NonRefundable, &createdAt, &updatedAt)
+	if err != nil {
+		return err
+	}
+
+	item.CreatedAt = createdAt.Format(time.RFC3339)
+	item.UpdatedAt = updatedAt.Format(time.RFC3339)
+	return nil
+}
+
+func (r *CancellationPolicyRepo) Create(ctx context.Context, req entity.CancellationPolicy) (entity.CancellationPolicy, error) {
+	req.ID = uuid.NewString()
+	query, args, err := r.pg.Builder.Insert("cancellation_policies").
+		Columns(`id, hotel_id, room_category, free_cancellation_hours, late_refund_percent, non_refundable`).
+		Values(req.ID, req.HotelID, req.RoomCategory, req.FreeCancellationHours, req.LateRefundPercent, req.NonRefundable).ToSql()
+	if err != nil {
+		return entity.CancellationPolicy{}, err
+	}
+
+	_, err = r.pg.Pool.Exec(ctx, query, args...)
+	if err != nil {
+		return entity.CancellationPolicy{}, err
+	}
+
+	return r.GetSingle(ctx, entity.Id{ID: req.ID})
+}
+
+func (r *CancellationPolicyRepo) GetSingle(ctx context.Context, req entity.Id) (entity.CancellationPolicy, error) {
+	var response entity.CancellationPolicy
+
+	if req.ID == "" {
+		return entity.CancellationPolicy{}, fmt.Errorf("GetSingle - invalid request")
+	}
+
+	query, args, err := r.pg.Builder.Select(cancellationPolicyColumns).From("cancellation_policies").Where("id = ?", req.ID).ToSql()
+	if err != nil {
+		return entity.CancellationPolicy{}, err
+	}
+
+	err = scanCancellationPolicy(r.pg.Pool.QueryRow(ctx, query, args...), &response)
+	if err != nil {
+		return entity.CancellationPolicy{}, err
+	}
+
+	return response, nil
+}
+
+// GetByCategory returns the policy a hotel set for a room category.
+func (r *CancellationPolicyRepo) GetByCategory(ctx context.Context, req entity.CancellationPolicy) (entity.CancellationPolicy, error) {
+	var response entity.CancellationPolicy
+
+	query, args, err := r.pg.Builder.Select(cancellationPolicyColumns).From("cancellation_policies").
+		Where("hotel_id = ? AND room_category = ?", req.HotelID, req.RoomCategory).ToSql()
+	if err != nil {
+		return entity.CancellationPolicy{}, err
+	}
+
+	err = scanCancellationPolicy(r.pg.Pool.QueryRow(ctx, query, args...), &response)
+	if err != nil {
+		return entity.CancellationPolicy{}, err
+	}
+
+	return response, nil
+}
+
+func (r *CancellationPolicyRepo) GetList(ctx context.Context, req entity.GetListFilter) (entity.CancellationPolicyList, error) {
+	response := entity.CancellationPolicyList{}
+
+	queryBuilder := r.pg.Builder.Select(cancellationPolicyColumns).From("cancellation_policies")
+
+	queryBuilder, where := PrepareGetListQuery(queryBuilder, req)
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return response, err
+	}
+
+	rows, err := r.pg.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return response, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item entity.CancellationPolicy
+		if err = scanCancellationPolicy(rows, &item); err != nil {
+			return response, err
+		}
+
+		response.Items = append(response.Items, item)
+	}
+
+	countQuery, args, err := r.pg.Builder.Select("COUNT(1)").From("cancellation_policies").Where(where).ToSql()
+	if err != nil {
+		return response, err
+	}
+
+	err = r.pg.Pool.QueryRow(ctx, countQuery, args...).Scan(&response.Count)
+	if err != nil {
+		return response, err
+	}
+
+	return response, nil
+}
+
+// Update replaces the terms of a policy. Zero is a meaningful value for every term,
+// so unlike other repos all of them are written.
+func (r *CancellationPolicyRepo) Update(ctx context.Context, req entity.CancellationPolicy) (entity.CancellationPolicy, error) {
+	updateFields := map[string]interface{}{
+		"free_cancellation_hours": req.FreeCancellationHours,
+		"late_refund_percent":     req.LateRefundPercent,
+		"non_refundable":          req.NonRefundable,
+		"updated_at":              "now()",
+	}
+
+	query, args, err := r.pg.Builder.Update("cancellation_policies").SetMap(updateFields).Where("id = ?", req.ID).ToSql()
+	if err != nil {
+		return entity.CancellationPolicy{}, err
+	}
+
+	_, err = r.pg.Pool.Exec(ctx, query, args...)
+	if err != nil {
+		return entity.CancellationPolicy{}, err
+	}
+
+	return r.GetSingle(ctx, entity.Id{ID: req.ID})
+}
+
+func (r *CancellationPolicyRepo) Delete(ctx context.Context, req entity.Id) error {
+	query, args, err := r.pg.Builder.Delete("cancellation_policies").Where("id = ?", req.ID).ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.pg.Pool.Exec(ctx, query, args...)
+	return err
+}
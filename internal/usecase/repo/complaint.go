@@ -0,0 +1,246 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Avazbek-02/Online-Hotel-System/config"
+	"github.com/Avazbek-02/Online-Hotel-System/internal/entity"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/logger"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/postgres"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+type ComplaintRepo struct {
+	pg     *postgres.Postgres
+	config *config.Config
+	logger *logger.Logger
+}
+
+func NewComplaintRepo(pg *postgres.Postgres, config *config.Config, logger *logger.Logger) *ComplaintRepo {
+	return &ComplaintRepo{
+		pg:     pg,
+		config: config,
+		logger: logger,
+	}
+}
+
+const complaintColumns = `id, user_id, booking_id, hotel_id, COALESCE(assignee_id::text, ''), message, status, resolved_at, created_at, updated_at`
+
+func scanComplaint(row pgx.Row, item *entity.Complaint) error {
+	var (
+		resolvedAt           sql.NullTime
+		createdAt, updatedAt time.Time
+	)
+
+	err := row.Scan(&item.ID, &item.UserID, &item.BookingID, &item.HotelID, &item.AssigneeID, &item.Message, &item.Status, &resolvedAt, &createdAt, &updatedAt)
+	if err != nil {
+		return err
+	}
+
+	if resolvedAt.Valid {
+		item.ResolvedAt = resolvedAt.Time.Format(time.RFC3339)
+	}
+	item.CreatedAt = createdAt.Format(time.RFC3339)
+	item.UpdatedAt = updatedAt.Format(time.RFC3339)
+	return nil
+}
+
+func (r *ComplaintRepo) Create(ctx context.Context, req entity.Complaint) (entity.Complaint, error) {
+	req.ID = uuid.NewString()
+	query, args, err := r.pg.Builder.Insert("complaints").
+		Columns(`id, user_id, booking_id, hotel_id, message, status`).
+		Values(req.ID, req.UserID, req.BookingID, req.HotelID, req.Message, entity.ComplaintStatusPending).ToSql()
+	if err != nil {
+		return entity.Complaint{}, err
+	}
+
+	_, err = r.pg.Pool.Exec(ctx, query, args...)
+	if err != nil {
+		return entity.Complaint{}, err
+	}
+
+	return r.GetSingle(ctx, entity.Id{ID: req.ID})
+}
+
+func (r *ComplaintRepo) GetSingle(ctx context.Context, req entity.Id) (entity.Complaint, error) {
+	var response entity.Complaint
+
+	if req.ID == "" {
+		return entity.Complaint{}, fmt.Errorf("GetSingle - invalid request")
+	}
+
+	query, args, err := r.pg.Builder.Select(complaintColumns).From("complaints").Where("id = ?", req.ID).ToSql()
+	if err != nil {
+		return entity.Complaint{}, err
+	}
+
+	err = scanComplaint(r.pg.Pool.QueryRow(ctx, query, args...), &response)
+	if err != nil {
+		return entity.Complaint{}, err
+	}
+
+	return response, nil
+}
+
+func (r *ComplaintRepo) GetList(ctx context.Context, req entity.GetListFilter) (entity.ComplaintList, error) {
+	response := entity.ComplaintList{}
+
+	queryBuilder := r.pg.Builder.Select(complaintColumns).From("complaints")
+
+	queryBuilder, where := PrepareGetListQuery(queryBuilder, req)
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return response, err
+	}
+
+	rows, err := r.pg.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return response, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item entity.Complaint
+		if err = scanComplaint(rows, &item); err != nil {
+			return response, err
+		}
+
+		response.Items = append(response.Items, item)
+	}
+
+	countQuery, args, err := r.pg.Builder.Select("COUNT(1)").From("complaints").Where(where).ToSql()
+	if err != nil {
+		return response, err
+	}
+
+	err = r.pg.Pool.QueryRow(ctx, countQuery, args...).Scan(&response.Count)
+	if err != nil {
+		return response, err
+	}
+
+	return response, nil
+}
+
+// Assign hands an open complaint to a staff member. A pending complaint is taken into work
+// at the same time.
+func (r *ComplaintRepo) Assign(ctx context.Context, req entity.Complaint) (entity.Complaint, error) {
+	tag, err := r.pg.Pool.Exec(ctx, `
+		UPDATE complaints SET
+			assignee_id = $1,
+			status = CASE WHEN status = $2 THEN $3 ELSE status END,
+			updated_at = now()
+		WHERE id = $4 AND status IN ($2, $3)`,
+		req.AssigneeID, entity.ComplaintStatusPending, entity.ComplaintStatusInProgress, req.ID)
+	if err != nil {
+		return entity.Complaint{}, err
+	}
+
+	if tag.RowsAffected() == 0 {
+		if _, err = r.GetSingle(ctx, entity.Id{ID: req.ID}); err != nil {
+			return entity.Complaint{}, err
+		}
+		return entity.Complaint{}, entity.ErrComplaintStatusChanged
+	}
+
+	return r.GetSingle(ctx, entity.Id{ID: req.ID})
+}
+
+// UpdateStatus moves a complaint from one status to another. The current status is part of
+// the condition, so a concurrent change makes it fail with ErrComplaintStatusChanged.
+func (r *ComplaintRepo) UpdateStatus(ctx context.Context, id, from, to string) (entity.Complaint, error) {
+	updateFields := map[string]interface{}{
+		"status":     to,
+		"updated_at": "now()",
+	}
+	if to == entity.ComplaintStatusResolved || to == entity.ComplaintStatusRejected {
+		updateFields["resolved_at"] = "now()"
+	}
+
+	query, args, err := r.pg.Builder.Update("complaints").SetMap(updateFields).
+		Where("id = ? AND status = ?", id, from).ToSql()
+	if err != nil {
+		return entity.Complaint{}, err
+	}
+
+	tag, err := r.pg.Pool.Exec(ctx, query, args...)
+	if err != nil {
+		return entity.Complaint{}, err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return entity.Complaint{}, entity.ErrComplaintStatusChanged
+	}
+
+	return r.GetSingle(ctx, entity.Id{ID: id})
+}
+
+func (r *ComplaintRepo) Delete(ctx context.Context, req entity.Id) error {
+	query, args, err := r.pg.Builder.Delete("complaints").Where("id = ?", req.ID).ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.pg.Pool.Exec(ctx, query, args...)
+	return err
+}
+
+func (r *ComplaintRepo) AddMessage(ctx context.Context, req entity.ComplaintMessage) (entity.ComplaintMessage, error) {
+	var createdAt time.Time
+
+	req.ID = uuid.NewString()
+	query, args, err := r.pg.Builder.Insert("complaint_messages").
+		Columns(`id, complaint_id, user_id, message`).
+		Values(req.ID, req.ComplaintID, req.UserID, req.Message).
+		Suffix("RETURNING created_at").ToSql()
+	if err != nil {
+		return entity.ComplaintMessage{}, err
+	}
+
+	err = r.pg.Pool.QueryRow(ctx, query, args...).Scan(&createdAt)
+	if err != nil {
+		return entity.ComplaintMessage{}, err
+	}
+
+	req.CreatedAt = createdAt.Format(time.RFC3339)
+	return req, nil
+}
+
+// GetMessages returns the message thread of a complaint, oldest first.
+func (r *ComplaintRepo) GetMessages(ctx context.Context, req entity.Id) (entity.ComplaintMessageList, error) {
+	response := entity.ComplaintMessageList{}
+
+	query, args, err := r.pg.Builder.Select(`id, complaint_id, user_id, message, created_at`).
+		From("complaint_messages").
+		Where("complaint_id = ?", req.ID).
+		OrderBy("created_at ASC").ToSql()
+	if err != nil {
+		return response, err
+	}
+
+	rows, err := r.pg.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return response, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			item      entity.ComplaintMessage
+			createdAt time.Time
+		)
+		if err = rows.Scan(&item.ID, &item.ComplaintID, &item.UserID, &item.Message, &createdAt); err != nil {
+			return response, err
+		}
+
+		item.CreatedAt = createdAt.Format(time.RFC3339)
+		response.Items = append(response.Items, item)
+	}
+
+	response.Count = len(response.Items)
+	return response, rows.Err()
+}
@@ -22,11 +22,15 @@ func PrepareFilter(filters []entity.Filter) squirrel.And {
 			where = append(where, squirrel.Lt{e.Column: e.Value})
 		case "lte":
 			where = append(where, squirrel.LtOrEq{e.Column: e.Value})
+		case "in":
+			where = append(where, squirrel.Eq{e.Column: e.Values})
 		case "search":
 			or = append(or, squirrel.ILike{e.Column: "%" + e.Value + "%"})
 		}
 	}
-	where = append(where, or)
+	if len(or) > 0 {
+		where = append(where, or)
+	}
 
 	return where
 }
@@ -0,0 +1,253 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Avazbek-02/Online-Hotel-System/config"
+	"github.com/Avazbek-02/Online-Hotel-System/internal/entity"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/logger"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/postgres"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+type HotelRepo struct {
+	pg     *postgres.Postgres
+	config *config.Config
+	logger *logger.Logger
+}
+
+func NewHotelRepo(pg *postgres.Postgres, config *config.Config, logger *logger.Logger) *HotelRepo {
+	return &HotelRepo{
+		pg:     pg,
+		config: config,
+		logger: logger,
+	}
+}
+
+const hotelColumns = `id, name, address, COALESCE(latitude, 0), COALESCE(longitude, 0), timezone, currency, amenities, created_at, updated_at`
+
+func scanHotel(row pgx.Row, item *entity.Hotel) error {
+	var createdAt, updatedAt time.Time
+
+	err := row.Scan(&item.ID, &item.Name, &item.Address, &item.Latitude, &item.Longitude, &item.Timezone, &item.Currency, &item.Amenities, &createdAt, &updatedAt)
+	if err != nil {
+		return err
+	}
+
+	item.CreatedAt = createdAt.Format(time.RFC3339)
+	item.UpdatedAt = updatedAt.Format(time.RFC3339)
+	return nil
+}
+
+func (r *HotelRepo) Create(ctx context.Context, req entity.Hotel) (entity.Hotel, error) {
+	req.ID = uuid.NewString()
+	if req.Amenities == nil {
+		req.Amenities = []string{}
+	}
+
+	query, args, err := r.pg.Builder.Insert("hotels").
+		Columns(`id, name, address, latitude, longitude, timezone, currency, amenities`).
+		Values(req.ID, req.Name, req.Address, req.Latitude, req.Longitude, req.Timezone, req.Currency, req.Amenities).ToSql()
+	if err != nil {
+		return entity.Hotel{}, err
+	}
+
+	_, err = r.pg.Pool.Exec(ctx, query, args...)
+	if err != nil {
+		return entity.Hotel{}, err
+	}
+
+	return r.GetSingle(ctx, entity.Id{ID: req.ID})
+}
+
+func (r *HotelRepo) GetSingle(ctx context.Context, req entity.Id) (entity.Hotel, error) {
+	var response entity.Hotel
+
+	if req.ID == "" {
+		return entity.Hotel{}, fmt.Errorf("GetSingle - invalid request")
+	}
+
+	query, args, err := r.pg.Builder.Select(hotelColumns).From("hotels").Where("id = ?", req.ID).ToSql()
+	if err != nil {
+		return entity.Hotel{}, err
+	}
+
+	err = scanHotel(r.pg.Pool.QueryRow(ctx, query, args...), &response)
+	if err != nil {
+		return entity.Hotel{}, err
+	}
+
+	return response, nil
+}
+
+func (r *HotelRepo) GetList(ctx context.Context, req entity.GetListFilter) (entity.HotelList, error) {
+	response := entity.HotelList{}
+
+	queryBuilder := r.pg.Builder.Select(hotelColumns).From("hotels")
+
+	queryBuilder, where := PrepareGetListQuery(queryBuilder, req)
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return response, err
+	}
+
+	rows, err := r.pg.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return response, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item entity.Hotel
+		if err = scanHotel(rows, &item); err != nil {
+			return response, err
+		}
+
+		response.Items = append(response.Items, item)
+	}
+
+	countQuery, args, err := r.pg.Builder.Select("COUNT(1)").From("hotels").Where(where).ToSql()
+	if err != nil {
+		return response, err
+	}
+
+	err = r.pg.Pool.QueryRow(ctx, countQuery, args...).Scan(&response.Count)
+	if err != nil {
+		return response, err
+	}
+
+	return response, nil
+}
+
+func (r *HotelRepo) Update(ctx context.Context, req entity.Hotel) (entity.Hotel, error) {
+	updateFields := make(map[string]interface{})
+
+	if req.Name != "" && req.Name != "string" {
+		updateFields["name"] = req.Name
+	}
+	if req.Address != "" && req.Address != "string" {
+		updateFields["address"] = req.Address
+	}
+	if req.Latitude != 0 || req.Longitude != 0 {
+		updateFields["latitude"] = req.Latitude
+		updateFields["longitude"] = req.Longitude
+	}
+	if req.Timezone != "" && req.Timezone != "string" {
+		updateFields["timezone"] = req.Timezone
+	}
+	if req.Currency != "" && req.Currency != "string" {
+		updateFields["currency"] = req.Currency
+	}
+	if req.Amenities != nil {
+		updateFields["amenities"] = req.Amenities
+	}
+
+	if len(updateFields) == 0 {
+		return entity.Hotel{}, errors.New("no fields to update")
+	}
+
+	updateFields["updated_at"] = "now()"
+
+	query, args, err := r.pg.Builder.Update("hotels").SetMap(updateFields).Where("id = ?", req.ID).ToSql()
+	if err != nil {
+		return entity.Hotel{}, err
+	}
+
+	_, err = r.pg.Pool.Exec(ctx, query, args...)
+	if err != nil {
+		return entity.Hotel{}, err
+	}
+
+	return r.GetSingle(ctx, entity.Id{ID: req.ID})
+}
+
+func (r *HotelRepo) Delete(ctx context.Context, req entity.Id) error {
+	query, args, err := r.pg.Builder.Delete("hotels").Where("id = ?", req.ID).ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.pg.Pool.Exec(ctx, query, args...)
+	return err
+}
+
+// AddStaff assigns a user to a hotel. Assigning someone twice is not an error.
+func (r *HotelRepo) AddStaff(ctx context.Context, req entity.HotelStaff) error {
+	_, err := r.pg.Pool.Exec(ctx, `
+		INSERT INTO hotel_staff (hotel_id, user_id) VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`, req.HotelID, req.UserID)
+	return err
+}
+
+// RemoveStaff returns pgx.ErrNoRows when the user isn't assigned to the hotel.
+func (r *HotelRepo) RemoveStaff(ctx context.Context, req entity.HotelStaff) error {
+	tag, err := r.pg.Pool.Exec(ctx, `DELETE FROM hotel_staff WHERE hotel_id = $1 AND user_id = $2`, req.HotelID, req.UserID)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *HotelRepo) GetStaff(ctx context.Context, req entity.Id) (entity.HotelStaffList, error) {
+	response := entity.HotelStaffList{}
+
+	rows, err := r.pg.Pool.Query(ctx, `
+		SELECT s.hotel_id, s.user_id, u.fullname, u.email, u.role, s.created_at
+		FROM hotel_staff s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.hotel_id = $1
+		ORDER BY u.role, u.fullname`, req.ID)
+	if err != nil {
+		return response, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			item      entity.HotelStaff
+			createdAt time.Time
+		)
+
+		err = rows.Scan(&item.HotelID, &item.UserID, &item.FullName, &item.Email, &item.UserRole, &createdAt)
+		if err != nil {
+			return response, err
+		}
+
+		item.CreatedAt = createdAt.Format(time.RFC3339)
+		response.Items = append(response.Items, item)
+	}
+
+	response.Count = len(response.Items)
+	return response, rows.Err()
+}
+
+// GetHotelIDsByStaff returns the hotels a user is assigned to.
+func (r *HotelRepo) GetHotelIDsByStaff(ctx context.Context, req entity.Id) ([]string, error) {
+	rows, err := r.pg.Pool.Query(ctx, `SELECT hotel_id FROM hotel_staff WHERE user_id = $1`, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hotelIDs := []string{}
+	for rows.Next() {
+		var hotelID string
+		if err = rows.Scan(&hotelID); err != nil {
+			return nil, err
+		}
+
+		hotelIDs = append(hotelIDs, hotelID)
+	}
+
+	return hotelIDs, rows.Err()
+}
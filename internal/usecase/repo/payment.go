@@ -0,0 +1,327 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Avazbek-02/Online-Hotel-System/config"
+	"github.com/Avazbek-02/Online-Hotel-System/internal/entity"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/logger"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/postgres"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+type PaymentRepo struct {
+	pg     *postgres.Postgres
+	config *config.Config
+	logger *logger.Logger
+}
+
+func NewPaymentRepo(pg *postgres.Postgres, config *config.Config, logger *logger.Logger) *PaymentRepo {
+	return &PaymentRepo{
+		pg:     pg,
+		config: config,
+		logger: logger,
+	}
+}
+
+const paymentColumns = `id, booking_id, COALESCE(user_id::text, ''), amount, type, status, COALESCE(idempotency_key, ''), COALESCE(provider_ref, ''), payment_date, created_at, updated_at`
+
+func scanPayment(row pgx.Row, item *entity.Payment) error {
+	var (
+		paymentDate          sql.NullTime
+		createdAt, updatedAt time.Time
+	)
+
+	err := row.Scan(&item.ID, &item.BookingID, &item.UserID, &item.Amount, &item.Type, &item.Status, &item.IdempotencyKey, &item.ProviderRef, &paymentDate, &createdAt, &updatedAt)
+	if err != nil {
+		return err
+	}
+
+	if paymentDate.Valid {
+		item.PaymentDate = paymentDate.Time.Format(time.RFC3339)
+	}
+	item.CreatedAt = createdAt.Format(time.RFC3339)
+	item.UpdatedAt = updatedAt.Format(time.RFC3339)
+	return nil
+}
+
+// Create records a payment against a booking. The booking row is locked for the
+// duration of the transaction so that concurrent payments see each other, and the
+// booking is moved to confirmed once the succeeded payments cover its total price.
+// A payment with an idempotency key the user already used is returned as is, unless it is
+// for a different booking, amount or type.
+func (r *PaymentRepo) Create(ctx context.Context, req entity.Payment) (entity.Payment, error) {
+	tx, err := r.pg.Pool.Begin(ctx)
+	if err != nil {
+		return entity.Payment{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	var bookingID string
+	err = tx.QueryRow(ctx, "SELECT id FROM bookings WHERE id = $1 FOR UPDATE", req.BookingID).Scan(&bookingID)
+	if err != nil {
+		return entity.Payment{}, err
+	}
+
+	if req.IdempotencyKey != "" {
+		existing, err := r.getByIdempotencyKey(ctx, tx, req)
+		if err == nil {
+			if existing.BookingID != req.BookingID || existing.Amount != req.Amount || existing.Type != req.Type {
+				return entity.Payment{}, entity.ErrIdempotencyKeyReused
+			}
+			return existing, nil
+		}
+		if err != pgx.ErrNoRows {
+			return entity.Payment{}, err
+		}
+	}
+
+	balance, err := r.getBalance(ctx, tx, req.BookingID)
+	if err != nil {
+		return entity.Payment{}, err
+	}
+
+	// pending payments may still succeed, so they reserve their part of the balance
+	if req.Amount > balance.Outstanding-balance.Pending {
+		return entity.Payment{}, entity.ErrPaymentExceedsBalance
+	}
+
+	req.ID = uuid.NewString()
+	paymentDate := sql.NullTime{}
+	if req.Status == entity.PaymentStatusSucceeded {
+		paymentDate = sql.NullTime{Time: time.Now(), Valid: true}
+	}
+
+	userID := sql.NullString{String: req.UserID, Valid: req.UserID != ""}
+	idempotencyKey := sql.NullString{String: req.IdempotencyKey, Valid: req.IdempotencyKey != ""}
+	providerRef := sql.NullString{String: req.ProviderRef, Valid: req.ProviderRef != ""}
+
+	query, args, err := r.pg.Builder.Insert("payments").
+		Columns(`id, booking_id, user_id, amount, type, status, idempotency_key, provider_ref, payment_date`).
+		Values(req.ID, req.BookingID, userID, req.Amount, req.Type, req.Status, idempotencyKey, providerRef, paymentDate).ToSql()
+	if err != nil {
+		return entity.Payment{}, err
+	}
+
+	_, err = tx.Exec(ctx, query, args...)
+	if err != nil {
+		return entity.Payment{}, err
+	}
+
+	if err = r.confirmIfPaid(ctx, tx, req.BookingID); err != nil {
+		return entity.Payment{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return entity.Payment{}, err
+	}
+
+	return r.GetSingle(ctx, entity.Id{ID: req.ID})
+}
+
+func (r *PaymentRepo) GetSingle(ctx context.Context, req entity.Id) (entity.Payment, error) {
+	var response entity.Payment
+
+	if req.ID == "" {
+		return entity.Payment{}, fmt.Errorf("GetSingle - invalid request")
+	}
+
+	query, args, err := r.pg.Builder.Select(paymentColumns).From("payments").Where("id = ?", req.ID).ToSql()
+	if err != nil {
+		return entity.Payment{}, err
+	}
+
+	err = scanPayment(r.pg.Pool.QueryRow(ctx, query, args...), &response)
+	if err != nil {
+		return entity.Payment{}, err
+	}
+
+	return response, nil
+}
+
+// GetByIdempotencyKey returns the payment the user made with an idempotency key.
+func (r *PaymentRepo) GetByIdempotencyKey(ctx context.Context, req entity.Payment) (entity.Payment, error) {
+	return r.getByIdempotencyKey(ctx, r.pg.Pool, req)
+}
+
+func (r *PaymentRepo) GetByProviderRef(ctx context.Context, ref string) (entity.Payment, error) {
+	var response entity.Payment
+
+	query, args, err := r.pg.Builder.Select(paymentColumns).From("payments").Where("provider_ref = ?", ref).ToSql()
+	if err != nil {
+		return entity.Payment{}, err
+	}
+
+	err = scanPayment(r.pg.Pool.QueryRow(ctx, query, args...), &response)
+	if err != nil {
+		return entity.Payment{}, err
+	}
+
+	return response, nil
+}
+
+// UpdateStatus stores the outcome reported by the payment provider and confirms the
+// booking if the payment settled it. Only pending payments change status, a payment voided by
+// a cancellation while the provider was charging it just gets the provider's reference.
+func (r *PaymentRepo) UpdateStatus(ctx context.Context, req entity.Payment) (entity.Payment, error) {
+	tx, err := r.pg.Pool.Begin(ctx)
+	if err != nil {
+		return entity.Payment{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	var bookingID string
+	err = tx.QueryRow(ctx, `
+		SELECT b.id FROM bookings b
+		JOIN payments p ON p.booking_id = b.id
+		WHERE p.id = $1
+		FOR UPDATE OF b`, req.ID).Scan(&bookingID)
+	if err != nil {
+		return entity.Payment{}, err
+	}
+
+	updateFields := map[string]interface{}{
+		"status":     req.Status,
+		"updated_at": "now()",
+	}
+	if req.ProviderRef != "" {
+		updateFields["provider_ref"] = req.ProviderRef
+	}
+	if req.Status == entity.PaymentStatusSucceeded {
+		updateFields["payment_date"] = "now()"
+	}
+
+	query, args, err := r.pg.Builder.Update("payments").SetMap(updateFields).
+		Where("id = ? AND status = ?", req.ID, entity.PaymentStatusPending).ToSql()
+	if err != nil {
+		return entity.Payment{}, err
+	}
+
+	tag, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return entity.Payment{}, err
+	}
+
+	if tag.RowsAffected() == 0 && req.ProviderRef != "" {
+		_, err = tx.Exec(ctx, `UPDATE payments SET provider_ref = $1, updated_at = now() WHERE id = $2 AND provider_ref IS NULL`,
+			req.ProviderRef, req.ID)
+		if err != nil {
+			return entity.Payment{}, err
+		}
+	}
+
+	if err = r.confirmIfPaid(ctx, tx, bookingID); err != nil {
+		return entity.Payment{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return entity.Payment{}, err
+	}
+
+	return r.GetSingle(ctx, entity.Id{ID: req.ID})
+}
+
+func (r *PaymentRepo) GetList(ctx context.Context, req entity.GetListFilter) (entity.PaymentList, error) {
+	response := entity.PaymentList{}
+
+	queryBuilder := r.pg.Builder.Select(paymentColumns).From("payments")
+
+	queryBuilder, where := PrepareGetListQuery(queryBuilder, req)
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return response, err
+	}
+
+	rows, err := r.pg.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return response, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item entity.Payment
+		if err = scanPayment(rows, &item); err != nil {
+			return response, err
+		}
+
+		response.Items = append(response.Items, item)
+	}
+
+	countQuery, args, err := r.pg.Builder.Select("COUNT(1)").From("payments").Where(where).ToSql()
+	if err != nil {
+		return response, err
+	}
+
+	err = r.pg.Pool.QueryRow(ctx, countQuery, args...).Scan(&response.Count)
+	if err != nil {
+		return response, err
+	}
+
+	return response, nil
+}
+
+// GetBalance returns how much of a booking's total price has been paid and what is still outstanding.
+func (r *PaymentRepo) GetBalance(ctx context.Context, req entity.Id) (entity.BookingBalance, error) {
+	return r.getBalance(ctx, r.pg.Pool, req.ID)
+}
+
+type queryRower interface {
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+func (r *PaymentRepo) getByIdempotencyKey(ctx context.Context, q queryRower, req entity.Payment) (entity.Payment, error) {
+	var response entity.Payment
+
+	query, args, err := r.pg.Builder.Select(paymentColumns).From("payments").
+		Where("user_id = ? AND idempotency_key = ?", req.UserID, req.IdempotencyKey).ToSql()
+	if err != nil {
+		return entity.Payment{}, err
+	}
+
+	err = scanPayment(q.QueryRow(ctx, query, args...), &response)
+	if err != nil {
+		return entity.Payment{}, err
+	}
+
+	return response, nil
+}
+
+func (r *PaymentRepo) getBalance(ctx context.Context, q queryRower, bookingID string) (entity.BookingBalance, error) {
+	response := entity.BookingBalance{BookingID: bookingID}
+
+	err := q.QueryRow(ctx, `
+		SELECT b.total_price,
+			COALESCE((SELECT SUM(p.amount) FROM payments p WHERE p.booking_id = b.id AND p.status IN ('succeeded', 'refunded')), 0),
+			COALESCE((SELECT SUM(p.amount) FROM payments p WHERE p.booking_id = b.id AND p.status = 'pending'), 0),
+			COALESCE((SELECT SUM(rf.amount) FROM refunds rf WHERE rf.booking_id = b.id AND rf.status <> 'failed'), 0)
+		FROM bookings b
+		WHERE b.id = $1`, bookingID).Scan(&response.TotalPrice, &response.Paid, &response.Pending, &response.Refunded)
+	if err != nil {
+		return entity.BookingBalance{}, err
+	}
+
+	response.Outstanding = math.Max(0, math.Round((response.TotalPrice-response.Paid)*100)/100)
+	return response, nil
+}
+
+// confirmIfPaid moves a pending booking to confirmed once nothing is outstanding.
+func (r *PaymentRepo) confirmIfPaid(ctx context.Context, tx pgx.Tx, bookingID string) error {
+	balance, err := r.getBalance(ctx, tx, bookingID)
+	if err != nil {
+		return err
+	}
+
+	if balance.TotalPrice <= 0 || balance.Outstanding > 0 {
+		return nil
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE bookings SET status = $1, updated_at = now() WHERE id = $2 AND status = $3`,
+		entity.BookingStatusConfirmed, bookingID, entity.BookingStatusPending)
+	return err
+}
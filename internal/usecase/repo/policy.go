@@ -0,0 +1,196 @@
+package repo
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Avazbek-02/Online-Hotel-System/config"
+	"github.com/Avazbek-02/Online-Hotel-System/internal/entity"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/logger"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/postgres"
+	"github.com/casbin/casbin/model"
+	"github.com/casbin/casbin/persist"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+)
+
+// policyColumns are the rule columns of casbin_rule, the ptype excluded.
+var policyColumns = []string{"v0", "v1", "v2", "v3", "v4", "v5"}
+
+// PolicyRepo stores the Casbin policy in casbin_rule and doubles as the Casbin adapter.
+type PolicyRepo struct {
+	pg     *postgres.Postgres
+	config *config.Config
+	logger *logger.Logger
+}
+
+func NewPolicyRepo(pg *postgres.Postgres, config *config.Config, logger *logger.Logger) *PolicyRepo {
+	return &PolicyRepo{
+		pg:     pg,
+		config: config,
+		logger: logger,
+	}
+}
+
+func (r *PolicyRepo) CreatePolicy(ctx context.Context, req entity.Policy) error {
+	return r.insertRule(ctx, r.pg.Pool, "p", []string{req.Subject, req.Object, req.Action})
+}
+
+// DeletePolicy returns pgx.ErrNoRows when there is no such policy.
+func (r *PolicyRepo) DeletePolicy(ctx context.Context, req entity.Policy) error {
+	return r.deleteRule(ctx, "p", []string{req.Subject, req.Object, req.Action})
+}
+
+func (r *PolicyRepo) CreateRoleInheritance(ctx context.Context, req entity.RoleInheritance) error {
+	return r.insertRule(ctx, r.pg.Pool, "g", []string{req.Role, req.Parent})
+}
+
+// DeleteRoleInheritance returns pgx.ErrNoRows when the role doesn't inherit from the parent.
+func (r *PolicyRepo) DeleteRoleInheritance(ctx context.Context, req entity.RoleInheritance) error {
+	return r.deleteRule(ctx, "g", []string{req.Role, req.Parent})
+}
+
+// LoadPolicy loads every rule into the model. It is part of persist.Adapter.
+func (r *PolicyRepo) LoadPolicy(m model.Model) error {
+	rows, err := r.pg.Pool.Query(context.Background(), `SELECT ptype, v0, v1, v2, v3, v4, v5 FROM casbin_rule ORDER BY id`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			ptype string
+			rule  = make([]string, len(policyColumns))
+		)
+
+		err = rows.Scan(&ptype, &rule[0], &rule[1], &rule[2], &rule[3], &rule[4], &rule[5])
+		if err != nil {
+			return err
+		}
+
+		// trailing columns are empty for rules shorter than six fields
+		for len(rule) > 0 && rule[len(rule)-1] == "" {
+			rule = rule[:len(rule)-1]
+		}
+
+		persist.LoadPolicyLine(ptype+", "+strings.Join(rule, ", "), m)
+	}
+
+	return rows.Err()
+}
+
+// SavePolicy replaces every stored rule with the rules of the model. It is part of persist.Adapter.
+func (r *PolicyRepo) SavePolicy(m model.Model) error {
+	ctx := context.Background()
+
+	tx, err := r.pg.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `DELETE FROM casbin_rule`)
+	if err != nil {
+		return err
+	}
+
+	for _, sec := range []string{"p", "g"} {
+		for ptype, assertion := range m[sec] {
+			for _, rule := range assertion.Policy {
+				if err = r.insertRule(ctx, tx, ptype, rule); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// AddPolicy is part of persist.Adapter.
+func (r *PolicyRepo) AddPolicy(sec string, ptype string, rule []string) error {
+	return r.insertRule(context.Background(), r.pg.Pool, ptype, rule)
+}
+
+// RemovePolicy is part of persist.Adapter.
+func (r *PolicyRepo) RemovePolicy(sec string, ptype string, rule []string) error {
+	err := r.deleteRule(context.Background(), ptype, rule)
+	if err == pgx.ErrNoRows {
+		return nil
+	}
+	return err
+}
+
+// RemoveFilteredPolicy is part of persist.Adapter.
+func (r *PolicyRepo) RemoveFilteredPolicy(sec string, ptype string, fieldIndex int, fieldValues ...string) error {
+	builder := r.pg.Builder.Delete("casbin_rule").Where("ptype = ?", ptype)
+
+	for i, value := range fieldValues {
+		column := fieldIndex + i
+		if value == "" || column >= len(policyColumns) {
+			continue
+		}
+		builder = builder.Where(policyColumns[column]+" = ?", value)
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.pg.Pool.Exec(context.Background(), query, args...)
+	return err
+}
+
+type execer interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+}
+
+func (r *PolicyRepo) insertRule(ctx context.Context, db execer, ptype string, rule []string) error {
+	values := []interface{}{ptype}
+	for i := range policyColumns {
+		value := ""
+		if i < len(rule) {
+			value = rule[i]
+		}
+		values = append(values, value)
+	}
+
+	query, args, err := r.pg.Builder.Insert("casbin_rule").
+		Columns(append([]string{"ptype"}, policyColumns...)...).
+		Values(values...).ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(ctx, query, args...)
+	return err
+}
+
+func (r *PolicyRepo) deleteRule(ctx context.Context, ptype string, rule []string) error {
+	builder := r.pg.Builder.Delete("casbin_rule").Where("ptype = ?", ptype)
+	for i, column := range policyColumns {
+		value := ""
+		if i < len(rule) {
+			value = rule[i]
+		}
+		builder = builder.Where(column+" = ?", value)
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return err
+	}
+
+	tag, err := r.pg.Pool.Exec(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,274 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Avazbek-02/Online-Hotel-System/config"
+	"github.com/Avazbek-02/Online-Hotel-System/internal/entity"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/logger"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/postgres"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+type RateRepo struct {
+	pg     *postgres.Postgres
+	config *config.Config
+	logger *logger.Logger
+}
+
+func NewRateRepo(pg *postgres.Postgres, config *config.Config, logger *logger.Logger) *RateRepo {
+	return &RateRepo{
+		pg:     pg,
+		config: config,
+		logger: logger,
+	}
+}
+
+const rateSeasonColumns = `id, room_type_id, name, start_date, end_date, price, min_stay, created_at, updated_at`
+
+func scanRateSeason(row pgx.Row, item *entity.RateSeason) error {
+	var (
+		startDate, endDate   time.Time
+		createdAt, updatedAt time.Time
+	)
+
+	err := row.Scan(&item.ID, &item.RoomTypeID, &item.Name, &startDate, &endDate, &item.Price, &item.MinStay, &createdAt, &updatedAt)
+	if err != nil {
+		return err
+	}
+
+	item.StartDate = startDate.Format(time.DateOnly)
+	item.EndDate = endDate.Format(time.DateOnly)
+	item.CreatedAt = createdAt.Format(time.RFC3339)
+	item.UpdatedAt = updatedAt.Format(time.RFC3339)
+	return nil
+}
+
+const rateOverrideColumns = `room_type_id, date, price, min_stay, created_at, updated_at`
+
+func scanRateOverride(row pgx.Row, item *entity.RateOverride) error {
+	var date, createdAt, updatedAt time.Time
+
+	err := row.Scan(&item.RoomTypeID, &date, &item.Price, &item.MinStay, &createdAt, &updatedAt)
+	if err != nil {
+		return err
+	}
+
+	item.Date = date.Format(time.DateOnly)
+	item.CreatedAt = createdAt.Format(time.RFC3339)
+	item.UpdatedAt = updatedAt.Format(time.RFC3339)
+	return nil
+}
+
+// GetCalendar returns the base price, seasons, day-of-week modifiers and overrides of a room type
+// that apply to nights in [from, to). Without a range every season and override is returned.
+func (r *RateRepo) GetCalendar(ctx context.Context, req entity.RateCalendarRequest) (entity.RateCalendar, error) {
+	response := entity.RateCalendar{
+		RoomTypeID:   req.RoomTypeID,
+		Seasons:      []entity.RateSeason{},
+		DayModifiers: []entity.RateDayModifier{},
+		Overrides:    []entity.RateOverride{},
+	}
+
+	err := r.pg.Pool.QueryRow(ctx, `SELECT base_price, min_stay FROM room_types WHERE id = $1`, req.RoomTypeID).
+		Scan(&response.BasePrice, &response.MinStay)
+	if err != nil {
+		return response, err
+	}
+
+	seasons := squirrel.And{squirrel.Eq{"room_type_id": req.RoomTypeID}}
+	overrides := squirrel.And{squirrel.Eq{"room_type_id": req.RoomTypeID}}
+	if req.From != "" && req.To != "" {
+		seasons = append(seasons, squirrel.Expr("start_date < ?::date AND end_date >= ?::date", req.To, req.From))
+		overrides = append(overrides, squirrel.Expr("date >= ?::date AND date < ?::date", req.From, req.To))
+	}
+
+	query, args, err := r.pg.Builder.Select(rateSeasonColumns).From("rate_seasons").Where(seasons).OrderBy("start_date", "end_date").ToSql()
+	if err != nil {
+		return response, err
+	}
+
+	rows, err := r.pg.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return response, err
+	}
+
+	for rows.Next() {
+		var item entity.RateSeason
+		if err = scanRateSeason(rows, &item); err != nil {
+			rows.Close()
+			return response, err
+		}
+
+		response.Seasons = append(response.Seasons, item)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return response, err
+	}
+
+	rows, err = r.pg.Pool.Query(ctx, `SELECT weekday, percent FROM rate_day_modifiers WHERE room_type_id = $1 ORDER BY weekday`, req.RoomTypeID)
+	if err != nil {
+		return response, err
+	}
+
+	for rows.Next() {
+		var item entity.RateDayModifier
+		if err = rows.Scan(&item.Weekday, &item.Percent); err != nil {
+			rows.Close()
+			return response, err
+		}
+
+		response.DayModifiers = append(response.DayModifiers, item)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return response, err
+	}
+
+	query, args, err = r.pg.Builder.Select(rateOverrideColumns).From("rate_overrides").Where(overrides).OrderBy("date").ToSql()
+	if err != nil {
+		return response, err
+	}
+
+	rows, err = r.pg.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return response, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item entity.RateOverride
+		if err = scanRateOverride(rows, &item); err != nil {
+			return response, err
+		}
+
+		response.Overrides = append(response.Overrides, item)
+	}
+
+	return response, rows.Err()
+}
+
+func (r *RateRepo) CreateSeason(ctx context.Context, req entity.RateSeason) (entity.RateSeason, error) {
+	var response entity.RateSeason
+
+	req.ID = uuid.NewString()
+
+	query, args, err := r.pg.Builder.Insert("rate_seasons").
+		Columns(`id, room_type_id, name, start_date, end_date, price, min_stay`).
+		Values(req.ID, req.RoomTypeID, req.Name, req.StartDate, req.EndDate, req.Price, req.MinStay).
+		Suffix("RETURNING " + rateSeasonColumns).ToSql()
+	if err != nil {
+		return response, err
+	}
+
+	err = scanRateSeason(r.pg.Pool.QueryRow(ctx, query, args...), &response)
+	return response, err
+}
+
+// UpdateSeason changes a season of the room type. It returns pgx.ErrNoRows when the room type has no such season.
+func (r *RateRepo) UpdateSeason(ctx context.Context, req entity.RateSeason) (entity.RateSeason, error) {
+	var response entity.RateSeason
+
+	updateFields := make(map[string]interface{})
+
+	if req.Name != "" && req.Name != "string" {
+		updateFields["name"] = req.Name
+	}
+	if req.StartDate != "" && req.StartDate != "string" {
+		updateFields["start_date"] = req.StartDate
+	}
+	if req.EndDate != "" && req.EndDate != "string" {
+		updateFields["end_date"] = req.EndDate
+	}
+	if req.Price != 0 {
+		updateFields["price"] = req.Price
+	}
+	if req.MinStay != 0 {
+		updateFields["min_stay"] = req.MinStay
+	}
+
+	if len(updateFields) == 0 {
+		return response, errors.New("no fields to update")
+	}
+
+	updateFields["updated_at"] = "now()"
+
+	query, args, err := r.pg.Builder.Update("rate_seasons").SetMap(updateFields).
+		Where("id = ? AND room_type_id = ?", req.ID, req.RoomTypeID).
+		Suffix("RETURNING " + rateSeasonColumns).ToSql()
+	if err != nil {
+		return response, err
+	}
+
+	err = scanRateSeason(r.pg.Pool.QueryRow(ctx, query, args...), &response)
+	return response, err
+}
+
+// DeleteSeason returns pgx.ErrNoRows when the room type has no such season.
+func (r *RateRepo) DeleteSeason(ctx context.Context, req entity.RateSeason) error {
+	tag, err := r.pg.Pool.Exec(ctx, `DELETE FROM rate_seasons WHERE id = $1 AND room_type_id = $2`, req.ID, req.RoomTypeID)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	return nil
+}
+
+// SetDayModifiers replaces the day-of-week modifiers of a room type with the given ones.
+func (r *RateRepo) SetDayModifiers(ctx context.Context, req entity.RateDayModifierList) error {
+	tx, err := r.pg.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `DELETE FROM rate_day_modifiers WHERE room_type_id = $1`, req.RoomTypeID)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range req.Items {
+		_, err = tx.Exec(ctx, `INSERT INTO rate_day_modifiers (room_type_id, weekday, percent) VALUES ($1, $2, $3)`,
+			req.RoomTypeID, item.Weekday, item.Percent)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// SetOverride creates the override of a night or replaces the one it has.
+func (r *RateRepo) SetOverride(ctx context.Context, req entity.RateOverride) (entity.RateOverride, error) {
+	var response entity.RateOverride
+
+	err := scanRateOverride(r.pg.Pool.QueryRow(ctx, `
+		INSERT INTO rate_overrides (room_type_id, date, price, min_stay) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (room_type_id, date) DO UPDATE SET price = EXCLUDED.price, min_stay = EXCLUDED.min_stay, updated_at = now()
+		RETURNING `+rateOverrideColumns, req.RoomTypeID, req.Date, req.Price, req.MinStay), &response)
+
+	return response, err
+}
+
+// DeleteOverride returns pgx.ErrNoRows when the night has no override.
+func (r *RateRepo) DeleteOverride(ctx context.Context, req entity.RateOverride) error {
+	tag, err := r.pg.Pool.Exec(ctx, `DELETE FROM rate_overrides WHERE room_type_id = $1 AND date = $2`, req.RoomTypeID, req.Date)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,178 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Avazbek-02/Online-Hotel-System/config"
+	"github.com/Avazbek-02/Online-Hotel-System/internal/entity"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/logger"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/postgres"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+type RefundRepo struct {
+	pg     *postgres.Postgres
+	config *config.Config
+	logger *logger.Logger
+}
+
+func NewRefundRepo(pg *postgres.Postgres, config *config.Config, logger *logger.Logger) *RefundRepo {
+	return &RefundRepo{
+		pg:     pg,
+		config: config,
+		logger: logger,
+	}
+}
+
+const refundColumns = `id, payment_id, booking_id, amount, status, COALESCE(provider_ref, ''), COALESCE(reason, ''), created_at, updated_at`
+
+func scanRefund(row pgx.Row, item *entity.Refund) error {
+	var createdAt, updatedAt time.Time
+
+	err := row.Scan(&item.ID, &item.PaymentID, &item.BookingID, &item.Amount, &item.Status, &item.ProviderRef, &item.Reason, &createdAt, &updatedAt)
+	if err != nil {
+		return err
+	}
+
+	item.CreatedAt = createdAt.Format(time.RFC3339)
+	item.UpdatedAt = updatedAt.Format(time.RFC3339)
+	return nil
+}
+
+func (r *RefundRepo) Create(ctx context.Context, req entity.Refund) (entity.Refund, error) {
+	req.ID = uuid.NewString()
+	providerRef := sql.NullString{String: req.ProviderRef, Valid: req.ProviderRef != ""}
+
+	query, args, err := r.pg.Builder.Insert("refunds").
+		Columns(`id, payment_id, booking_id, amount, status, provider_ref, reason`).
+		Values(req.ID, req.PaymentID, req.BookingID, req.Amount, req.Status, providerRef, req.Reason).ToSql()
+	if err != nil {
+		return entity.Refund{}, err
+	}
+
+	_, err = r.pg.Pool.Exec(ctx, query, args...)
+	if err != nil {
+		return entity.Refund{}, err
+	}
+
+	return r.GetSingle(ctx, entity.Id{ID: req.ID})
+}
+
+func (r *RefundRepo) GetSingle(ctx context.Context, req entity.Id) (entity.Refund, error) {
+	var response entity.Refund
+
+	if req.ID == "" {
+		return entity.Refund{}, fmt.Errorf("GetSingle - invalid request")
+	}
+
+	query, args, err := r.pg.Builder.Select(refundColumns).From("refunds").Where("id = ?", req.ID).ToSql()
+	if err != nil {
+		return entity.Refund{}, err
+	}
+
+	err = scanRefund(r.pg.Pool.QueryRow(ctx, query, args...), &response)
+	if err != nil {
+		return entity.Refund{}, err
+	}
+
+	return response, nil
+}
+
+func (r *RefundRepo) GetList(ctx context.Context, req entity.GetListFilter) (entity.RefundList, error) {
+	response := entity.RefundList{}
+
+	queryBuilder := r.pg.Builder.Select(refundColumns).From("refunds")
+
+	queryBuilder, where := PrepareGetListQuery(queryBuilder, req)
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return response, err
+	}
+
+	rows, err := r.pg.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return response, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item entity.Refund
+		if err = scanRefund(rows, &item); err != nil {
+			return response, err
+		}
+
+		response.Items = append(response.Items, item)
+	}
+
+	countQuery, args, err := r.pg.Builder.Select("COUNT(1)").From("refunds").Where(where).ToSql()
+	if err != nil {
+		return response, err
+	}
+
+	err = r.pg.Pool.QueryRow(ctx, countQuery, args...).Scan(&response.Count)
+	if err != nil {
+		return response, err
+	}
+
+	return response, nil
+}
+
+// UpdateStatus settles a pending refund. Once the succeeded refunds of a payment add up to
+// its amount the payment is marked refunded. A refund that is no longer pending is left as it
+// is and ErrRefundNotPending is returned, so a retried settlement can't pay out twice.
+func (r *RefundRepo) UpdateStatus(ctx context.Context, req entity.Refund) (entity.Refund, error) {
+	updateFields := map[string]interface{}{
+		"status":     req.Status,
+		"updated_at": "now()",
+	}
+	if req.ProviderRef != "" {
+		updateFields["provider_ref"] = req.ProviderRef
+	}
+
+	tx, err := r.pg.Pool.Begin(ctx)
+	if err != nil {
+		return entity.Refund{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	query, args, err := r.pg.Builder.Update("refunds").SetMap(updateFields).
+		Where("id = ? AND status = ?", req.ID, entity.RefundStatusPending).
+		Suffix("RETURNING payment_id").ToSql()
+	if err != nil {
+		return entity.Refund{}, err
+	}
+
+	var paymentID string
+	err = tx.QueryRow(ctx, query, args...).Scan(&paymentID)
+	if err == pgx.ErrNoRows {
+		if _, err = r.GetSingle(ctx, entity.Id{ID: req.ID}); err != nil {
+			return entity.Refund{}, err
+		}
+		return entity.Refund{}, entity.ErrRefundNotPending
+	}
+	if err != nil {
+		return entity.Refund{}, err
+	}
+
+	if req.Status == entity.RefundStatusSucceeded {
+		_, err = tx.Exec(ctx, `
+			UPDATE payments p SET status = $1, updated_at = now()
+			WHERE p.id = $2 AND p.status = $3
+			  AND p.amount <= (SELECT COALESCE(SUM(f.amount), 0) FROM refunds f WHERE f.payment_id = p.id AND f.status = $4)`,
+			entity.PaymentStatusRefunded, paymentID, entity.PaymentStatusSucceeded, entity.RefundStatusSucceeded)
+		if err != nil {
+			return entity.Refund{}, err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return entity.Refund{}, err
+	}
+
+	return r.GetSingle(ctx, entity.Id{ID: req.ID})
+}
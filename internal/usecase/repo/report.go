@@ -0,0 +1,120 @@
+package repo
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/Avazbek-02/Online-Hotel-System/config"
+	"github.com/Avazbek-02/Online-Hotel-System/internal/entity"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/logger"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/postgres"
+)
+
+type ReportRepo struct {
+	pg     *postgres.Postgres
+	config *config.Config
+	logger *logger.Logger
+}
+
+func NewReportRepo(pg *postgres.Postgres, config *config.Config, logger *logger.Logger) *ReportRepo {
+	return &ReportRepo{
+		pg:     pg,
+		config: config,
+		logger: logger,
+	}
+}
+
+// Summary reports occupancy and revenue for the nights from req.From up to, not including, req.To.
+func (r *ReportRepo) Summary(ctx context.Context, req entity.ReportRequest) (entity.ReportSummary, error) {
+	response := entity.ReportSummary{
+		HotelID:          req.HotelID,
+		From:             req.From,
+		To:               req.To,
+		BookingsByStatus: map[string]int{},
+	}
+
+	from, err := time.Parse(time.DateOnly, req.From)
+	if err != nil {
+		return entity.ReportSummary{}, err
+	}
+
+	to, err := time.Parse(time.DateOnly, req.To)
+	if err != nil {
+		return entity.ReportSummary{}, err
+	}
+
+	// an empty hotel id matches every hotel
+	err = r.pg.Pool.QueryRow(ctx, `SELECT COUNT(1) FROM rooms WHERE $1 = '' OR hotel_id::text = $1`, req.HotelID).Scan(&response.Rooms)
+	if err != nil {
+		return entity.ReportSummary{}, err
+	}
+
+	// the share of each stay inside the period, priced pro rata per night
+	err = r.pg.Pool.QueryRow(ctx, `
+		WITH stays AS (
+			SELECT b.total_price,
+				b.check_out_date - b.check_in_date AS nights,
+				LEAST(b.check_out_date, $2::date) - GREATEST(b.check_in_date, $1::date) AS nights_in_period
+			FROM bookings b
+			WHERE b.check_in_date < $2::date AND b.check_out_date > $1::date AND b.status <> $3
+			  AND ($4 = '' OR b.hotel_id::text = $4)
+		)
+		SELECT COALESCE(SUM(nights_in_period), 0), COALESCE(SUM(total_price * nights_in_period / nights), 0)
+		FROM stays`, req.From, req.To, entity.BookingStatusCancelled, req.HotelID).Scan(&response.RoomNightsSold, &response.RoomRevenue)
+	if err != nil {
+		return entity.ReportSummary{}, err
+	}
+
+	rows, err := r.pg.Pool.Query(ctx, `
+		SELECT status, COUNT(1) FROM bookings
+		WHERE check_in_date < $2::date AND check_out_date > $1::date
+		  AND ($3 = '' OR hotel_id::text = $3)
+		GROUP BY status`, req.From, req.To, req.HotelID)
+	if err != nil {
+		return entity.ReportSummary{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			status string
+			count  int
+		)
+		if err = rows.Scan(&status, &count); err != nil {
+			return entity.ReportSummary{}, err
+		}
+
+		response.BookingsByStatus[status] = count
+	}
+	if err = rows.Err(); err != nil {
+		return entity.ReportSummary{}, err
+	}
+
+	err = r.pg.Pool.QueryRow(ctx, `
+		SELECT
+			COALESCE((SELECT SUM(p.amount) FROM payments p JOIN bookings b ON b.id = p.booking_id
+				WHERE p.status IN ($3, $6) AND p.payment_date >= $1::date AND p.payment_date < $2::date
+				  AND ($5 = '' OR b.hotel_id::text = $5)), 0),
+			COALESCE((SELECT SUM(f.amount) FROM refunds f JOIN bookings b ON b.id = f.booking_id
+				WHERE f.status = $4 AND f.updated_at >= $1::date AND f.updated_at < $2::date
+				  AND ($5 = '' OR b.hotel_id::text = $5)), 0)`,
+		req.From, req.To, entity.PaymentStatusSucceeded, entity.RefundStatusSucceeded, req.HotelID, entity.PaymentStatusRefunded).Scan(&response.PaymentsReceived, &response.RefundsPaid)
+	if err != nil {
+		return entity.ReportSummary{}, err
+	}
+
+	nights := int(to.Sub(from) / (24 * time.Hour))
+	response.RoomNightsAvailable = response.Rooms * nights
+	response.RoomRevenue = math.Round(response.RoomRevenue*100) / 100
+
+	if response.RoomNightsAvailable > 0 {
+		response.OccupancyRate = math.Round(float64(response.RoomNightsSold)/float64(response.RoomNightsAvailable)*10000) / 10000
+		response.RevPAR = math.Round(response.RoomRevenue/float64(response.RoomNightsAvailable)*100) / 100
+	}
+	if response.RoomNightsSold > 0 {
+		response.AverageDailyRate = math.Round(response.RoomRevenue/float64(response.RoomNightsSold)*100) / 100
+	}
+
+	return response, nil
+}
@@ -0,0 +1,295 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Avazbek-02/Online-Hotel-System/config"
+	"github.com/Avazbek-02/Online-Hotel-System/internal/entity"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/logger"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/postgres"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+type RoomImageRepo struct {
+	pg     *postgres.Postgres
+	config *config.Config
+	logger *logger.Logger
+}
+
+func NewRoomImageRepo(pg *postgres.Postgres, config *config.Config, logger *logger.Logger) *RoomImageRepo {
+	return &RoomImageRepo{
+		pg:     pg,
+		config: config,
+		logger: logger,
+	}
+}
+
+const roomImageColumns = `id, room_id, COALESCE(image_url, ''), COALESCE(thumbnail_url, ''), COALESCE(medium_url, ''),
+	COALESCE(object_name, ''), variant_objects, position, is_cover, created_at`
+
+func scanRoomImage(row pgx.Row, item *entity.RoomImage) error {
+	var createdAt time.Time
+
+	err := row.Scan(&item.ID, &item.RoomID, &item.ImageURL, &item.ThumbnailURL, &item.MediumURL,
+		&item.ObjectName, &item.VariantObjects, &item.Position, &item.IsCover, &createdAt)
+	if err != nil {
+		return err
+	}
+
+	item.CreatedAt = createdAt.Format(time.RFC3339)
+	return nil
+}
+
+// Create appends an image to the end of a room's gallery. The first image of a room becomes its cover.
+func (r *RoomImageRepo) Create(ctx context.Context, req entity.RoomImage) (entity.RoomImage, error) {
+	tx, err := r.pg.Pool.Begin(ctx)
+	if err != nil {
+		return entity.RoomImage{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err = r.lockRoom(ctx, tx, req.RoomID); err != nil {
+		return entity.RoomImage{}, err
+	}
+
+	var count int
+	err = tx.QueryRow(ctx, `SELECT COUNT(1), COALESCE(MAX(position) + 1, 0) FROM room_images WHERE room_id = $1`, req.RoomID).
+		Scan(&count, &req.Position)
+	if err != nil {
+		return entity.RoomImage{}, err
+	}
+
+	req.ID = uuid.NewString()
+	req.IsCover = count == 0
+	if req.VariantObjects == nil {
+		req.VariantObjects = []string{}
+	}
+
+	query, args, err := r.pg.Builder.Insert("room_images").
+		Columns(`id, room_id, image_url, thumbnail_url, medium_url, object_name, variant_objects, position, is_cover`).
+		Values(req.ID, req.RoomID, req.ImageURL, req.ThumbnailURL, req.MediumURL, req.ObjectName, req.VariantObjects, req.Position, req.IsCover).ToSql()
+	if err != nil {
+		return entity.RoomImage{}, err
+	}
+
+	_, err = tx.Exec(ctx, query, args...)
+	if err != nil {
+		return entity.RoomImage{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return entity.RoomImage{}, err
+	}
+
+	return r.GetSingle(ctx, entity.Id{ID: req.ID})
+}
+
+func (r *RoomImageRepo) GetSingle(ctx context.Context, req entity.Id) (entity.RoomImage, error) {
+	var response entity.RoomImage
+
+	if req.ID == "" {
+		return entity.RoomImage{}, fmt.Errorf("GetSingle - invalid request")
+	}
+
+	query, args, err := r.pg.Builder.Select(roomImageColumns).From("room_images").Where("id = ?", req.ID).ToSql()
+	if err != nil {
+		return entity.RoomImage{}, err
+	}
+
+	err = scanRoomImage(r.pg.Pool.QueryRow(ctx, query, args...), &response)
+	if err != nil {
+		return entity.RoomImage{}, err
+	}
+
+	return response, nil
+}
+
+// GetByRooms returns the galleries of the given rooms keyed by room id, each ordered by position.
+func (r *RoomImageRepo) GetByRooms(ctx context.Context, roomIDs []string) (map[string][]entity.RoomImage, error) {
+	response := make(map[string][]entity.RoomImage, len(roomIDs))
+	if len(roomIDs) == 0 {
+		return response, nil
+	}
+
+	query, args, err := r.pg.Builder.Select(roomImageColumns).From("room_images").
+		Where("room_id = ANY(?)", roomIDs).
+		OrderBy("room_id", "position ASC", "created_at ASC").ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pg.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item entity.RoomImage
+		if err = scanRoomImage(rows, &item); err != nil {
+			return nil, err
+		}
+
+		response[item.RoomID] = append(response[item.RoomID], item)
+	}
+
+	return response, rows.Err()
+}
+
+// GetByRoomTypes returns the galleries of the rooms of the given room types keyed by room type id.
+// The cover of every room comes first, then the rest of its gallery in order.
+func (r *RoomImageRepo) GetByRoomTypes(ctx context.Context, roomTypeIDs []string) (map[string][]entity.RoomImage, error) {
+	response := make(map[string][]entity.RoomImage, len(roomTypeIDs))
+	if len(roomTypeIDs) == 0 {
+		return response, nil
+	}
+
+	rows, err := r.pg.Pool.Query(ctx, `
+		SELECT rooms.room_type_id, i.id, i.room_id, COALESCE(i.image_url, ''), COALESCE(i.thumbnail_url, ''),
+			COALESCE(i.medium_url, ''), COALESCE(i.object_name, ''), i.variant_objects, i.position, i.is_cover, i.created_at
+		FROM room_images i
+		JOIN rooms ON rooms.id = i.room_id
+		WHERE rooms.room_type_id = ANY($1)
+		ORDER BY rooms.room_type_id, i.is_cover DESC, rooms.room_number, i.position, i.created_at`,
+		roomTypeIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			roomTypeID string
+			item       entity.RoomImage
+			createdAt  time.Time
+		)
+
+		err = rows.Scan(&roomTypeID, &item.ID, &item.RoomID, &item.ImageURL, &item.ThumbnailURL, &item.MediumURL,
+			&item.ObjectName, &item.VariantObjects, &item.Position, &item.IsCover, &createdAt)
+		if err != nil {
+			return nil, err
+		}
+		item.CreatedAt = createdAt.Format(time.RFC3339)
+
+		response[roomTypeID] = append(response[roomTypeID], item)
+	}
+
+	return response, rows.Err()
+}
+
+// Reorder sets the gallery order of a room. imageIDs must list every image of the room exactly once.
+func (r *RoomImageRepo) Reorder(ctx context.Context, roomID string, imageIDs []string) error {
+	tx, err := r.pg.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err = r.lockRoom(ctx, tx, roomID); err != nil {
+		return err
+	}
+
+	var count int
+	err = tx.QueryRow(ctx, `SELECT COUNT(1) FROM room_images WHERE room_id = $1`, roomID).Scan(&count)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(imageIDs))
+	for _, id := range imageIDs {
+		seen[id] = true
+	}
+	if count != len(imageIDs) || len(seen) != len(imageIDs) {
+		return entity.ErrInvalidImageOrder
+	}
+
+	for position, id := range imageIDs {
+		tag, err := tx.Exec(ctx, `UPDATE room_images SET position = $1 WHERE id = $2 AND room_id = $3`, position, id, roomID)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return entity.ErrInvalidImageOrder
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// SetCover makes an image the cover of its room, replacing the previous cover.
+func (r *RoomImageRepo) SetCover(ctx context.Context, req entity.Id) (entity.RoomImage, error) {
+	image, err := r.GetSingle(ctx, req)
+	if err != nil {
+		return entity.RoomImage{}, err
+	}
+
+	tx, err := r.pg.Pool.Begin(ctx)
+	if err != nil {
+		return entity.RoomImage{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err = r.lockRoom(ctx, tx, image.RoomID); err != nil {
+		return entity.RoomImage{}, err
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE room_images SET is_cover = false WHERE room_id = $1 AND is_cover`, image.RoomID)
+	if err != nil {
+		return entity.RoomImage{}, err
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE room_images SET is_cover = true WHERE id = $1`, image.ID)
+	if err != nil {
+		return entity.RoomImage{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return entity.RoomImage{}, err
+	}
+
+	return r.GetSingle(ctx, req)
+}
+
+// Delete removes an image from the gallery and returns it so the caller can remove the object.
+// If it was the cover, the first remaining image takes its place.
+func (r *RoomImageRepo) Delete(ctx context.Context, req entity.Id) (entity.RoomImage, error) {
+	image, err := r.GetSingle(ctx, req)
+	if err != nil {
+		return entity.RoomImage{}, err
+	}
+
+	tx, err := r.pg.Pool.Begin(ctx)
+	if err != nil {
+		return entity.RoomImage{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err = r.lockRoom(ctx, tx, image.RoomID); err != nil {
+		return entity.RoomImage{}, err
+	}
+
+	_, err = tx.Exec(ctx, `DELETE FROM room_images WHERE id = $1`, image.ID)
+	if err != nil {
+		return entity.RoomImage{}, err
+	}
+
+	if image.IsCover {
+		_, err = tx.Exec(ctx, `
+			UPDATE room_images SET is_cover = true
+			WHERE id = (SELECT id FROM room_images WHERE room_id = $1 ORDER BY position, created_at LIMIT 1)`, image.RoomID)
+		if err != nil {
+			return entity.RoomImage{}, err
+		}
+	}
+
+	return image, tx.Commit(ctx)
+}
+
+// lockRoom serializes gallery changes of a room so positions and the cover stay consistent.
+func (r *RoomImageRepo) lockRoom(ctx context.Context, tx pgx.Tx, roomID string) error {
+	var id string
+	return tx.QueryRow(ctx, "SELECT id FROM rooms WHERE id = $1 FOR UPDATE", roomID).Scan(&id)
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/Avazbek-02/Online-Hotel-System/pkg/logger"
 	"github.com/Avazbek-02/Online-Hotel-System/pkg/postgres"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
 )
 
 // RoomReviewRepo - room_reviews uchun repository
@@ -29,23 +30,41 @@ func NewRoomReviewRepo(pg *postgres.Postgres, config *config.Config, logger *log
 	}
 }
 
-// Create - yangi room review qo'shadi
+// Create - yangi room review qo'shadi va xona reytingini qayta hisoblaydi
 func (r *RoomReviewRepo) Create(ctx context.Context, req entity.RoomReview) (entity.RoomReview, error) {
 	req.ID = uuid.NewString()
 	query, args, err := r.pg.Builder.Insert("room_reviews").
-		Columns("id", "user_id", "room_id", "rating", "comment").
-		Values(req.ID, req.UserID, req.RoomID, req.Rating, req.Comment).
+		Columns("id", "user_id", "room_id", "booking_id", "rating", "comment").
+		Values(req.ID, req.UserID, req.RoomID, req.BookingID, req.Rating, req.Comment).
 		ToSql()
 	if err != nil {
 		return entity.RoomReview{}, err
 	}
 
-	_, err = r.pg.Pool.Exec(ctx, query, args...)
+	tx, err := r.pg.Pool.Begin(ctx)
 	if err != nil {
 		return entity.RoomReview{}, err
 	}
+	defer tx.Rollback(ctx)
 
-	return req, nil
+	if err = r.lockRoom(ctx, tx, req.RoomID); err != nil {
+		return entity.RoomReview{}, err
+	}
+
+	_, err = tx.Exec(ctx, query, args...)
+	if err != nil {
+		return entity.RoomReview{}, err
+	}
+
+	if err = r.refreshRoomRating(ctx, tx, req.RoomID); err != nil {
+		return entity.RoomReview{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return entity.RoomReview{}, err
+	}
+
+	return r.GetSingle(ctx, entity.Id{ID: req.ID})
 }
 
 // GetSingle - bitta room review ni ID bo'yicha qaytaradi
@@ -54,7 +73,7 @@ func (r *RoomReviewRepo) GetSingle(ctx context.Context, req entity.Id) (entity.R
 	var createdAt, updatedAt time.Time
 
 	queryBuilder := r.pg.Builder.
-		Select("id, user_id, room_id, rating, comment, created_at, updated_at").
+		Select("id, user_id, room_id, COALESCE(booking_id::text, ''), rating, comment, created_at, updated_at").
 		From("room_reviews")
 
 	if req.ID == "" {
@@ -69,7 +88,7 @@ func (r *RoomReviewRepo) GetSingle(ctx context.Context, req entity.Id) (entity.R
 	}
 
 	err = r.pg.Pool.QueryRow(ctx, query, args...).
-		Scan(&response.ID, &response.UserID, &response.RoomID, &response.Rating, &response.Comment, &createdAt, &updatedAt)
+		Scan(&response.ID, &response.UserID, &response.RoomID, &response.BookingID, &response.Rating, &response.Comment, &createdAt, &updatedAt)
 	if err != nil {
 		return entity.RoomReview{}, err
 	}
@@ -87,7 +106,7 @@ func (r *RoomReviewRepo) GetList(ctx context.Context, req entity.GetListFilter)
 	)
 
 	queryBuilder := r.pg.Builder.
-		Select("id, user_id, room_id, rating, comment, created_at, updated_at").
+		Select("id, user_id, room_id, COALESCE(booking_id::text, ''), rating, comment, created_at, updated_at").
 		From("room_reviews")
 
 	// PrepareGetListQuery funksiyasi mavjud bo'lsa, undan foydalaning.
@@ -106,7 +125,7 @@ func (r *RoomReviewRepo) GetList(ctx context.Context, req entity.GetListFilter)
 
 	for rows.Next() {
 		var item entity.RoomReview
-		err = rows.Scan(&item.ID, &item.UserID, &item.RoomID, &item.Rating, &item.Comment, &createdAt, &updatedAt)
+		err = rows.Scan(&item.ID, &item.UserID, &item.RoomID, &item.BookingID, &item.Rating, &item.Comment, &createdAt, &updatedAt)
 		if err != nil {
 			return response, err
 		}
@@ -131,7 +150,7 @@ func (r *RoomReviewRepo) GetList(ctx context.Context, req entity.GetListFilter)
 	return response, nil
 }
 
-// Update - mavjud room review ni yangilaydi
+// Update - mavjud room review ni yangilaydi va xona reytingini qayta hisoblaydi
 func (r *RoomReviewRepo) Update(ctx context.Context, req entity.RoomReview) (entity.RoomReview, error) {
 	updateFields := make(map[string]interface{})
 
@@ -142,32 +161,132 @@ func (r *RoomReviewRepo) Update(ctx context.Context, req entity.RoomReview) (ent
 		updateFields["comment"] = req.Comment
 	}
 
-	updateFields["updated_at"] = "now()"
-
 	if len(updateFields) == 0 {
 		return entity.RoomReview{}, errors.New("no fields to update")
 	}
 
+	updateFields["updated_at"] = "now()"
+
 	query, args, err := r.pg.Builder.Update("room_reviews").SetMap(updateFields).Where("id = ?", req.ID).ToSql()
 	if err != nil {
 		return entity.RoomReview{}, err
 	}
 
-	_, err = r.pg.Pool.Exec(ctx, query, args...)
+	tx, err := r.pg.Pool.Begin(ctx)
+	if err != nil {
+		return entity.RoomReview{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	roomID, err := r.reviewRoomID(ctx, tx, req.ID)
+	if err != nil {
+		return entity.RoomReview{}, err
+	}
+
+	if err = r.lockRoom(ctx, tx, roomID); err != nil {
+		return entity.RoomReview{}, err
+	}
+
+	_, err = tx.Exec(ctx, query, args...)
 	if err != nil {
 		return entity.RoomReview{}, err
 	}
 
+	if err = r.refreshRoomRating(ctx, tx, roomID); err != nil {
+		return entity.RoomReview{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return entity.RoomReview{}, err
+	}
+
 	return r.GetSingle(ctx, entity.Id{ID: req.ID})
 }
 
-// Delete - room review ni ID bo'yicha o'chiradi
+// Delete - room review ni ID bo'yicha o'chiradi va xona reytingini qayta hisoblaydi
 func (r *RoomReviewRepo) Delete(ctx context.Context, req entity.Id) error {
 	query, args, err := r.pg.Builder.Delete("room_reviews").Where("id = ?", req.ID).ToSql()
 	if err != nil {
 		return err
 	}
 
-	_, err = r.pg.Pool.Exec(ctx, query, args...)
+	tx, err := r.pg.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	roomID, err := r.reviewRoomID(ctx, tx, req.ID)
+	if err != nil {
+		return err
+	}
+
+	if err = r.lockRoom(ctx, tx, roomID); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	if err = r.refreshRoomRating(ctx, tx, roomID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetRatingHistogram - xona uchun har bir yulduz (1-5) bo'yicha review sonini qaytaradi
+func (r *RoomReviewRepo) GetRatingHistogram(ctx context.Context, req entity.Id) (map[int]int, error) {
+	response := map[int]int{1: 0, 2: 0, 3: 0, 4: 0, 5: 0}
+
+	query, args, err := r.pg.Builder.
+		Select("ROUND(rating)::int AS star, COUNT(1)").
+		From("room_reviews").
+		Where("room_id = ?", req.ID).
+		GroupBy("star").ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pg.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var star, count int
+		if err = rows.Scan(&star, &count); err != nil {
+			return nil, err
+		}
+		response[star] = count
+	}
+
+	return response, rows.Err()
+}
+
+// reviewRoomID - review qaysi xonaga tegishli ekanini qaytaradi
+func (r *RoomReviewRepo) reviewRoomID(ctx context.Context, tx pgx.Tx, reviewID string) (string, error) {
+	var roomID string
+	err := tx.QueryRow(ctx, "SELECT room_id FROM room_reviews WHERE id = $1", reviewID).Scan(&roomID)
+	return roomID, err
+}
+
+// lockRoom - xona qatorini bloklaydi, shunda bir vaqtdagi review lar reytingni eskirgan qiymat bilan yozib yubormaydi
+func (r *RoomReviewRepo) lockRoom(ctx context.Context, tx pgx.Tx, roomID string) error {
+	var id string
+	return tx.QueryRow(ctx, "SELECT id FROM rooms WHERE id = $1 FOR UPDATE", roomID).Scan(&id)
+}
+
+// refreshRoomRating - rooms.rating va rooms.review_count ni room_reviews dan qayta hisoblaydi
+func (r *RoomReviewRepo) refreshRoomRating(ctx context.Context, tx pgx.Tx, roomID string) error {
+	_, err := tx.Exec(ctx, `
+		UPDATE rooms SET
+			rating = COALESCE((SELECT AVG(rating) FROM room_reviews WHERE room_id = $1), 0),
+			review_count = (SELECT COUNT(1) FROM room_reviews WHERE room_id = $1),
+			updated_at = now()
+		WHERE id = $1`, roomID)
 	return err
 }
@@ -0,0 +1,336 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Avazbek-02/Online-Hotel-System/config"
+	"github.com/Avazbek-02/Online-Hotel-System/internal/entity"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/logger"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/postgres"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+type RoomTypeRepo struct {
+	pg     *postgres.Postgres
+	config *config.Config
+	logger *logger.Logger
+}
+
+func NewRoomTypeRepo(pg *postgres.Postgres, config *config.Config, logger *logger.Logger) *RoomTypeRepo {
+	return &RoomTypeRepo{
+		pg:     pg,
+		config: config,
+		logger: logger,
+	}
+}
+
+const roomTypeColumns = `id, hotel_id, name, type, category, capacity, bed_configuration, size, amenities, base_price, min_stay, description, created_at, updated_at`
+
+// freeRoomsQuery counts the rooms of a type left on the busiest night of a stay. %[1]s is the
+// room type id, the placeholders are the check-in date, the check-out date and a booking to
+// leave out, e.g. the one being changed.
+const freeRoomsQuery = `((
+	SELECT COUNT(1) FROM rooms r WHERE r.room_type_id = %[1]s AND r.status <> 'maintenance'
+) - (
+	SELECT COALESCE(MAX(taken), 0) FROM (
+		SELECT COUNT(b.id) AS taken
+		FROM generate_series(?::date, ?::date - 1, interval '1 day') AS night
+		LEFT JOIN bookings b ON b.room_type_id = %[1]s AND b.status <> 'cancelled' AND b.id::text <> ?
+			AND night >= b.check_in_date AND night < b.check_out_date
+		GROUP BY night
+	) nights
+))`
+
+func freeRooms(roomTypeID, checkInDate, checkOutDate, excludeBookingID string) squirrel.Sqlizer {
+	return squirrel.Expr(fmt.Sprintf(freeRoomsQuery, roomTypeID), checkInDate, checkOutDate, excludeBookingID)
+}
+
+// stayPriceQuery prices a stay in a room type from its rate calendar the way a quote does: an
+// override fixes the price of its night, otherwise the season starting last, or the base price,
+// is adjusted by the day-of-week modifier, and every night is rounded to cents. %[1]s is the
+// room type, the placeholders are the check-in and check-out dates.
+const stayPriceQuery = `(
+	SELECT COALESCE(SUM(ROUND(CASE
+		WHEN o.price > 0 THEN o.price
+		ELSE COALESCE(s.price, %[1]s.base_price) * (1 + COALESCE(m.percent, 0) / 100)
+	END, 2)), 0)
+	FROM generate_series(?::date, ?::date - 1, interval '1 day') AS night
+	LEFT JOIN rate_overrides o ON o.room_type_id = %[1]s.id AND o.date = night::date
+	LEFT JOIN rate_day_modifiers m ON m.room_type_id = %[1]s.id AND m.weekday = EXTRACT(DOW FROM night)
+	LEFT JOIN LATERAL (
+		SELECT price FROM rate_seasons
+		WHERE room_type_id = %[1]s.id AND night::date BETWEEN start_date AND end_date
+		ORDER BY start_date DESC, end_date LIMIT 1
+	) s ON true
+)`
+
+// arrivalMinStayQuery is the minimum stay for arriving on a date: that of its override, else that of
+// the season starting last, else that of the room type. %[1]s is the room type, the placeholders
+// are the arrival date twice.
+const arrivalMinStayQuery = `COALESCE(
+	(SELECT NULLIF(min_stay, 0) FROM rate_overrides WHERE room_type_id = %[1]s.id AND date = ?::date),
+	(SELECT NULLIF(min_stay, 0) FROM rate_seasons WHERE room_type_id = %[1]s.id AND ?::date BETWEEN start_date AND end_date
+		ORDER BY start_date DESC, end_date LIMIT 1),
+	%[1]s.min_stay
+)`
+
+func scanRoomType(row pgx.Row, item *entity.RoomType, extra ...interface{}) error {
+	var createdAt, updatedAt time.Time
+
+	dest := []interface{}{&item.ID, &item.HotelID, &item.Name, &item.Type, &item.Category, &item.Capacity, &item.BedConfiguration,
+		&item.Size, &item.Amenities, &item.BasePrice, &item.MinStay, &item.Description, &createdAt, &updatedAt}
+
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return err
+	}
+
+	item.CreatedAt = createdAt.Format(time.RFC3339)
+	item.UpdatedAt = updatedAt.Format(time.RFC3339)
+	return nil
+}
+
+func (r *RoomTypeRepo) Create(ctx context.Context, req entity.RoomType) (entity.RoomType, error) {
+	req.ID = uuid.NewString()
+	if req.Amenities == nil {
+		req.Amenities = []string{}
+	}
+	if req.MinStay == 0 {
+		req.MinStay = 1
+	}
+
+	query, args, err := r.pg.Builder.Insert("room_types").
+		Columns(`id, hotel_id, name, type, category, capacity, bed_configuration, size, amenities, base_price, min_stay, description`).
+		Values(req.ID, req.HotelID, req.Name, req.Type, req.Category, req.Capacity, req.BedConfiguration, req.Size, req.Amenities, req.BasePrice, req.MinStay, req.Description).ToSql()
+	if err != nil {
+		return entity.RoomType{}, err
+	}
+
+	_, err = r.pg.Pool.Exec(ctx, query, args...)
+	if err != nil {
+		return entity.RoomType{}, err
+	}
+
+	return r.GetSingle(ctx, entity.Id{ID: req.ID})
+}
+
+func (r *RoomTypeRepo) GetSingle(ctx context.Context, req entity.Id) (entity.RoomType, error) {
+	var response entity.RoomType
+
+	if req.ID == "" {
+		return entity.RoomType{}, fmt.Errorf("GetSingle - invalid request")
+	}
+
+	query, args, err := r.pg.Builder.Select(roomTypeColumns).From("room_types").Where("id = ?", req.ID).ToSql()
+	if err != nil {
+		return entity.RoomType{}, err
+	}
+
+	err = scanRoomType(r.pg.Pool.QueryRow(ctx, query, args...), &response)
+	if err != nil {
+		return entity.RoomType{}, err
+	}
+
+	return response, nil
+}
+
+func (r *RoomTypeRepo) GetList(ctx context.Context, req entity.GetListFilter) (entity.RoomTypeList, error) {
+	response := entity.RoomTypeList{}
+
+	queryBuilder := r.pg.Builder.Select(roomTypeColumns).From("room_types")
+
+	queryBuilder, where := PrepareGetListQuery(queryBuilder, req)
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return response, err
+	}
+
+	rows, err := r.pg.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return response, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item entity.RoomType
+		if err = scanRoomType(rows, &item); err != nil {
+			return response, err
+		}
+
+		response.Items = append(response.Items, item)
+	}
+
+	countQuery, args, err := r.pg.Builder.Select("COUNT(1)").From("room_types").Where(where).ToSql()
+	if err != nil {
+		return response, err
+	}
+
+	err = r.pg.Pool.QueryRow(ctx, countQuery, args...).Scan(&response.Count)
+	if err != nil {
+		return response, err
+	}
+
+	return response, nil
+}
+
+// Update changes a room type. The hotel stays the same, its rooms and bookings belong to it.
+func (r *RoomTypeRepo) Update(ctx context.Context, req entity.RoomType) (entity.RoomType, error) {
+	updateFields := make(map[string]interface{})
+
+	if req.Name != "" && req.Name != "string" {
+		updateFields["name"] = req.Name
+	}
+	if req.Type != "" && req.Type != "string" {
+		updateFields["type"] = req.Type
+	}
+	if req.Category != "" && req.Category != "string" {
+		updateFields["category"] = req.Category
+	}
+	if req.Capacity != 0 {
+		updateFields["capacity"] = req.Capacity
+	}
+	if req.BedConfiguration != "" && req.BedConfiguration != "string" {
+		updateFields["bed_configuration"] = req.BedConfiguration
+	}
+	if req.Size != 0 {
+		updateFields["size"] = req.Size
+	}
+	if req.Amenities != nil {
+		updateFields["amenities"] = req.Amenities
+	}
+	if req.BasePrice != 0 {
+		updateFields["base_price"] = req.BasePrice
+	}
+	if req.MinStay != 0 {
+		updateFields["min_stay"] = req.MinStay
+	}
+	if req.Description != "" && req.Description != "string" {
+		updateFields["description"] = req.Description
+	}
+
+	if len(updateFields) == 0 {
+		return entity.RoomType{}, errors.New("no fields to update")
+	}
+
+	updateFields["updated_at"] = "now()"
+
+	query, args, err := r.pg.Builder.Update("room_types").SetMap(updateFields).Where("id = ?", req.ID).ToSql()
+	if err != nil {
+		return entity.RoomType{}, err
+	}
+
+	_, err = r.pg.Pool.Exec(ctx, query, args...)
+	if err != nil {
+		return entity.RoomType{}, err
+	}
+
+	return r.GetSingle(ctx, entity.Id{ID: req.ID})
+}
+
+func (r *RoomTypeRepo) Delete(ctx context.Context, req entity.Id) error {
+	query, args, err := r.pg.Builder.Delete("room_types").Where("id = ?", req.ID).ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.pg.Pool.Exec(ctx, query, args...)
+	return err
+}
+
+// Search returns room types with at least one room free on every night of the [check_in, check_out)
+// interval that match the guest count, category and type, and that can be booked for that long.
+// The price range bounds the average nightly price of the stay, which results are sorted by.
+func (r *RoomTypeRepo) Search(ctx context.Context, req entity.RoomSearchRequest) (entity.RoomTypeList, error) {
+	response := entity.RoomTypeList{}
+
+	checkIn, err := time.Parse(time.DateOnly, req.CheckInDate)
+	if err != nil {
+		return response, err
+	}
+	checkOut, err := time.Parse(time.DateOnly, req.CheckOutDate)
+	if err != nil {
+		return response, err
+	}
+	nights := int(checkOut.Sub(checkIn).Hours() / 24)
+
+	where := squirrel.And{}
+	if req.HotelID != "" {
+		where = append(where, squirrel.Eq{"hotel_id": req.HotelID})
+	}
+	if req.Guests > 0 {
+		where = append(where, squirrel.GtOrEq{"capacity": req.Guests})
+	}
+	if req.Category != "" {
+		where = append(where, squirrel.Eq{"category": req.Category})
+	}
+	if req.Type != "" {
+		where = append(where, squirrel.Eq{"type": req.Type})
+	}
+
+	bookable := squirrel.And{squirrel.Expr("available > 0"), squirrel.LtOrEq{"arrival_min_stay": nights}}
+	if req.MinPrice > 0 {
+		bookable = append(bookable, squirrel.GtOrEq{"total_price": req.MinPrice * float64(nights)})
+	}
+	if req.MaxPrice > 0 {
+		bookable = append(bookable, squirrel.LtOrEq{"total_price": req.MaxPrice * float64(nights)})
+	}
+
+	if req.Limit <= 0 {
+		req.Limit = 10
+	}
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+
+	matching := r.pg.Builder.
+		Select(roomTypeColumns).
+		Column(squirrel.Alias(freeRooms("room_types.id", req.CheckInDate, req.CheckOutDate, ""), "available")).
+		Column(squirrel.Alias(squirrel.Expr(fmt.Sprintf(stayPriceQuery, "room_types"), req.CheckInDate, req.CheckOutDate), "total_price")).
+		Column(squirrel.Alias(squirrel.Expr(fmt.Sprintf(arrivalMinStayQuery, "room_types"), req.CheckInDate, req.CheckInDate), "arrival_min_stay")).
+		From("room_types").
+		Where(where)
+
+	query, args, err := r.pg.Builder.
+		Select(roomTypeColumns, "available", "total_price").
+		FromSelect(matching, "t").
+		Where(bookable).
+		OrderBy("total_price asc", "id").
+		Limit(uint64(req.Limit)).
+		Offset(uint64((req.Page - 1) * req.Limit)).ToSql()
+	if err != nil {
+		return response, err
+	}
+
+	rows, err := r.pg.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return response, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item entity.RoomType
+		if err = scanRoomType(rows, &item, &item.Available, &item.TotalPrice); err != nil {
+			return response, err
+		}
+
+		response.Items = append(response.Items, item)
+	}
+
+	countQuery, args, err := r.pg.Builder.Select("COUNT(1)").FromSelect(matching, "t").Where(bookable).ToSql()
+	if err != nil {
+		return response, err
+	}
+
+	err = r.pg.Pool.QueryRow(ctx, countQuery, args...).Scan(&response.Count)
+	if err != nil {
+		return response, err
+	}
+
+	return response, nil
+}
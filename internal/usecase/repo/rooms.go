@@ -10,7 +10,9 @@ import (
 	"github.com/Avazbek-02/Online-Hotel-System/internal/entity"
 	"github.com/Avazbek-02/Online-Hotel-System/pkg/logger"
 	"github.com/Avazbek-02/Online-Hotel-System/pkg/postgres"
+	"github.com/Masterminds/squirrel"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
 )
 
 type RoomsRepo struct {
@@ -27,11 +29,16 @@ func NewRoomsRepo(pg *postgres.Postgres, config *config.Config, logger *logger.L
 	}
 }
 
+// roomTypeHotelID keeps the hotel_id of a room or booking in step with its room type.
+func roomTypeHotelID(roomTypeID string) squirrel.Sqlizer {
+	return squirrel.Expr("(SELECT hotel_id FROM room_types WHERE id = ?)", roomTypeID)
+}
+
 func (r *RoomsRepo) Create(ctx context.Context, req entity.Room) (entity.Room, error) {
 	req.ID = uuid.NewString()
 	query, args, err := r.pg.Builder.Insert("rooms").
-		Columns(`id, type, category, status, price, availability, rating`).
-		Values(req.ID, req.Type, req.Category, req.Status, req.Price, req.Availability, req.Rating).ToSql()
+		Columns(`id, hotel_id, room_type_id, room_number, floor, status, availability`).
+		Values(req.ID, roomTypeHotelID(req.RoomTypeID), req.RoomTypeID, req.RoomNumber, req.Floor, req.Status, req.Availability).ToSql()
 	if err != nil {
 		return entity.Room{}, err
 	}
@@ -41,7 +48,7 @@ func (r *RoomsRepo) Create(ctx context.Context, req entity.Room) (entity.Room, e
 		return entity.Room{}, err
 	}
 
-	return req, nil
+	return r.GetSingle(ctx, entity.Id{ID: req.ID})
 }
 
 func (r *RoomsRepo) GetSingle(ctx context.Context, req entity.Id) (entity.Room, error) {
@@ -49,7 +56,7 @@ func (r *RoomsRepo) GetSingle(ctx context.Context, req entity.Id) (entity.Room,
 	var createdAt, updatedAt time.Time
 
 	queryBuilder := r.pg.Builder.
-		Select(`id, type, category, status, price, availability, rating, created_at, updated_at`).
+		Select(`id, hotel_id, room_type_id, room_number, floor, status, availability, rating, review_count, created_at, updated_at`).
 		From("rooms")
 
 	switch {
@@ -65,7 +72,7 @@ func (r *RoomsRepo) GetSingle(ctx context.Context, req entity.Id) (entity.Room,
 	}
 
 	err = r.pg.Pool.QueryRow(ctx, query, args...).
-		Scan(&response.ID, &response.Type, &response.Category, &response.Status, &response.Price, &response.Availability, &response.Rating, &createdAt, &updatedAt)
+		Scan(&response.ID, &response.HotelID, &response.RoomTypeID, &response.RoomNumber, &response.Floor, &response.Status, &response.Availability, &response.Rating, &response.ReviewCount, &createdAt, &updatedAt)
 	if err != nil {
 		return entity.Room{}, err
 	}
@@ -82,7 +89,7 @@ func (r *RoomsRepo) GetList(ctx context.Context, req entity.GetListFilter) (enti
 	)
 
 	queryBuilder := r.pg.Builder.
-		Select("id, type, category, status, price, availability, rating, created_at, updated_at").
+		Select("id, hotel_id, room_type_id, room_number, floor, status, availability, rating, review_count, created_at, updated_at").
 		From("rooms")
 
 	queryBuilder, where := PrepareGetListQuery(queryBuilder, req)
@@ -100,7 +107,7 @@ func (r *RoomsRepo) GetList(ctx context.Context, req entity.GetListFilter) (enti
 
 	for rows.Next() {
 		var item entity.Room
-		err = rows.Scan(&item.ID, &item.Type, &item.Category, &item.Status, &item.Price, &item.Availability, &item.Rating, &createdAt, &updatedAt)
+		err = rows.Scan(&item.ID, &item.HotelID, &item.RoomTypeID, &item.RoomNumber, &item.Floor, &item.Status, &item.Availability, &item.Rating, &item.ReviewCount, &createdAt, &updatedAt)
 		if err != nil {
 			return response, err
 		}
@@ -111,7 +118,7 @@ func (r *RoomsRepo) GetList(ctx context.Context, req entity.GetListFilter) (enti
 		response.Items = append(response.Items, item)
 	}
 
-	countQuery, args, err := r.pg.Builder.Select("COUNT(1)").From("users").Where(where).ToSql()
+	countQuery, args, err := r.pg.Builder.Select("COUNT(1)").From("rooms").Where(where).ToSql()
 	if err != nil {
 		return response, err
 	}
@@ -124,21 +131,24 @@ func (r *RoomsRepo) GetList(ctx context.Context, req entity.GetListFilter) (enti
 	return response, nil
 }
 
+// Update changes a room. Moving it to another type, or into maintenance, takes it away from
+// its type, which is refused with ErrRoomTypeOversold when the type's bookings still need it,
+// and a room that holds a stay can't change type at all.
 func (r *RoomsRepo) Update(ctx context.Context, req entity.Room) (entity.Room, error) {
 	updateFields := make(map[string]interface{})
 
-	if req.Type != "" && req.Type != "string" {
-		updateFields["type"] = req.Type
+	if req.RoomTypeID != "" && req.RoomTypeID != "string" {
+		updateFields["room_type_id"] = req.RoomTypeID
 	}
-	if req.Category != "" && req.Category != "string" {
-		updateFields["category"] = req.Category
+	if req.RoomNumber != "" && req.RoomNumber != "string" {
+		updateFields["room_number"] = req.RoomNumber
+	}
+	if req.Floor != 0 {
+		updateFields["floor"] = req.Floor
 	}
 	if req.Status != "" && req.Status != "string" {
 		updateFields["status"] = req.Status
 	}
-	if req.Price != 0{
-		updateFields["price"] = req.Price
-	}
 	if req.Availability{
 		updateFields["availability"] = req.Availability
 	}
@@ -149,25 +159,137 @@ func (r *RoomsRepo) Update(ctx context.Context, req entity.Room) (entity.Room, e
 		return entity.Room{}, errors.New("no fields to update")
 	}
 
+	tx, err := r.pg.Pool.Begin(ctx)
+	if err != nil {
+		return entity.Room{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	current, err := r.lockRoom(ctx, tx, req.ID, req.RoomTypeID)
+	if err != nil {
+		return entity.Room{}, err
+	}
+
+	typeChanged := updateFields["room_type_id"] != nil && req.RoomTypeID != current.RoomTypeID
+	if typeChanged {
+		var holdsStay bool
+		err = tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM bookings WHERE room_id = $1 AND status NOT IN ($2, $3))`,
+			req.ID, entity.BookingStatusCancelled, entity.BookingStatusCompleted).Scan(&holdsStay)
+		if err != nil {
+			return entity.Room{}, err
+		}
+		if holdsStay {
+			return entity.Room{}, entity.ErrRoomOccupied
+		}
+	}
+
 	query, args, err := r.pg.Builder.Update("rooms").SetMap(updateFields).Where("id = ?", req.ID).ToSql()
 	if err != nil {
 		return entity.Room{}, err
 	}
 
-	_, err = r.pg.Pool.Exec(ctx, query, args...)
+	_, err = tx.Exec(ctx, query, args...)
 	if err != nil {
 		return entity.Room{}, err
 	}
 
+	toMaintenance := req.Status == entity.RoomStatusMaintenance && current.Status != entity.RoomStatusMaintenance
+	if typeChanged || toMaintenance {
+		if err = r.checkNotOversold(ctx, tx, current.RoomTypeID); err != nil {
+			return entity.Room{}, err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return entity.Room{}, err
+	}
+
 	return r.GetSingle(ctx, entity.Id{ID: req.ID})
 }
 
+// Delete removes a room, refused with ErrRoomTypeOversold when its type's bookings still need it.
 func (r *RoomsRepo) Delete(ctx context.Context, req entity.Id) error {
+	tx, err := r.pg.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	current, err := r.lockRoom(ctx, tx, req.ID, "")
+	if err != nil {
+		return err
+	}
+
 	query, args, err := r.pg.Builder.Delete("rooms").Where("id = ?", req.ID).ToSql()
 	if err != nil {
 		return err
 	}
 
-	_, err = r.pg.Pool.Exec(ctx, query, args...)
-	return err
+	_, err = tx.Exec(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	if current.Status != entity.RoomStatusMaintenance {
+		if err = r.checkNotOversold(ctx, tx, current.RoomTypeID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// lockRoom locks a room and its room type, and the type it moves to if any, like a booking
+// locks the type it is made against. The types are locked in a fixed order so that two
+// changes can't deadlock. It returns the room's type and status before the change.
+func (r *RoomsRepo) lockRoom(ctx context.Context, tx pgx.Tx, roomID, newRoomTypeID string) (entity.Room, error) {
+	var room entity.Room
+	err := tx.QueryRow(ctx, `SELECT room_type_id, status FROM rooms WHERE id = $1`, roomID).Scan(&room.RoomTypeID, &room.Status)
+	if err != nil {
+		return entity.Room{}, err
+	}
+
+	typeIDs := []string{room.RoomTypeID}
+	if newRoomTypeID != "" && newRoomTypeID != "string" && newRoomTypeID != room.RoomTypeID {
+		typeIDs = append(typeIDs, newRoomTypeID)
+	}
+
+	_, err = tx.Exec(ctx, `SELECT id FROM room_types WHERE id = ANY($1::uuid[]) ORDER BY id FOR UPDATE`, typeIDs)
+	if err != nil {
+		return entity.Room{}, err
+	}
+
+	// read again under the locks, a check-in may have changed it meanwhile
+	err = tx.QueryRow(ctx, `SELECT room_type_id, status FROM rooms WHERE id = $1 FOR UPDATE`, roomID).Scan(&room.RoomTypeID, &room.Status)
+	if err != nil {
+		return entity.Room{}, err
+	}
+
+	return room, nil
+}
+
+// checkNotOversold returns ErrRoomTypeOversold when a room type has fewer rooms out of
+// maintenance than it has bookings on some night from yesterday on, which covers today
+// in every time zone.
+func (r *RoomsRepo) checkNotOversold(ctx context.Context, tx pgx.Tx, roomTypeID string) error {
+	var rooms, booked int
+	err := tx.QueryRow(ctx, `
+		SELECT
+			(SELECT COUNT(1) FROM rooms WHERE room_type_id = $1 AND status <> $2),
+			(SELECT COALESCE(MAX(taken), 0) FROM (
+				SELECT COUNT(1) AS taken
+				FROM bookings b, generate_series(GREATEST(b.check_in_date, CURRENT_DATE - 1), b.check_out_date - 1, interval '1 day') AS night
+				WHERE b.room_type_id = $1 AND b.status NOT IN ($3, $4)
+				GROUP BY night
+			) nights)`,
+		roomTypeID, entity.RoomStatusMaintenance, entity.BookingStatusCancelled, entity.BookingStatusCompleted).Scan(&rooms, &booked)
+	if err != nil {
+		return err
+	}
+
+	if rooms < booked {
+		return entity.ErrRoomTypeOversold
+	}
+
+	return nil
 }
@@ -11,7 +11,7 @@ import (
 	"github.com/Avazbek-02/Online-Hotel-System/pkg/logger"
 	"github.com/Avazbek-02/Online-Hotel-System/pkg/postgres"
 	"github.com/google/uuid"
-
+	"github.com/jackc/pgx/v4"
 )
 
 type SessionRepo struct {
@@ -39,8 +39,8 @@ func (r *SessionRepo) Create(ctx context.Context, req entity.Session) (entity.Se
 	}
 
 	qeury, args, err := r.pg.Builder.Insert("sessions").
-		Columns(`id, user_id, ip_address, user_agent, is_active, expires_at, platform`).
-		Values(req.ID, req.UserID, req.IPAddress, req.UserAgent, req.IsActive, expireDate, req.Platform).ToSql()
+		Columns(`id, user_id, ip_address, user_agent, is_active, expires_at, platform, two_factor_verified`).
+		Values(req.ID, req.UserID, req.IPAddress, req.UserAgent, req.IsActive, expireDate, req.Platform, req.TwoFactorVerified).ToSql()
 	if err != nil {
 		return entity.Session{}, err
 	}
@@ -60,7 +60,7 @@ func (r *SessionRepo) GetSingle(ctx context.Context, req entity.Id) (entity.Sess
 		expiresAt, lastActiveAt sql.NullTime
 	)
 	qeuryBuilder := r.pg.Builder.
-		Select(`id, user_id, ip_address, user_agent, is_active, expires_at, last_active_at, platform, created_at, updated_at`).
+		Select(`id, user_id, ip_address, user_agent, is_active, expires_at, last_active_at, platform, two_factor_verified, created_at, updated_at`).
 		From("sessions").Where("id = ?", req.ID)
 
 	qeury, args, err := qeuryBuilder.ToSql()
@@ -70,7 +70,7 @@ func (r *SessionRepo) GetSingle(ctx context.Context, req entity.Id) (entity.Sess
 
 	err = r.pg.Pool.QueryRow(ctx, qeury, args...).
 		Scan(&response.ID, &response.UserID, &response.IPAddress, &response.UserAgent,
-			&response.IsActive, &expiresAt, &lastActiveAt, &response.Platform, &createdAt, &updatedAt)
+			&response.IsActive, &expiresAt, &lastActiveAt, &response.Platform, &response.TwoFactorVerified, &createdAt, &updatedAt)
 	if err != nil {
 		return entity.Session{}, err
 	}
@@ -94,7 +94,7 @@ func (r *SessionRepo) GetList(ctx context.Context, req entity.GetListFilter) (en
 
 	// Start building the query
 	queryBuilder := r.pg.Builder.
-		Select(`id, user_id, ip_address, user_agent, is_active, expires_at, last_active_at, platform, created_at, updated_at`).
+		Select(`id, user_id, ip_address, user_agent, is_active, expires_at, last_active_at, platform, two_factor_verified, created_at, updated_at`).
 		From("sessions")
 
 	// Apply filters to the query
@@ -113,6 +113,10 @@ func (r *SessionRepo) GetList(ctx context.Context, req entity.GetListFilter) (en
 				if filter.Type == "eq" && filter.Value != "" {
 					queryBuilder = queryBuilder.Where("is_active = ?", filter.Value)
 				}
+			case "expires_at":
+				if expiresAt, err := time.Parse(time.RFC3339, filter.Value); filter.Type == "gt" && err == nil {
+					queryBuilder = queryBuilder.Where("(expires_at IS NULL OR expires_at > ?)", expiresAt)
+				}
 			}
 		}
 	}
@@ -142,7 +146,7 @@ func (r *SessionRepo) GetList(ctx context.Context, req entity.GetListFilter) (en
 		var item entity.Session
 		var expiresAt, lastActiveAt sql.NullTime
 		err = rows.Scan(&item.ID, &item.UserID, &item.IPAddress, &item.UserAgent,
-			&item.IsActive, &expiresAt, &lastActiveAt, &item.Platform, &createdAt, &updatedAt)
+			&item.IsActive, &expiresAt, &lastActiveAt, &item.Platform, &item.TwoFactorVerified, &createdAt, &updatedAt)
 		if err != nil {
 			return response, fmt.Errorf("error scanning row: %w", err)
 		}
@@ -168,6 +172,14 @@ func (r *SessionRepo) GetList(ctx context.Context, req entity.GetListFilter) (en
 				if filter.Type == "eq" && filter.Value != "" {
 					countQueryBuilder = countQueryBuilder.Where("user_id = ?", filter.Value)
 				}
+			case "is_active":
+				if filter.Type == "eq" && filter.Value != "" {
+					countQueryBuilder = countQueryBuilder.Where("is_active = ?", filter.Value)
+				}
+			case "expires_at":
+				if expiresAt, err := time.Parse(time.RFC3339, filter.Value); filter.Type == "gt" && err == nil {
+					countQueryBuilder = countQueryBuilder.Where("(expires_at IS NULL OR expires_at > ?)", expiresAt)
+				}
 			}
 		}
 	}
@@ -221,6 +233,53 @@ func (r *SessionRepo) Delete(ctx context.Context, req entity.Id) error {
 	return nil
 }
 
+// Touch records activity on a session unless it was recorded within the last interval.
+func (r *SessionRepo) Touch(ctx context.Context, req entity.Session, interval time.Duration) error {
+	_, err := r.pg.Pool.Exec(ctx, `
+		UPDATE sessions SET last_active_at = now(), ip_address = $1
+		WHERE id = $2 AND (last_active_at IS NULL OR last_active_at < now() - $3 * interval '1 second')`,
+		req.IPAddress, req.ID, interval.Seconds())
+	return err
+}
+
+// Revoke deactivates a single active session of a user.
+func (r *SessionRepo) Revoke(ctx context.Context, req entity.Session) error {
+	tag, err := r.pg.Pool.Exec(ctx, `
+		UPDATE sessions SET is_active = false, updated_at = now()
+		WHERE id = $1 AND user_id = $2 AND is_active`, req.ID, req.UserID)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	return nil
+}
+
+// RevokeByUser deactivates every active session of a user except the one with
+// keepSessionID, which may be empty to revoke them all.
+func (r *SessionRepo) RevokeByUser(ctx context.Context, userID, keepSessionID string) error {
+	queryBuilder := r.pg.Builder.Update("sessions").
+		SetMap(map[string]interface{}{
+			"is_active":  false,
+			"updated_at": "now()",
+		}).
+		Where("user_id = ? AND is_active", userID)
+	if keepSessionID != "" {
+		queryBuilder = queryBuilder.Where("id <> ?", keepSessionID)
+	}
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.pg.Pool.Exec(ctx, query, args...)
+	return err
+}
+
 func (r *SessionRepo) UpdateField(ctx context.Context, req entity.UpdateFieldRequest) (entity.RowsEffected, error) {
 	mp := map[string]interface{}{}
 	response := entity.RowsEffected{}
@@ -243,3 +302,103 @@ func (r *SessionRepo) UpdateField(ctx context.Context, req entity.UpdateFieldReq
 
 	return response, nil
 }
+
+// CreateRefreshToken stores the hash of a refresh token issued for a session.
+func (r *SessionRepo) CreateRefreshToken(ctx context.Context, req entity.RefreshToken) (entity.RefreshToken, error) {
+	req.ID = uuid.NewString()
+
+	query, args, err := r.pg.Builder.Insert("refresh_tokens").
+		Columns(`id, session_id, token_hash, expires_at`).
+		Values(req.ID, req.SessionID, req.TokenHash, req.ExpiresAt).ToSql()
+	if err != nil {
+		return entity.RefreshToken{}, err
+	}
+
+	_, err = r.pg.Pool.Exec(ctx, query, args...)
+	if err != nil {
+		return entity.RefreshToken{}, err
+	}
+
+	return req, nil
+}
+
+// RotateRefreshToken exchanges the refresh token with the given hash for next, which
+// belongs to the same session. The old token row is locked, so of two concurrent
+// rotations with the same token only one succeeds. Presenting a token that was already
+// rotated revokes the session with every token issued for it and returns
+// ErrRefreshTokenReused.
+func (r *SessionRepo) RotateRefreshToken(ctx context.Context, tokenHash string, next entity.RefreshToken) (entity.Session, error) {
+	var (
+		tokenID, sessionID string
+		expiresAt          time.Time
+		usedAt             sql.NullTime
+		isActive           bool
+		sessionExpiresAt   sql.NullTime
+	)
+
+	tx, err := r.pg.Pool.Begin(ctx)
+	if err != nil {
+		return entity.Session{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
+		SELECT id, session_id, expires_at, used_at FROM refresh_tokens
+		WHERE token_hash = $1
+		FOR UPDATE`, tokenHash).Scan(&tokenID, &sessionID, &expiresAt, &usedAt)
+	if err == pgx.ErrNoRows {
+		return entity.Session{}, entity.ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return entity.Session{}, err
+	}
+
+	if usedAt.Valid {
+		_, err = tx.Exec(ctx, `UPDATE sessions SET is_active = false, updated_at = now() WHERE id = $1`, sessionID)
+		if err != nil {
+			return entity.Session{}, err
+		}
+
+		if err = tx.Commit(ctx); err != nil {
+			return entity.Session{}, err
+		}
+		return entity.Session{}, entity.ErrRefreshTokenReused
+	}
+
+	err = tx.QueryRow(ctx, `SELECT is_active, expires_at FROM sessions WHERE id = $1 FOR UPDATE`, sessionID).
+		Scan(&isActive, &sessionExpiresAt)
+	if err != nil {
+		return entity.Session{}, err
+	}
+
+	now := time.Now()
+	if !isActive || now.After(expiresAt) || (sessionExpiresAt.Valid && now.After(sessionExpiresAt.Time)) {
+		return entity.Session{}, entity.ErrInvalidRefreshToken
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE refresh_tokens SET used_at = now() WHERE id = $1`, tokenID)
+	if err != nil {
+		return entity.Session{}, err
+	}
+
+	next.ID = uuid.NewString()
+	next.SessionID = sessionID
+	_, err = tx.Exec(ctx, `INSERT INTO refresh_tokens (id, session_id, token_hash, expires_at) VALUES ($1, $2, $3, $4)`,
+		next.ID, next.SessionID, next.TokenHash, next.ExpiresAt)
+	if err != nil {
+		return entity.Session{}, err
+	}
+
+	// the session lives as long as its newest refresh token
+	_, err = tx.Exec(ctx, `UPDATE sessions SET expires_at = $1, last_active_at = now(), updated_at = now() WHERE id = $2`,
+		next.ExpiresAt, sessionID)
+	if err != nil {
+		return entity.Session{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return entity.Session{}, err
+	}
+
+	return r.GetSingle(ctx, entity.Id{ID: sessionID})
+}
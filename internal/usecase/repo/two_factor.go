@@ -0,0 +1,130 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/Avazbek-02/Online-Hotel-System/config"
+	"github.com/Avazbek-02/Online-Hotel-System/internal/entity"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/logger"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/postgres"
+	"github.com/google/uuid"
+)
+
+type TwoFactorRepo struct {
+	pg     *postgres.Postgres
+	config *config.Config
+	logger *logger.Logger
+}
+
+func NewTwoFactorRepo(pg *postgres.Postgres, config *config.Config, logger *logger.Logger) *TwoFactorRepo {
+	return &TwoFactorRepo{
+		pg:     pg,
+		config: config,
+		logger: logger,
+	}
+}
+
+func (r *TwoFactorRepo) Get(ctx context.Context, req entity.Id) (entity.TwoFactor, error) {
+	response := entity.TwoFactor{UserID: req.ID}
+
+	err := r.pg.Pool.QueryRow(ctx, `
+		SELECT COALESCE(totp_secret, ''), totp_enabled, COALESCE(totp_last_step, 0)
+		FROM users WHERE id = $1`, req.ID).Scan(&response.Secret, &response.Enabled, &response.LastStep)
+	if err != nil {
+		return entity.TwoFactor{}, err
+	}
+
+	return response, nil
+}
+
+// Enroll stores a new secret and recovery codes for a user who doesn't have two-factor
+// authentication enabled yet. It stays off until Enable is called with a valid code.
+func (r *TwoFactorRepo) Enroll(ctx context.Context, req entity.TwoFactor, recoveryCodeHashes []string) error {
+	tx, err := r.pg.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE users SET totp_secret = $1, totp_enabled = false, totp_last_step = NULL, updated_at = now()
+		WHERE id = $2 AND NOT totp_enabled`, req.Secret, req.UserID)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		if _, err = r.Get(ctx, entity.Id{ID: req.UserID}); err != nil {
+			return err
+		}
+		return entity.ErrTwoFactorEnabled
+	}
+
+	_, err = tx.Exec(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, req.UserID)
+	if err != nil {
+		return err
+	}
+
+	for _, codeHash := range recoveryCodeHashes {
+		_, err = tx.Exec(ctx, `INSERT INTO user_recovery_codes (id, user_id, code_hash) VALUES ($1, $2, $3)`,
+			uuid.NewString(), req.UserID, codeHash)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *TwoFactorRepo) Enable(ctx context.Context, req entity.Id) error {
+	_, err := r.pg.Pool.Exec(ctx, `UPDATE users SET totp_enabled = true, updated_at = now() WHERE id = $1`, req.ID)
+	return err
+}
+
+// Disable turns two-factor authentication off and drops the secret and recovery codes.
+func (r *TwoFactorRepo) Disable(ctx context.Context, req entity.Id) error {
+	tx, err := r.pg.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		UPDATE users SET totp_secret = NULL, totp_enabled = false, totp_last_step = NULL, updated_at = now()
+		WHERE id = $1`, req.ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, req.ID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// UseStep records that a code of the given time step was accepted. It reports false if
+// a code of that step or a later one was accepted before, i.e. the code is replayed.
+func (r *TwoFactorRepo) UseStep(ctx context.Context, userID string, step int64) (bool, error) {
+	tag, err := r.pg.Pool.Exec(ctx, `
+		UPDATE users SET totp_last_step = $1
+		WHERE id = $2 AND (totp_last_step IS NULL OR totp_last_step < $1)`, step, userID)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// UseRecoveryCode marks an unused recovery code as used and reports whether there was one.
+func (r *TwoFactorRepo) UseRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error) {
+	tag, err := r.pg.Pool.Exec(ctx, `
+		UPDATE user_recovery_codes SET used_at = now()
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`, userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
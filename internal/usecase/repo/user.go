@@ -10,6 +10,7 @@ import (
 	"github.com/Avazbek-02/Online-Hotel-System/internal/entity"
 	"github.com/Avazbek-02/Online-Hotel-System/pkg/logger"
 	"github.com/Avazbek-02/Online-Hotel-System/pkg/postgres"
+	"github.com/Masterminds/squirrel"
 	"github.com/google/uuid"
 )
 
@@ -79,6 +80,24 @@ func (r *UserRepo) GetSingle(ctx context.Context, req entity.UserSingleRequest)
 	return response, nil
 }
 
+// GetPasswordHash returns the stored password hash of a user. It is kept out of
+// GetSingle so that the hash never ends up in a response by accident.
+func (r *UserRepo) GetPasswordHash(ctx context.Context, req entity.Id) (string, error) {
+	var hash string
+
+	query, args, err := r.pg.Builder.Select("password").From("users").Where("id = ?", req.ID).ToSql()
+	if err != nil {
+		return "", err
+	}
+
+	err = r.pg.Pool.QueryRow(ctx, query, args...).Scan(&hash)
+	if err != nil {
+		return "", err
+	}
+
+	return hash, nil
+}
+
 func (r *UserRepo) GetList(ctx context.Context, req entity.GetListFilter) (entity.UserList, error) {
 	var (
 		response             = entity.UserList{}
@@ -89,8 +108,33 @@ func (r *UserRepo) GetList(ctx context.Context, req entity.GetListFilter) (entit
 		Select("id, fullname, username, email, phone, user_status, gender, role, created_at, updated_at").
 		From("users")
 
+	// users have no hotel, a hotel_id "in" filter keeps the guests who booked at one of
+	// the hotels and the staff assigned to one of them
+	var (
+		hotelIDs []string
+		scoped   bool
+		filters  = make([]entity.Filter, 0, len(req.Filters))
+	)
+	for _, filter := range req.Filters {
+		if filter.Column == "hotel_id" && filter.Type == "in" {
+			hotelIDs, scoped = filter.Values, true
+			continue
+		}
+		filters = append(filters, filter)
+	}
+	req.Filters = filters
+
 	queryBuilder, where := PrepareGetListQuery(queryBuilder, req)
 
+	if scoped {
+		hotelUsers := squirrel.Or{
+			squirrel.Expr(`role = 'user' AND id IN (SELECT user_id FROM bookings WHERE hotel_id = ANY(?::uuid[]))`, hotelIDs),
+			squirrel.Expr(`role NOT IN ('user', 'admin', 'superadmin') AND id IN (SELECT user_id FROM hotel_staff WHERE hotel_id = ANY(?::uuid[]))`, hotelIDs),
+		}
+		queryBuilder = queryBuilder.Where(hotelUsers)
+		where = append(where, hotelUsers)
+	}
+
 	query, args, err := queryBuilder.ToSql()
 	if err != nil {
 		return response, err
@@ -173,6 +217,22 @@ func (r *UserRepo) Update(ctx context.Context, req entity.User) (entity.User, er
 	return r.GetSingle(ctx, entity.UserSingleRequest{ID: req.ID})
 }
 
+// UpdatePassword replaces the password hash of a user.
+func (r *UserRepo) UpdatePassword(ctx context.Context, req entity.User) error {
+	query, args, err := r.pg.Builder.Update("users").
+		SetMap(map[string]interface{}{
+			"password":   req.Password_hash,
+			"updated_at": "now()",
+		}).
+		Where("id = ?", req.ID).ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.pg.Pool.Exec(ctx, query, args...)
+	return err
+}
+
 func (r *UserRepo) Delete(ctx context.Context, req entity.Id) error {
 	query, args, err := r.pg.Builder.Delete("users").Where("id = ?", req.ID).ToSql()
 	if err != nil {
@@ -0,0 +1,8 @@
+ALTER TABLE "bookings" DROP CONSTRAINT IF EXISTS "bookings_no_overlap";
+ALTER TABLE "bookings" DROP CONSTRAINT IF EXISTS "bookings_dates_check";
+
+ALTER TABLE "bookings"
+  ALTER COLUMN "check_in_date" DROP NOT NULL,
+  ALTER COLUMN "check_out_date" DROP NOT NULL,
+  ALTER COLUMN "status" DROP NOT NULL,
+  ALTER COLUMN "status" DROP DEFAULT;
@@ -0,0 +1,20 @@
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+UPDATE "bookings" SET "status" = 'pending' WHERE "status" IS NULL;
+
+ALTER TABLE "bookings"
+  ALTER COLUMN "check_in_date" SET NOT NULL,
+  ALTER COLUMN "check_out_date" SET NOT NULL,
+  ALTER COLUMN "status" SET NOT NULL,
+  ALTER COLUMN "status" SET DEFAULT 'pending';
+
+ALTER TABLE "bookings" ADD CONSTRAINT "bookings_dates_check"
+  CHECK ("check_out_date" > "check_in_date");
+
+-- A room can't have two non-cancelled stays whose [check_in, check_out) ranges intersect.
+-- Concurrent inserts for the same room and dates are serialized by the index, so exactly one wins.
+ALTER TABLE "bookings" ADD CONSTRAINT "bookings_no_overlap"
+  EXCLUDE USING gist (
+    "room_id" WITH =,
+    daterange("check_in_date", "check_out_date", '[)') WITH &&
+  ) WHERE ("status" <> 'cancelled');
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS "rooms_category_type_price_idx";
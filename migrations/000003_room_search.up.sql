@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS "rooms_category_type_price_idx" ON "rooms" ("category", "type", "price");
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS "room_reviews_booking_id_key";
+ALTER TABLE "room_reviews" DROP COLUMN IF EXISTS "booking_id";
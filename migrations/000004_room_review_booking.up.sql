@@ -0,0 +1,6 @@
+ALTER TABLE "room_reviews" ADD COLUMN IF NOT EXISTS "booking_id" UUID;
+
+ALTER TABLE "room_reviews" ADD FOREIGN KEY ("booking_id") REFERENCES "bookings" ("id");
+
+-- One review per stay.
+CREATE UNIQUE INDEX IF NOT EXISTS "room_reviews_booking_id_key" ON "room_reviews" ("booking_id");
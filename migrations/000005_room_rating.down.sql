@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS "rooms_rating_idx";
+
+ALTER TABLE "room_reviews" DROP CONSTRAINT IF EXISTS "room_reviews_rating_check";
+
+ALTER TABLE "rooms"
+  ALTER COLUMN "rating" DROP NOT NULL,
+  ALTER COLUMN "rating" DROP DEFAULT;
+
+ALTER TABLE "rooms" DROP COLUMN IF EXISTS "review_count";
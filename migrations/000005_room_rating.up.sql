@@ -0,0 +1,22 @@
+ALTER TABLE "rooms" ADD COLUMN IF NOT EXISTS "review_count" INT NOT NULL DEFAULT 0;
+
+UPDATE "rooms" SET
+  "rating" = COALESCE(s."avg", 0),
+  "review_count" = COALESCE(s."cnt", 0)
+FROM (
+  SELECT "room_id", AVG("rating") AS "avg", COUNT(*) AS "cnt"
+  FROM "room_reviews"
+  GROUP BY "room_id"
+) s
+WHERE "rooms"."id" = s."room_id";
+
+UPDATE "rooms" SET "rating" = 0 WHERE "rating" IS NULL;
+
+ALTER TABLE "rooms"
+  ALTER COLUMN "rating" SET DEFAULT 0,
+  ALTER COLUMN "rating" SET NOT NULL;
+
+ALTER TABLE "room_reviews" ADD CONSTRAINT "room_reviews_rating_check"
+  CHECK ("rating" BETWEEN 1 AND 5) NOT VALID;
+
+CREATE INDEX IF NOT EXISTS "rooms_rating_idx" ON "rooms" ("rating");
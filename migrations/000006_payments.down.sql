@@ -0,0 +1,15 @@
+DROP INDEX IF EXISTS "payments_booking_id_idx";
+DROP INDEX IF EXISTS "payments_idempotency_key_key";
+
+ALTER TABLE "payments" DROP CONSTRAINT IF EXISTS "payments_status_check";
+ALTER TABLE "payments" DROP CONSTRAINT IF EXISTS "payments_type_check";
+ALTER TABLE "payments" DROP CONSTRAINT IF EXISTS "payments_amount_check";
+
+ALTER TABLE "payments"
+  DROP COLUMN IF EXISTS "idempotency_key",
+  DROP COLUMN IF EXISTS "type",
+  ALTER COLUMN "amount" DROP NOT NULL,
+  ALTER COLUMN "status" DROP NOT NULL,
+  ALTER COLUMN "status" DROP DEFAULT;
+
+ALTER TABLE "bookings" DROP COLUMN IF EXISTS "total_price";
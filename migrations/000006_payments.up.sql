@@ -0,0 +1,22 @@
+ALTER TABLE "bookings" ADD COLUMN IF NOT EXISTS "total_price" DECIMAL(10,2) NOT NULL DEFAULT 0;
+
+UPDATE "bookings" b SET "total_price" = COALESCE(r."price", 0) * (b."check_out_date" - b."check_in_date")
+FROM "rooms" r
+WHERE r."id" = b."room_id";
+
+UPDATE "payments" SET "status" = 'succeeded' WHERE "status" IS NULL;
+
+ALTER TABLE "payments"
+  ADD COLUMN IF NOT EXISTS "type" VARCHAR(20) NOT NULL DEFAULT 'payment',
+  ADD COLUMN IF NOT EXISTS "idempotency_key" VARCHAR(255),
+  ALTER COLUMN "amount" SET NOT NULL,
+  ALTER COLUMN "status" SET NOT NULL,
+  ALTER COLUMN "status" SET DEFAULT 'pending';
+
+ALTER TABLE "payments" ADD CONSTRAINT "payments_amount_check" CHECK ("amount" > 0);
+ALTER TABLE "payments" ADD CONSTRAINT "payments_type_check" CHECK ("type" IN ('deposit', 'payment'));
+ALTER TABLE "payments" ADD CONSTRAINT "payments_status_check"
+  CHECK ("status" IN ('pending', 'succeeded', 'failed', 'refunded'));
+
+CREATE UNIQUE INDEX IF NOT EXISTS "payments_idempotency_key_key" ON "payments" ("idempotency_key");
+CREATE INDEX IF NOT EXISTS "payments_booking_id_idx" ON "payments" ("booking_id");
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "payments_provider_ref_key";
+
+ALTER TABLE "payments" DROP COLUMN IF EXISTS "provider_ref";
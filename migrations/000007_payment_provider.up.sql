@@ -0,0 +1,3 @@
+ALTER TABLE "payments" ADD COLUMN IF NOT EXISTS "provider_ref" VARCHAR(255);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "payments_provider_ref_key" ON "payments" ("provider_ref");
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS "refunds";
+DROP TABLE IF EXISTS "cancellation_policies";
+
+ALTER TABLE "bookings" DROP COLUMN IF EXISTS "cancelled_at";
+ALTER TABLE "bookings" DROP CONSTRAINT IF EXISTS "bookings_status_check";
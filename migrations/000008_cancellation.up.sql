@@ -0,0 +1,44 @@
+UPDATE "bookings" SET "status" = 'pending'
+WHERE "status" NOT IN ('pending', 'confirmed', 'cancelled', 'completed');
+
+ALTER TABLE "bookings" ADD CONSTRAINT "bookings_status_check"
+  CHECK ("status" IN ('pending', 'confirmed', 'cancelled', 'completed'));
+
+ALTER TABLE "bookings" ADD COLUMN IF NOT EXISTS "cancelled_at" TIMESTAMP;
+
+CREATE TABLE IF NOT EXISTS "cancellation_policies" (
+  "id" UUID PRIMARY KEY,
+  "room_category" room_category NOT NULL UNIQUE,
+  "free_cancellation_hours" INT NOT NULL DEFAULT 48,
+  "late_refund_percent" INT NOT NULL DEFAULT 50,
+  "non_refundable" BOOLEAN NOT NULL DEFAULT false,
+  "created_at" TIMESTAMP DEFAULT (CURRENT_TIMESTAMP),
+  "updated_at" TIMESTAMP DEFAULT (CURRENT_TIMESTAMP),
+  CONSTRAINT "cancellation_policies_free_hours_check" CHECK ("free_cancellation_hours" >= 0),
+  CONSTRAINT "cancellation_policies_late_refund_check" CHECK ("late_refund_percent" BETWEEN 0 AND 100)
+);
+
+INSERT INTO "cancellation_policies" ("id", "room_category")
+SELECT gen_random_uuid(), c
+FROM unnest(enum_range(NULL::room_category)) AS c
+ON CONFLICT ("room_category") DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS "refunds" (
+  "id" UUID PRIMARY KEY,
+  "payment_id" UUID NOT NULL,
+  "booking_id" UUID NOT NULL,
+  "amount" DECIMAL(10,2) NOT NULL,
+  "status" VARCHAR(20) NOT NULL DEFAULT 'pending',
+  "provider_ref" VARCHAR(255),
+  "reason" TEXT,
+  "created_at" TIMESTAMP DEFAULT (CURRENT_TIMESTAMP),
+  "updated_at" TIMESTAMP DEFAULT (CURRENT_TIMESTAMP),
+  CONSTRAINT "refunds_amount_check" CHECK ("amount" > 0),
+  CONSTRAINT "refunds_status_check" CHECK ("status" IN ('pending', 'succeeded', 'failed'))
+);
+
+ALTER TABLE "refunds" ADD FOREIGN KEY ("payment_id") REFERENCES "payments" ("id");
+
+ALTER TABLE "refunds" ADD FOREIGN KEY ("booking_id") REFERENCES "bookings" ("id");
+
+CREATE INDEX IF NOT EXISTS "refunds_booking_id_idx" ON "refunds" ("booking_id");
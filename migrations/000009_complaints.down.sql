@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS "complaint_messages";
+
+DROP INDEX IF EXISTS "complaints_status_idx";
+ALTER TABLE "complaints" DROP COLUMN IF EXISTS "resolved_at";
+ALTER TABLE "complaints" DROP COLUMN IF EXISTS "assignee_id";
+ALTER TABLE "complaints" DROP CONSTRAINT IF EXISTS "complaints_status_check";
+ALTER TABLE "complaints" ALTER COLUMN "status" DROP NOT NULL;
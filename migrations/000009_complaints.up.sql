@@ -0,0 +1,29 @@
+UPDATE "complaints" SET "status" = 'pending'
+WHERE "status" IS NULL OR "status" NOT IN ('pending', 'in_progress', 'resolved', 'rejected');
+
+ALTER TABLE "complaints" ALTER COLUMN "status" SET NOT NULL;
+
+ALTER TABLE "complaints" ADD CONSTRAINT "complaints_status_check"
+  CHECK ("status" IN ('pending', 'in_progress', 'resolved', 'rejected'));
+
+ALTER TABLE "complaints" ADD COLUMN IF NOT EXISTS "assignee_id" UUID;
+
+ALTER TABLE "complaints" ADD COLUMN IF NOT EXISTS "resolved_at" TIMESTAMP;
+
+ALTER TABLE "complaints" ADD FOREIGN KEY ("assignee_id") REFERENCES "users" ("id");
+
+CREATE INDEX IF NOT EXISTS "complaints_status_idx" ON "complaints" ("status");
+
+CREATE TABLE IF NOT EXISTS "complaint_messages" (
+  "id" UUID PRIMARY KEY,
+  "complaint_id" UUID NOT NULL,
+  "user_id" UUID NOT NULL,
+  "message" TEXT NOT NULL,
+  "created_at" TIMESTAMP DEFAULT (CURRENT_TIMESTAMP)
+);
+
+ALTER TABLE "complaint_messages" ADD FOREIGN KEY ("complaint_id") REFERENCES "complaints" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "complaint_messages" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+CREATE INDEX IF NOT EXISTS "complaint_messages_complaint_id_idx" ON "complaint_messages" ("complaint_id", "created_at");
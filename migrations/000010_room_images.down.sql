@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS "room_images_room_id_cover_key";
+DROP INDEX IF EXISTS "room_images_room_id_position_idx";
+
+ALTER TABLE "room_images" DROP COLUMN IF EXISTS "is_cover";
+ALTER TABLE "room_images" DROP COLUMN IF EXISTS "position";
+ALTER TABLE "room_images" DROP COLUMN IF EXISTS "object_name";
@@ -0,0 +1,9 @@
+ALTER TABLE "room_images" ADD COLUMN IF NOT EXISTS "object_name" TEXT;
+
+ALTER TABLE "room_images" ADD COLUMN IF NOT EXISTS "position" INT NOT NULL DEFAULT 0;
+
+ALTER TABLE "room_images" ADD COLUMN IF NOT EXISTS "is_cover" BOOLEAN NOT NULL DEFAULT false;
+
+CREATE INDEX IF NOT EXISTS "room_images_room_id_position_idx" ON "room_images" ("room_id", "position");
+
+CREATE UNIQUE INDEX IF NOT EXISTS "room_images_room_id_cover_key" ON "room_images" ("room_id") WHERE "is_cover";
@@ -0,0 +1,3 @@
+ALTER TABLE "room_images" DROP COLUMN IF EXISTS "variant_objects";
+ALTER TABLE "room_images" DROP COLUMN IF EXISTS "medium_url";
+ALTER TABLE "room_images" DROP COLUMN IF EXISTS "thumbnail_url";
@@ -0,0 +1,5 @@
+ALTER TABLE "room_images" ADD COLUMN IF NOT EXISTS "thumbnail_url" TEXT;
+
+ALTER TABLE "room_images" ADD COLUMN IF NOT EXISTS "medium_url" TEXT;
+
+ALTER TABLE "room_images" ADD COLUMN IF NOT EXISTS "variant_objects" TEXT[] NOT NULL DEFAULT '{}';
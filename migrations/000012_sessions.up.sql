@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS "sessions" (
+  "id" UUID PRIMARY KEY,
+  "user_id" UUID NOT NULL,
+  "ip_address" VARCHAR(64),
+  "user_agent" TEXT,
+  "is_active" BOOLEAN NOT NULL DEFAULT true,
+  "expires_at" TIMESTAMP,
+  "last_active_at" TIMESTAMP DEFAULT (CURRENT_TIMESTAMP),
+  "platform" VARCHAR(50),
+  "created_at" TIMESTAMP DEFAULT (CURRENT_TIMESTAMP),
+  "updated_at" TIMESTAMP DEFAULT (CURRENT_TIMESTAMP)
+);
+
+ALTER TABLE "sessions" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS "sessions_user_id_idx" ON "sessions" ("user_id");
+
+-- every refresh token ever issued for a session is kept, so presenting an already
+-- rotated one can be told apart from presenting garbage
+CREATE TABLE IF NOT EXISTS "refresh_tokens" (
+  "id" UUID PRIMARY KEY,
+  "session_id" UUID NOT NULL,
+  "token_hash" VARCHAR(64) NOT NULL UNIQUE,
+  "expires_at" TIMESTAMP NOT NULL,
+  "used_at" TIMESTAMP,
+  "created_at" TIMESTAMP DEFAULT (CURRENT_TIMESTAMP)
+);
+
+ALTER TABLE "refresh_tokens" ADD FOREIGN KEY ("session_id") REFERENCES "sessions" ("id") ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS "refresh_tokens_session_id_idx" ON "refresh_tokens" ("session_id");
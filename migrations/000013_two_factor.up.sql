@@ -0,0 +1,18 @@
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "totp_secret" VARCHAR(64);
+
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "totp_enabled" BOOLEAN NOT NULL DEFAULT false;
+
+-- the last time step a code was accepted for, so a code can't be replayed
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "totp_last_step" BIGINT;
+
+CREATE TABLE IF NOT EXISTS "user_recovery_codes" (
+  "id" UUID PRIMARY KEY,
+  "user_id" UUID NOT NULL,
+  "code_hash" VARCHAR(64) NOT NULL,
+  "used_at" TIMESTAMP,
+  "created_at" TIMESTAMP DEFAULT (CURRENT_TIMESTAMP)
+);
+
+ALTER TABLE "user_recovery_codes" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE UNIQUE INDEX IF NOT EXISTS "user_recovery_codes_user_id_code_hash_idx" ON "user_recovery_codes" ("user_id", "code_hash");
@@ -0,0 +1,71 @@
+-- the access policy used to be read from config/policy.csv at startup, it is now
+-- managed at runtime and every instance reloads it when it changes
+CREATE TABLE IF NOT EXISTS "casbin_rule" (
+  "id" SERIAL PRIMARY KEY,
+  "ptype" VARCHAR(10) NOT NULL,
+  "v0" VARCHAR(255) NOT NULL DEFAULT '',
+  "v1" VARCHAR(255) NOT NULL DEFAULT '',
+  "v2" VARCHAR(255) NOT NULL DEFAULT '',
+  "v3" VARCHAR(255) NOT NULL DEFAULT '',
+  "v4" VARCHAR(255) NOT NULL DEFAULT '',
+  "v5" VARCHAR(255) NOT NULL DEFAULT '',
+  "created_at" TIMESTAMP DEFAULT (CURRENT_TIMESTAMP)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "casbin_rule_rule_idx" ON "casbin_rule" ("ptype", "v0", "v1", "v2", "v3", "v4", "v5");
+
+-- seeded with config/policy.csv as it was, including rules for routes that were never
+-- registered, so they show up as stale in the policy list instead of silently disappearing
+INSERT INTO "casbin_rule" ("ptype", "v0", "v1", "v2") VALUES
+  ('p', 'unauthorized', '/swagger/*', 'GET'),
+  ('p', 'unauthorized', '/v1/auth/*', 'GET|POST'),
+  ('p', 'unauthorized', '/v1/room/*', 'GET'),
+  ('p', 'unauthorized', '/v1/review/list', 'GET'),
+  ('p', 'unauthorized', '/v1/review/:id', 'GET'),
+  ('p', 'unauthorized', '/v1/payment/webhook', 'POST'),
+  ('p', 'unauthorized', '/storage/*', 'GET'),
+  ('p', 'unauthorized', '/v1/cancellation-policy/list', 'GET'),
+  ('p', 'unauthorized', '/v1/cancellation-policy/:id', 'GET'),
+  ('p', 'user', '/v1/user/*', 'GET|POST|PUT|DELETE'),
+  ('p', 'user', '/v1/user/:id', 'GET'),
+  ('p', 'admin', '/v1/user/*', 'GET|POST|PUT|DELETE'),
+  ('p', 'user', '/v1/session/me', 'GET'),
+  ('p', 'user', '/v1/session/me/*', 'DELETE'),
+  ('p', 'admin', '/v1/session/*', 'GET|POST|PUT|DELETE'),
+  ('p', 'admin', '/v1/room/*', 'GET|POST|PUT|DELETE'),
+  ('p', 'user', '/v1/booking/', 'POST'),
+  ('p', 'user', '/v1/booking/list', 'GET'),
+  ('p', 'user', '/v1/booking/:id', 'GET'),
+  ('p', 'user', '/v1/booking/:id/cancel', 'PUT'),
+  ('p', 'user', '/v1/booking/:id/balance', 'GET'),
+  ('p', 'admin', '/v1/booking/*', 'GET|POST|PUT|DELETE'),
+  ('p', 'user', '/v1/payment/', 'POST'),
+  ('p', 'user', '/v1/payment/list', 'GET'),
+  ('p', 'user', '/v1/payment/:id', 'GET'),
+  ('p', 'admin', '/v1/payment/*', 'GET|POST|PUT|DELETE'),
+  ('p', 'user', '/v1/refund/list', 'GET'),
+  ('p', 'admin', '/v1/refund/*', 'GET|POST|PUT|DELETE'),
+  ('p', 'admin', '/v1/cancellation-policy/*', 'GET|POST|PUT|DELETE'),
+  ('p', 'user', '/v1/complaint/', 'POST'),
+  ('p', 'user', '/v1/complaint/list', 'GET'),
+  ('p', 'user', '/v1/complaint/:id', 'GET'),
+  ('p', 'user', '/v1/complaint/:id/message', 'POST'),
+  ('p', 'admin', '/v1/complaint/*', 'GET|POST|PUT|DELETE'),
+  ('p', 'user', '/v1/business/*', 'GET|POST|PUT|DELETE'),
+  ('p', 'user', '/v1/business/:id', 'GET'),
+  ('p', 'admin', '/v1/business/*', 'GET|POST|PUT|DELETE'),
+  ('p', 'user', '/v1/review/*', 'GET|POST|PUT|DELETE'),
+  ('p', 'user', '/v1/review/:id', 'GET'),
+  ('p', 'admin', '/v1/review/*', 'GET|POST|PUT|DELETE'),
+  ('p', 'user', '/v1/notification/*', 'GET|POST|PUT|DELETE'),
+  ('p', 'user', '/v1/notification/:id', 'GET'),
+  ('p', 'admin', '/v1/notification/*', 'GET|POST|PUT|DELETE'),
+  ('p', 'user', '/v1/report/*', 'GET|POST|PUT|DELETE'),
+  ('p', 'user', '/v1/report/:id', 'GET'),
+  ('p', 'admin', '/v1/report/*', 'GET|POST|PUT|DELETE'),
+  ('p', 'user', '/v1/event/*', 'GET|POST|PUT|DELETE'),
+  ('p', 'user', '/v1/event/:id', 'GET'),
+  ('p', 'admin', '/v1/event/*', 'GET|POST|PUT|DELETE'),
+  ('g', 'user', 'unauthorized', ''),
+  ('g', 'admin', 'user', '')
+ON CONFLICT DO NOTHING;
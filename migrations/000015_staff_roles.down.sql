@@ -0,0 +1,14 @@
+DELETE FROM "casbin_rule" WHERE "v0" IN ('staff', 'receptionist', 'housekeeping', 'manager');
+
+UPDATE "bookings" SET "status" = 'confirmed' WHERE "status" = 'checked_in';
+
+ALTER TABLE "bookings" DROP CONSTRAINT IF EXISTS "bookings_status_check";
+ALTER TABLE "bookings" ADD CONSTRAINT "bookings_status_check"
+  CHECK ("status" IN ('pending', 'confirmed', 'cancelled', 'completed'));
+
+ALTER TABLE "bookings" DROP COLUMN IF EXISTS "checked_out_at";
+ALTER TABLE "bookings" DROP COLUMN IF EXISTS "checked_in_at";
+
+-- enum values can't be dropped, so staff are demoted and cleaning rooms made available instead
+UPDATE "users" SET "role" = 'user' WHERE "role" IN ('receptionist', 'housekeeping', 'manager');
+UPDATE "rooms" SET "status" = 'available' WHERE "status" = 'cleaning';
@@ -0,0 +1,56 @@
+ALTER TYPE "user_role" ADD VALUE IF NOT EXISTS 'receptionist';
+ALTER TYPE "user_role" ADD VALUE IF NOT EXISTS 'housekeeping';
+ALTER TYPE "user_role" ADD VALUE IF NOT EXISTS 'manager';
+
+-- a room stays in cleaning after check-out until housekeeping marks it available
+ALTER TYPE "room_status" ADD VALUE IF NOT EXISTS 'cleaning';
+
+ALTER TABLE "bookings" ADD COLUMN IF NOT EXISTS "checked_in_at" TIMESTAMP;
+ALTER TABLE "bookings" ADD COLUMN IF NOT EXISTS "checked_out_at" TIMESTAMP;
+
+ALTER TABLE "bookings" DROP CONSTRAINT IF EXISTS "bookings_status_check";
+ALTER TABLE "bookings" ADD CONSTRAINT "bookings_status_check"
+  CHECK ("status" IN ('pending', 'confirmed', 'checked_in', 'cancelled', 'completed'));
+
+-- guests were allowed everything under /v1/report while it didn't exist, the reports
+-- it now serves are for managers and admins only
+DELETE FROM "casbin_rule" WHERE "ptype" = 'p' AND "v0" = 'user' AND "v1" LIKE '/v1/report/%';
+
+-- staff don't inherit the user role: handlers scope that role to the guest's own records
+INSERT INTO "casbin_rule" ("ptype", "v0", "v1", "v2") VALUES
+  ('g', 'staff', 'unauthorized', ''),
+  ('g', 'receptionist', 'staff', ''),
+  ('g', 'housekeeping', 'staff', ''),
+  ('g', 'manager', 'staff', ''),
+  ('p', 'staff', '/v1/user/:id', 'GET'),
+  ('p', 'staff', '/v1/user/', 'PUT'),
+  ('p', 'staff', '/v1/user/password', 'PUT'),
+  ('p', 'staff', '/v1/user/email', 'POST'),
+  ('p', 'staff', '/v1/user/email/confirm', 'PUT'),
+  ('p', 'staff', '/v1/user/2fa', 'POST|DELETE'),
+  ('p', 'staff', '/v1/user/2fa/confirm', 'PUT'),
+  ('p', 'staff', '/v1/session/me', 'GET'),
+  ('p', 'staff', '/v1/session/me/*', 'DELETE'),
+  ('p', 'receptionist', '/v1/user/list', 'GET'),
+  ('p', 'receptionist', '/v1/booking/', 'POST|PUT'),
+  ('p', 'receptionist', '/v1/booking/list', 'GET'),
+  ('p', 'receptionist', '/v1/booking/:id', 'GET'),
+  ('p', 'receptionist', '/v1/booking/:id/balance', 'GET'),
+  ('p', 'receptionist', '/v1/booking/:id/cancel', 'PUT'),
+  ('p', 'receptionist', '/v1/booking/:id/check-in', 'PUT'),
+  ('p', 'receptionist', '/v1/booking/:id/check-out', 'PUT'),
+  ('p', 'receptionist', '/v1/payment/', 'POST'),
+  ('p', 'receptionist', '/v1/payment/list', 'GET'),
+  ('p', 'receptionist', '/v1/payment/:id', 'GET'),
+  ('p', 'housekeeping', '/v1/room/:id/status', 'PUT'),
+  ('p', 'manager', '/v1/user/list', 'GET'),
+  ('p', 'manager', '/v1/booking/list', 'GET'),
+  ('p', 'manager', '/v1/booking/:id', 'GET'),
+  ('p', 'manager', '/v1/booking/:id/balance', 'GET'),
+  ('p', 'manager', '/v1/payment/list', 'GET'),
+  ('p', 'manager', '/v1/payment/:id', 'GET'),
+  ('p', 'manager', '/v1/refund/list', 'GET'),
+  ('p', 'manager', '/v1/complaint/list', 'GET'),
+  ('p', 'manager', '/v1/complaint/:id', 'GET'),
+  ('p', 'manager', '/v1/report/*', 'GET')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,9 @@
+DELETE FROM "casbin_rule" WHERE "ptype" = 'p' AND "v1" LIKE '/v1/hotel/%';
+
+ALTER TABLE "bookings" DROP COLUMN IF EXISTS "hotel_id";
+
+ALTER TABLE "rooms" DROP COLUMN IF EXISTS "hotel_id";
+
+DROP TABLE IF EXISTS "hotel_staff";
+
+DROP TABLE IF EXISTS "hotels";
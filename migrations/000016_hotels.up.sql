@@ -0,0 +1,60 @@
+CREATE TABLE IF NOT EXISTS "hotels" (
+  "id" UUID PRIMARY KEY,
+  "name" VARCHAR(255) NOT NULL,
+  "address" TEXT NOT NULL DEFAULT '',
+  "latitude" DOUBLE PRECISION,
+  "longitude" DOUBLE PRECISION,
+  "timezone" VARCHAR(64) NOT NULL DEFAULT 'UTC',
+  "currency" CHAR(3) NOT NULL DEFAULT 'USD',
+  "amenities" TEXT[] NOT NULL DEFAULT '{}',
+  "created_at" TIMESTAMP DEFAULT (CURRENT_TIMESTAMP),
+  "updated_at" TIMESTAMP DEFAULT (CURRENT_TIMESTAMP),
+  CONSTRAINT "hotels_latitude_check" CHECK ("latitude" BETWEEN -90 AND 90),
+  CONSTRAINT "hotels_longitude_check" CHECK ("longitude" BETWEEN -180 AND 180)
+);
+
+-- admins and staff manage only the hotels they are assigned to, superadmins manage all
+CREATE TABLE IF NOT EXISTS "hotel_staff" (
+  "hotel_id" UUID NOT NULL,
+  "user_id" UUID NOT NULL,
+  "created_at" TIMESTAMP DEFAULT (CURRENT_TIMESTAMP),
+  PRIMARY KEY ("hotel_id", "user_id")
+);
+
+ALTER TABLE "hotel_staff" ADD FOREIGN KEY ("hotel_id") REFERENCES "hotels" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "hotel_staff" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS "hotel_staff_user_id_idx" ON "hotel_staff" ("user_id");
+
+-- everything that exists so far belongs to the one hotel the system used to assume
+INSERT INTO "hotels" ("id", "name")
+SELECT gen_random_uuid(), 'Main hotel'
+WHERE EXISTS (SELECT 1 FROM "rooms")
+   OR EXISTS (SELECT 1 FROM "users" WHERE "role" IN ('admin', 'receptionist', 'housekeeping', 'manager'));
+
+INSERT INTO "hotel_staff" ("hotel_id", "user_id")
+SELECT h."id", u."id" FROM "hotels" h, "users" u
+WHERE u."role" IN ('admin', 'receptionist', 'housekeeping', 'manager');
+
+ALTER TABLE "rooms" ADD COLUMN IF NOT EXISTS "hotel_id" UUID;
+UPDATE "rooms" SET "hotel_id" = (SELECT "id" FROM "hotels" LIMIT 1) WHERE "hotel_id" IS NULL;
+ALTER TABLE "rooms" ALTER COLUMN "hotel_id" SET NOT NULL;
+ALTER TABLE "rooms" ADD FOREIGN KEY ("hotel_id") REFERENCES "hotels" ("id");
+CREATE INDEX IF NOT EXISTS "rooms_hotel_id_idx" ON "rooms" ("hotel_id");
+
+-- copied from the room so bookings can be listed per hotel without a join
+ALTER TABLE "bookings" ADD COLUMN IF NOT EXISTS "hotel_id" UUID;
+UPDATE "bookings" b SET "hotel_id" = r."hotel_id" FROM "rooms" r WHERE r."id" = b."room_id";
+ALTER TABLE "bookings" ALTER COLUMN "hotel_id" SET NOT NULL;
+ALTER TABLE "bookings" ADD FOREIGN KEY ("hotel_id") REFERENCES "hotels" ("id");
+CREATE INDEX IF NOT EXISTS "bookings_hotel_id_idx" ON "bookings" ("hotel_id");
+
+INSERT INTO "casbin_rule" ("ptype", "v0", "v1", "v2") VALUES
+  ('p', 'unauthorized', '/v1/hotel/list', 'GET'),
+  ('p', 'unauthorized', '/v1/hotel/:id', 'GET'),
+  ('p', 'admin', '/v1/hotel/', 'PUT'),
+  ('p', 'admin', '/v1/hotel/:id/staff', 'GET|POST'),
+  ('p', 'admin', '/v1/hotel/:id/staff/:user_id', 'DELETE'),
+  ('p', 'manager', '/v1/hotel/:id/staff', 'GET')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,80 @@
+-- what is sold: guests search and book a room type, the physical room is assigned at check-in
+CREATE TABLE IF NOT EXISTS "room_types" (
+  "id" UUID PRIMARY KEY,
+  "hotel_id" UUID NOT NULL,
+  "name" VARCHAR(100) NOT NULL,
+  "type" room_type NOT NULL,
+  "category" room_category NOT NULL,
+  "capacity" INT NOT NULL,
+  "bed_configuration" VARCHAR(100) NOT NULL DEFAULT '',
+  "size" DECIMAL(7,2) NOT NULL DEFAULT 0,
+  "amenities" TEXT[] NOT NULL DEFAULT '{}',
+  "base_price" DECIMAL(10,2) NOT NULL,
+  "description" TEXT NOT NULL DEFAULT '',
+  "created_at" TIMESTAMP DEFAULT (CURRENT_TIMESTAMP),
+  "updated_at" TIMESTAMP DEFAULT (CURRENT_TIMESTAMP),
+  CONSTRAINT "room_types_capacity_check" CHECK ("capacity" > 0),
+  CONSTRAINT "room_types_size_check" CHECK ("size" >= 0),
+  CONSTRAINT "room_types_base_price_check" CHECK ("base_price" >= 0)
+);
+
+ALTER TABLE "room_types" ADD FOREIGN KEY ("hotel_id") REFERENCES "hotels" ("id");
+
+CREATE INDEX IF NOT EXISTS "room_types_hotel_id_idx" ON "room_types" ("hotel_id");
+
+CREATE INDEX IF NOT EXISTS "room_types_category_type_base_price_idx" ON "room_types" ("category", "type", "base_price");
+
+-- one type for every combination of type, category and price the rooms of a hotel had
+INSERT INTO "room_types" ("id", "hotel_id", "name", "type", "category", "capacity", "base_price")
+SELECT gen_random_uuid(), r."hotel_id", initcap(r."type"::text) || ' ' || r."category"::text, r."type", r."category",
+  CASE r."category" WHEN 'single' THEN 1 WHEN 'double' THEN 2 WHEN '3xroom' THEN 3 WHEN '4xroom' THEN 4 ELSE 5 END,
+  r."price"
+FROM (SELECT DISTINCT "hotel_id", "type", "category", COALESCE("price", 0) AS "price" FROM "rooms") r;
+
+ALTER TABLE "rooms"
+  ADD COLUMN IF NOT EXISTS "room_type_id" UUID,
+  ADD COLUMN IF NOT EXISTS "room_number" VARCHAR(20),
+  ADD COLUMN IF NOT EXISTS "floor" INT NOT NULL DEFAULT 0;
+
+UPDATE "rooms" r SET "room_type_id" = t."id"
+FROM "room_types" t
+WHERE t."hotel_id" = r."hotel_id" AND t."type" = r."type" AND t."category" = r."category" AND t."base_price" = COALESCE(r."price", 0);
+
+-- rooms had no number, so they are numbered per hotel in the order they were added
+UPDATE "rooms" r SET "room_number" = n."room_number"
+FROM (
+  SELECT "id", row_number() OVER (PARTITION BY "hotel_id" ORDER BY "created_at", "id")::text AS "room_number"
+  FROM "rooms"
+) n
+WHERE n."id" = r."id";
+
+ALTER TABLE "rooms"
+  ALTER COLUMN "room_type_id" SET NOT NULL,
+  ALTER COLUMN "room_number" SET NOT NULL;
+
+ALTER TABLE "rooms" ADD FOREIGN KEY ("room_type_id") REFERENCES "room_types" ("id");
+
+CREATE INDEX IF NOT EXISTS "rooms_room_type_id_idx" ON "rooms" ("room_type_id");
+
+CREATE UNIQUE INDEX IF NOT EXISTS "rooms_hotel_id_room_number_key" ON "rooms" ("hotel_id", "room_number");
+
+DROP INDEX IF EXISTS "rooms_category_type_price_idx";
+
+ALTER TABLE "rooms"
+  DROP COLUMN IF EXISTS "type",
+  DROP COLUMN IF EXISTS "category",
+  DROP COLUMN IF EXISTS "price";
+
+-- bookings keep the room they already have, new ones get theirs at check-in
+ALTER TABLE "bookings" ADD COLUMN IF NOT EXISTS "room_type_id" UUID;
+UPDATE "bookings" b SET "room_type_id" = r."room_type_id" FROM "rooms" r WHERE r."id" = b."room_id";
+ALTER TABLE "bookings" ALTER COLUMN "room_type_id" SET NOT NULL;
+ALTER TABLE "bookings" ADD FOREIGN KEY ("room_type_id") REFERENCES "room_types" ("id");
+CREATE INDEX IF NOT EXISTS "bookings_room_type_id_idx" ON "bookings" ("room_type_id");
+
+ALTER TABLE "bookings" ALTER COLUMN "room_id" DROP NOT NULL;
+
+INSERT INTO "casbin_rule" ("ptype", "v0", "v1", "v2") VALUES
+  ('p', 'unauthorized', '/v1/room-type/*', 'GET'),
+  ('p', 'admin', '/v1/room-type/*', 'GET|POST|PUT|DELETE')
+ON CONFLICT DO NOTHING;
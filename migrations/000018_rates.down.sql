@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS "rate_overrides";
+
+DROP TABLE IF EXISTS "rate_day_modifiers";
+
+DROP TABLE IF EXISTS "rate_seasons";
+
+ALTER TABLE "room_types" DROP CONSTRAINT IF EXISTS "room_types_min_stay_check";
+ALTER TABLE "room_types" DROP COLUMN IF EXISTS "min_stay";
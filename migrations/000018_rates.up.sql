@@ -0,0 +1,51 @@
+-- the rate calendar of a room type: the base price applies unless a season, a day-of-week
+-- modifier or an override for the night says otherwise
+ALTER TABLE "room_types" ADD COLUMN IF NOT EXISTS "min_stay" INT NOT NULL DEFAULT 1;
+ALTER TABLE "room_types" ADD CONSTRAINT "room_types_min_stay_check" CHECK ("min_stay" > 0);
+
+-- seasons may nest, e.g. a Christmas week inside winter, the one starting last wins
+CREATE TABLE IF NOT EXISTS "rate_seasons" (
+  "id" UUID PRIMARY KEY,
+  "room_type_id" UUID NOT NULL,
+  "name" VARCHAR(100) NOT NULL,
+  "start_date" DATE NOT NULL,
+  "end_date" DATE NOT NULL,
+  "price" DECIMAL(10,2) NOT NULL,
+  "min_stay" INT NOT NULL DEFAULT 0,
+  "created_at" TIMESTAMP DEFAULT (CURRENT_TIMESTAMP),
+  "updated_at" TIMESTAMP DEFAULT (CURRENT_TIMESTAMP),
+  CONSTRAINT "rate_seasons_dates_check" CHECK ("end_date" >= "start_date"),
+  CONSTRAINT "rate_seasons_price_check" CHECK ("price" > 0),
+  CONSTRAINT "rate_seasons_min_stay_check" CHECK ("min_stay" >= 0)
+);
+
+ALTER TABLE "rate_seasons" ADD FOREIGN KEY ("room_type_id") REFERENCES "room_types" ("id") ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS "rate_seasons_room_type_id_dates_idx" ON "rate_seasons" ("room_type_id", "start_date", "end_date");
+
+-- weekday follows extract(dow): 0 is Sunday
+CREATE TABLE IF NOT EXISTS "rate_day_modifiers" (
+  "room_type_id" UUID NOT NULL,
+  "weekday" SMALLINT NOT NULL,
+  "percent" DECIMAL(5,2) NOT NULL,
+  PRIMARY KEY ("room_type_id", "weekday"),
+  CONSTRAINT "rate_day_modifiers_weekday_check" CHECK ("weekday" BETWEEN 0 AND 6),
+  CONSTRAINT "rate_day_modifiers_percent_check" CHECK ("percent" > -100)
+);
+
+ALTER TABLE "rate_day_modifiers" ADD FOREIGN KEY ("room_type_id") REFERENCES "room_types" ("id") ON DELETE CASCADE;
+
+-- a price of 0 keeps the calculated price and only sets the minimum stay
+CREATE TABLE IF NOT EXISTS "rate_overrides" (
+  "room_type_id" UUID NOT NULL,
+  "date" DATE NOT NULL,
+  "price" DECIMAL(10,2) NOT NULL DEFAULT 0,
+  "min_stay" INT NOT NULL DEFAULT 0,
+  "created_at" TIMESTAMP DEFAULT (CURRENT_TIMESTAMP),
+  "updated_at" TIMESTAMP DEFAULT (CURRENT_TIMESTAMP),
+  PRIMARY KEY ("room_type_id", "date"),
+  CONSTRAINT "rate_overrides_price_check" CHECK ("price" >= 0),
+  CONSTRAINT "rate_overrides_min_stay_check" CHECK ("min_stay" >= 0)
+);
+
+ALTER TABLE "rate_overrides" ADD FOREIGN KEY ("room_type_id") REFERENCES "room_types" ("id") ON DELETE CASCADE;
@@ -0,0 +1 @@
+ALTER TABLE "sessions" DROP COLUMN IF EXISTS "two_factor_verified";
@@ -0,0 +1,6 @@
+-- refreshing an admin's tokens requires a session that signed in with a second factor
+ALTER TABLE "sessions" ADD COLUMN IF NOT EXISTS "two_factor_verified" BOOLEAN NOT NULL DEFAULT false;
+
+-- pending secrets used to be handed out for a password alone, so they can't be trusted
+UPDATE "users" SET "totp_secret" = NULL, "totp_last_step" = NULL WHERE NOT "totp_enabled";
+DELETE FROM "user_recovery_codes" c USING "users" u WHERE u."id" = c."user_id" AND NOT u."totp_enabled";
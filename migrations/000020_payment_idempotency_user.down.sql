@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS "payments_user_id_idempotency_key_key";
+-- fails if two users picked the same key
+CREATE UNIQUE INDEX IF NOT EXISTS "payments_idempotency_key_key" ON "payments" ("idempotency_key");
+
+ALTER TABLE "payments" DROP COLUMN IF EXISTS "user_id";
@@ -0,0 +1,9 @@
+-- idempotency keys are the caller's own, so one user can't get at another's payment by guessing a key
+ALTER TABLE "payments" ADD COLUMN IF NOT EXISTS "user_id" UUID;
+
+UPDATE "payments" p SET "user_id" = b."user_id" FROM "bookings" b WHERE b."id" = p."booking_id";
+
+ALTER TABLE "payments" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE SET NULL;
+
+DROP INDEX IF EXISTS "payments_idempotency_key_key";
+CREATE UNIQUE INDEX IF NOT EXISTS "payments_user_id_idempotency_key_key" ON "payments" ("user_id", "idempotency_key");
@@ -0,0 +1,7 @@
+DELETE FROM "casbin_rule" WHERE "ptype" = 'p' AND "v0" = 'receptionist' AND "v1" = '/v1/refund/:id/settle';
+
+UPDATE "payments" SET "status" = 'failed' WHERE "status" = 'voided';
+
+ALTER TABLE "payments" DROP CONSTRAINT IF EXISTS "payments_status_check";
+ALTER TABLE "payments" ADD CONSTRAINT "payments_status_check"
+  CHECK ("status" IN ('pending', 'succeeded', 'failed', 'refunded'));
@@ -0,0 +1,14 @@
+-- payments still pending when their booking is cancelled are voided instead of left to settle
+ALTER TABLE "payments" DROP CONSTRAINT IF EXISTS "payments_status_check";
+ALTER TABLE "payments" ADD CONSTRAINT "payments_status_check"
+  CHECK ("status" IN ('pending', 'succeeded', 'failed', 'refunded', 'voided'));
+
+-- payments refunded in full before refunds marked them
+UPDATE "payments" p SET "status" = 'refunded', "updated_at" = now()
+WHERE p."status" = 'succeeded'
+  AND p."amount" <= (SELECT COALESCE(SUM(f."amount"), 0) FROM "refunds" f WHERE f."payment_id" = p."id" AND f."status" = 'succeeded');
+
+-- the front desk pays back refunds of payments taken at the desk
+INSERT INTO "casbin_rule" ("ptype", "v0", "v1", "v2") VALUES
+  ('p', 'receptionist', '/v1/refund/:id/settle', 'PUT')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1 @@
+ALTER TABLE "complaints" DROP COLUMN IF EXISTS "hotel_id";
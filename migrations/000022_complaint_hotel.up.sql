@@ -0,0 +1,6 @@
+-- copied from the booking so complaints can be listed per hotel without a join
+ALTER TABLE "complaints" ADD COLUMN IF NOT EXISTS "hotel_id" UUID;
+UPDATE "complaints" c SET "hotel_id" = b."hotel_id" FROM "bookings" b WHERE b."id" = c."booking_id";
+ALTER TABLE "complaints" ALTER COLUMN "hotel_id" SET NOT NULL;
+ALTER TABLE "complaints" ADD FOREIGN KEY ("hotel_id") REFERENCES "hotels" ("id");
+CREATE INDEX IF NOT EXISTS "complaints_hotel_id_idx" ON "complaints" ("hotel_id");
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS "cancellation_policies_hotel_id_room_category_key";
+
+-- one policy per category is kept, which hotel's is arbitrary
+DELETE FROM "cancellation_policies" p USING "cancellation_policies" o
+WHERE p."room_category" = o."room_category" AND p."id" > o."id";
+
+ALTER TABLE "cancellation_policies" DROP COLUMN IF EXISTS "hotel_id";
+ALTER TABLE "cancellation_policies" ADD CONSTRAINT "cancellation_policies_room_category_key" UNIQUE ("room_category");
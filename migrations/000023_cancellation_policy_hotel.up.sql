@@ -0,0 +1,15 @@
+-- each hotel sets its own cancellation terms per room category
+ALTER TABLE "cancellation_policies" ADD COLUMN IF NOT EXISTS "hotel_id" UUID;
+ALTER TABLE "cancellation_policies" DROP CONSTRAINT IF EXISTS "cancellation_policies_room_category_key";
+
+-- the policies so far applied to every hotel, so each hotel starts with its own copy of them
+INSERT INTO "cancellation_policies" ("id", "hotel_id", "room_category", "free_cancellation_hours", "late_refund_percent", "non_refundable")
+SELECT gen_random_uuid(), h."id", p."room_category", p."free_cancellation_hours", p."late_refund_percent", p."non_refundable"
+FROM "cancellation_policies" p, "hotels" h
+WHERE p."hotel_id" IS NULL;
+
+DELETE FROM "cancellation_policies" WHERE "hotel_id" IS NULL;
+
+ALTER TABLE "cancellation_policies" ALTER COLUMN "hotel_id" SET NOT NULL;
+ALTER TABLE "cancellation_policies" ADD FOREIGN KEY ("hotel_id") REFERENCES "hotels" ("id") ON DELETE CASCADE;
+CREATE UNIQUE INDEX IF NOT EXISTS "cancellation_policies_hotel_id_room_category_key" ON "cancellation_policies" ("hotel_id", "room_category");
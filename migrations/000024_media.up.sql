@@ -0,0 +1,4 @@
+-- stored images are served through a redirect to presigned URLs, the bucket is private
+INSERT INTO "casbin_rule" ("ptype", "v0", "v1", "v2") VALUES
+  ('p', 'unauthorized', '/v1/media/*', 'GET')
+ON CONFLICT DO NOTHING;
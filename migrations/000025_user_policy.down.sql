@@ -0,0 +1,8 @@
+DELETE FROM "casbin_rule" WHERE "ptype" = 'p' AND "v0" = 'user' AND "v1" IN (
+  '/v1/user/', '/v1/user/password', '/v1/user/email', '/v1/user/email/confirm', '/v1/user/2fa', '/v1/user/2fa/confirm'
+);
+DELETE FROM "casbin_rule" WHERE "ptype" = 'p' AND "v0" = 'user' AND "v1" = '/v1/user/:id' AND "v2" = 'GET|DELETE';
+
+INSERT INTO "casbin_rule" ("ptype", "v0", "v1", "v2") VALUES
+  ('p', 'user', '/v1/user/*', 'GET|POST|PUT|DELETE')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,13 @@
+-- guests were allowed everything under /v1/user, including creating accounts and
+-- listing users, they only need to manage their own account
+DELETE FROM "casbin_rule" WHERE "ptype" = 'p' AND "v0" = 'user' AND "v1" = '/v1/user/*';
+
+INSERT INTO "casbin_rule" ("ptype", "v0", "v1", "v2") VALUES
+  ('p', 'user', '/v1/user/:id', 'GET|DELETE'),
+  ('p', 'user', '/v1/user/', 'PUT'),
+  ('p', 'user', '/v1/user/password', 'PUT'),
+  ('p', 'user', '/v1/user/email', 'POST'),
+  ('p', 'user', '/v1/user/email/confirm', 'PUT'),
+  ('p', 'user', '/v1/user/2fa', 'POST|DELETE'),
+  ('p', 'user', '/v1/user/2fa/confirm', 'PUT')
+ON CONFLICT DO NOTHING;
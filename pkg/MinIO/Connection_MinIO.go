@@ -3,36 +3,48 @@ package minio
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"log/slog"
+	"net/http"
+	"net/url"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Avazbek-02/Online-Hotel-System/config"
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/storage"
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/minio-go/v7/pkg/lifecycle"
 )
 
 type MinIO struct {
-	client *minio.Client
-	Cf     *config.Config
+	client     *minio.Client
+	publicURL  string
+	publicRead bool
+	Cf         *config.Config
 }
 
+var _ storage.Storage = (*MinIO)(nil)
+
+// incomingExpiryDays is how long an upload may wait to be imported, lifecycle rules count in days.
+const incomingExpiryDays = 1
+
 // ContentType map now supports a wider range of image formats
-var ContentType = map[string]string{
-	".png":  "image/png",
-	".jpg":  "image/jpeg",
-	".jpeg": "image/jpeg",
-	".gif":  "image/gif",
-	".bmp":  "image/bmp",
-	".webp": "image/webp",
-	".tiff": "image/tiff",
-}
+var ContentType = storage.ContentType
+
+func MinIOConnect(cf *config.Config, opts ...Option) (*MinIO, error) {
+	m := &MinIO{Cf: cf}
+	for _, opt := range opts {
+		opt(m)
+	}
 
-func MinIOConnect(cf *config.Config) (*MinIO, error) {
 	endpoint := cf.MinioUrl
 	accessKeyID := cf.MinioUser
 	secretAccessKey := cf.MinIOSecredKey
-	useSSL := false
+	useSSL := cf.MinIOUseSSL
 
 	minioClient, err := minio.New(endpoint, &minio.Options{
 		Creds:  credentials.NewStaticV4(accessKeyID, secretAccessKey, ""),
@@ -54,50 +66,149 @@ func MinIOConnect(cf *config.Config) (*MinIO, error) {
 		}
 	}
 
-	policy := fmt.Sprintf(`{
+	// uploads waiting to be imported are never public
+	policy := ""
+	if m.publicRead {
+		policy = fmt.Sprintf(`{
       "Version": "2012-10-17",
       "Statement": [
           {
               "Effect": "Allow",
               "Principal": "*",
               "Action": ["s3:GetObject"],
-              "Resource": ["arn:aws:s3:::%s/*"]
+              "Resource": ["arn:aws:s3:::%[1]s/*"]
+          },
+          {
+              "Effect": "Deny",
+              "Principal": "*",
+              "Action": ["s3:GetObject"],
+              "Resource": ["arn:aws:s3:::%[1]s/%[2]s*"]
           }
       ]
-  }`, bucketName)
+  }`, bucketName, storage.IncomingPrefix)
+	}
 
+	// an empty policy removes the one a public bucket had before
 	err = minioClient.SetBucketPolicy(context.Background(), bucketName, policy)
 	if err != nil {
 		log.Println("error while setting bucket policy : ", err)
 		return nil, err
 	}
 
-	return &MinIO{
-		client: minioClient,
-		Cf:     cf,
-	}, err
-}
+	// uploads that are never imported would stay forever
+	rules := lifecycle.NewConfiguration()
+	rules.Rules = []lifecycle.Rule{{
+		ID:         "expire-incoming",
+		Status:     "Enabled",
+		RuleFilter: lifecycle.Filter{Prefix: storage.IncomingPrefix},
+		Expiration: lifecycle.Expiration{Days: incomingExpiryDays},
+	}}
+
+	err = minioClient.SetBucketLifecycle(context.Background(), bucketName, rules)
+	if err != nil {
+		log.Println("error while setting bucket lifecycle : ", err)
+		return nil, err
+	}
 
-func (m *MinIO) Upload(fileName, filePath string) (string, error) {
-	// Fayl kengaytmasini olish
-	ext := filepath.Ext(fileName)
-	contentType, ok := ContentType[ext]
-	if !ok {
-		// Agar kengaytma mos kelmasa, default ContentType qo'yamiz
-		contentType = "application/octet-stream"
+	publicURL := cf.MinIOPublicURL
+	if publicURL == "" {
+		publicURL = m.publicURL
+	}
+	if publicURL == "" {
+		scheme := "http"
+		if useSSL {
+			scheme = "https"
+		}
+		publicURL = fmt.Sprintf("%s://%s/%s", scheme, endpoint, bucketName)
 	}
 
-	cfg, _ := config.NewConfig()
+	m.client = minioClient
+	m.publicURL = publicURL
+	return m, nil
+}
+
+// Upload streams an object into the bucket and returns its public URL. If contentType is
+// empty it is taken from the object name's extension.
+func (m *MinIO) Upload(ctx context.Context, objectName string, reader io.Reader, size int64, contentType string) (string, error) {
+	if contentType == "" {
+		// Fayl kengaytmasini olish
+		var ok bool
+		contentType, ok = ContentType[filepath.Ext(objectName)]
+		if !ok {
+			// Agar kengaytma mos kelmasa, default ContentType qo'yamiz
+			contentType = "application/octet-stream"
+		}
+	}
 
 	// Faylni MinIOga yuklash
-	_, err := m.client.FPutObject(context.Background(), cfg.MinIOBucketName, fileName, filePath, minio.PutObjectOptions{ContentType: contentType})
+	_, err := m.client.PutObject(ctx, m.Cf.MinIOBucketName, objectName, reader, size, minio.PutObjectOptions{ContentType: contentType})
 	if err != nil {
-		slog.Error("Error while uploading file", "fileName", fileName, "bucket", cfg.MinIOBucketName, "error", err)
+		slog.Error("Error while uploading file", "objectName", objectName, "bucket", m.Cf.MinIOBucketName, "error", err)
 		return "", err
 	}
 
-	// MinIO URLni yaratish
-	minioURL := fmt.Sprintf("http://localhost:9000/%s/%s", cfg.MinIOBucketName, fileName)
+	return m.PublicURL(objectName), nil
+}
+
+// Download opens an object for reading. The caller must close it.
+func (m *MinIO) Download(ctx context.Context, objectName string) (io.ReadCloser, error) {
+	object, err := m.client.GetObject(ctx, m.Cf.MinIOBucketName, objectName, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	// GetObject is lazy, Stat surfaces a missing object here instead of on the first Read
+	if _, err = object.Stat(); err != nil {
+		object.Close()
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, storage.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return object, nil
+}
+
+// PublicURL returns the URL an object is served from.
+func (m *MinIO) PublicURL(objectName string) string {
+	return strings.TrimRight(m.publicURL, "/") + "/" + objectName
+}
+
+// PresignedPutURL returns a URL a client can upload an object to directly, valid for MinIOPresignExpiry.
+// Content-Length and Content-Type are part of the signature, so the upload can't be larger than size.
+func (m *MinIO) PresignedPutURL(ctx context.Context, objectName string, size int64, contentType string) (string, time.Time, error) {
+	expiry := m.Cf.MinIOPresignExpiry
+	headers := http.Header{
+		"Content-Length": {strconv.FormatInt(size, 10)},
+		"Content-Type":   {contentType},
+	}
+
+	u, err := m.client.PresignHeader(ctx, http.MethodPut, m.Cf.MinIOBucketName, objectName, expiry, nil, headers)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return u.String(), time.Now().Add(expiry), nil
+}
+
+// PresignedGetURL returns a temporary URL to download a private object, valid for MinIOPresignExpiry.
+func (m *MinIO) PresignedGetURL(ctx context.Context, objectName string) (string, time.Time, error) {
+	expiry := m.Cf.MinIOPresignExpiry
+	u, err := m.client.PresignedGetObject(ctx, m.Cf.MinIOBucketName, objectName, expiry, url.Values{})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return u.String(), time.Now().Add(expiry), nil
+}
+
+// Delete removes an object from the bucket.
+func (m *MinIO) Delete(ctx context.Context, objectName string) error {
+	err := m.client.RemoveObject(ctx, m.Cf.MinIOBucketName, objectName, minio.RemoveObjectOptions{})
+	if err != nil {
+		slog.Error("Error while deleting file", "objectName", objectName, "bucket", m.Cf.MinIOBucketName, "error", err)
+		return err
+	}
 
-	return minioURL, nil
+	return nil
 }
@@ -0,0 +1,20 @@
+package minio
+
+// Option -.
+type Option func(*MinIO)
+
+// PublicRead makes every object in the bucket readable without credentials, so the
+// stored URLs can be handed to clients as they are.
+func PublicRead() Option {
+	return func(m *MinIO) {
+		m.publicRead = true
+	}
+}
+
+// MediaURL serves objects from url unless MinIOPublicURL is set, e.g. from the API route that
+// redirects to presigned URLs when the bucket is private.
+func MediaURL(url string) Option {
+	return func(m *MinIO) {
+		m.publicURL = url
+	}
+}
@@ -61,6 +61,31 @@ func GenerateOtpEmailBody(otp string) (string, error) {
 	return builder.String(), nil
 }
 
+// GeneratePasswordResetEmailBody generates the HTML email body with a password reset code
+func GeneratePasswordResetEmailBody(otp string) (string, error) {
+	templateString := `
+<!DOCTYPE html>
+<html>
+<body>
+    <p>Your code to reset your password is {{.Code}}. If you did not ask for it, you can ignore this email.</p>
+</body>
+</html>
+`
+	tmpl, err := template.New("email").Parse(templateString)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse email template: %w", err)
+	}
+	otpData := Otp{otp}
+
+	var builder strings.Builder
+	err = tmpl.Execute(&builder, otpData)
+	if err != nil {
+		return "", fmt.Errorf("failed to execute email template: %w", err)
+	}
+
+	return builder.String(), nil
+}
+
 // sendEmail sends an email using SMTP
 func SendEmail(smtpHost, smtpPort, from, password, to, body string) error {
 	auth := smtp.PlainAuth("", from, password, smtpHost)
@@ -1,16 +1,21 @@
 package etc
 
 import (
-	"math/rand"
-	"time"
+	"crypto/rand"
+	"math/big"
 )
 
-func GenerateOTP(length int) string {
+// GenerateOTP returns a numeric code of the given length drawn from crypto/rand.
+func GenerateOTP(length int) (string, error) {
 	const charset = "0123456789"
-	seededRand := rand.New(rand.NewSource(time.Now().UnixNano()))
+	max := big.NewInt(int64(len(charset)))
 	otp := make([]byte, length)
 	for i := range otp {
-		otp[i] = charset[seededRand.Intn(len(charset))]
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		otp[i] = charset[n.Int64()]
 	}
-	return string(otp)
+	return string(otp), nil
 }
@@ -0,0 +1,35 @@
+package etc
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/base64"
+	"strings"
+)
+
+// GenerateToken returns a random, URL safe token of 32 bytes of entropy.
+func GenerateToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// GenerateRecoveryCode returns a random code like "k3f9-x2qa-7bde" that is easy to
+// write down. Compare codes after NormalizeRecoveryCode.
+func GenerateRecoveryCode() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	code := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b))[:12]
+	return code[:4] + "-" + code[4:8] + "-" + code[8:], nil
+}
+
+// NormalizeRecoveryCode strips what people add or change when typing a recovery code.
+func NormalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
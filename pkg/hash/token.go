@@ -0,0 +1,13 @@
+package hash
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// HashToken returns the SHA-256 hex digest of a random token. Unlike passwords, tokens
+// carry enough entropy that a fast hash is sufficient and lets them be looked up.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,159 @@
+// Package imageproc validates uploaded images and renders the resized variants that are stored.
+package imageproc
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif" // register decoders for image.Decode
+	"image/jpeg"
+	_ "image/png"
+
+	xdraw "golang.org/x/image/draw"
+
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	_defaultQuality  = 85
+	_defaultMaxPixel = 24_000_000 // Refuse to decode anything larger, e.g. 6000x4000, it would take too much memory
+
+	// ContentType of every variant produced by Process.
+	ContentType = "image/jpeg"
+	// Extension of every variant produced by Process.
+	Extension = ".jpg"
+)
+
+var (
+	// ErrTypeMismatch is returned when the bytes are not the image type the upload claims to be.
+	ErrTypeMismatch = errors.New("file content does not match its type")
+	// ErrTooLarge is returned for images with more pixels than we are willing to decode.
+	ErrTooLarge = errors.New("image dimensions are too large")
+)
+
+// decodeSlot lets one image be decoded at a time. At the pixel limit a decoded image takes
+// about 100 MB and is copied while it is flattened and oriented, concurrent uploads would
+// multiply that.
+var decodeSlot = make(chan struct{}, 1)
+
+// Variant is a size an uploaded image is rendered at. The image is scaled down to fit
+// into a MaxSize x MaxSize box, smaller images are never scaled up.
+type Variant struct {
+	Name    string
+	MaxSize int
+}
+
+// Variants are rendered for every upload, smallest first.
+var Variants = []Variant{
+	{Name: "thumbnail", MaxSize: 320},
+	{Name: "medium", MaxSize: 1024},
+	{Name: "full", MaxSize: 2560},
+}
+
+// Image is one rendered variant.
+type Image struct {
+	Variant string
+	Width   int
+	Height  int
+	Data    []byte
+}
+
+// Sniff returns the content type of an image from its leading bytes, or "" if it is none we accept.
+func Sniff(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return "image/png"
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8, 0xFF}):
+		return "image/jpeg"
+	case bytes.HasPrefix(data, []byte("GIF87a")), bytes.HasPrefix(data, []byte("GIF89a")):
+		return "image/gif"
+	case bytes.HasPrefix(data, []byte("BM")):
+		return "image/bmp"
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return "image/webp"
+	case bytes.HasPrefix(data, []byte("II*\x00")), bytes.HasPrefix(data, []byte("MM\x00*")):
+		return "image/tiff"
+	default:
+		return ""
+	}
+}
+
+// Process checks that data really is an image of declaredType and renders every Variant
+// as a JPEG. Re-encoding drops all metadata (EXIF, GPS, ICC), the EXIF orientation of
+// JPEG photos is applied to the pixels first so they keep facing the right way.
+func Process(data []byte, declaredType string) ([]Image, error) {
+	if Sniff(data) != declaredType {
+		return nil, ErrTypeMismatch
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width*cfg.Height > _defaultMaxPixel {
+		return nil, ErrTooLarge
+	}
+
+	decodeSlot <- struct{}{}
+	defer func() { <-decodeSlot }()
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	img := flatten(src)
+	if declaredType == "image/jpeg" {
+		img = orient(img, jpegOrientation(data))
+	}
+
+	images := make([]Image, 0, len(Variants))
+	for _, variant := range Variants {
+		resized := fit(img, variant.MaxSize)
+
+		var buf bytes.Buffer
+		if err = jpeg.Encode(&buf, resized, &jpeg.Options{Quality: _defaultQuality}); err != nil {
+			return nil, err
+		}
+
+		images = append(images, Image{
+			Variant: variant.Name,
+			Width:   resized.Bounds().Dx(),
+			Height:  resized.Bounds().Dy(),
+			Data:    buf.Bytes(),
+		})
+	}
+
+	return images, nil
+}
+
+// flatten draws img onto a white background, JPEG has no transparency.
+func flatten(img image.Image) *image.RGBA {
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Over)
+	return dst
+}
+
+// fit scales img down to fit into a maxSize x maxSize box, keeping the aspect ratio.
+func fit(img *image.RGBA, maxSize int) *image.RGBA {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	if w <= maxSize && h <= maxSize {
+		return img
+	}
+
+	if w >= h {
+		w, h = maxSize, max(1, h*maxSize/w)
+	} else {
+		w, h = max(1, w*maxSize/h), maxSize
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), img, img.Bounds(), xdraw.Src, nil)
+	return dst
+}
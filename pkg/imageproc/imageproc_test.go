@@ -0,0 +1,220 @@
+package imageproc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+var (
+	red  = color.RGBA{R: 255, A: 255}
+	blue = color.RGBA{B: 255, A: 255}
+)
+
+// halves returns a w x h image whose left half is red and right half is blue.
+func halves(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if x < w/2 {
+				img.SetRGBA(x, y, red)
+			} else {
+				img.SetRGBA(x, y, blue)
+			}
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// encodeJPEG encodes img with an Exif segment holding the orientation, 0 leaves it out.
+func encodeJPEG(t *testing.T, img image.Image, orientation int) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 100}); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	if orientation == 0 {
+		return data
+	}
+
+	// little endian TIFF header and an IFD0 with the orientation as its only entry
+	tiff := []byte("II*\x00\x08\x00\x00\x00\x01\x00")
+	tiff = binary.LittleEndian.AppendUint16(tiff, _exifOrientationTag)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 3) // SHORT
+	tiff = binary.LittleEndian.AppendUint32(tiff, 1)
+	tiff = binary.LittleEndian.AppendUint16(tiff, uint16(orientation))
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0) // value padding and no next IFD
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1}
+	app1 = binary.BigEndian.AppendUint16(app1, uint16(len(segment)+2))
+	app1 = append(app1, segment...)
+
+	// right after SOI
+	return append(append(append([]byte{}, data[:2]...), app1...), data[2:]...)
+}
+
+func variant(t *testing.T, images []Image, name string) Image {
+	t.Helper()
+
+	for _, img := range images {
+		if img.Variant == name {
+			return img
+		}
+	}
+	t.Fatalf("no %s variant", name)
+	return Image{}
+}
+
+func isRed(c color.Color) bool {
+	r, g, b, _ := c.RGBA()
+	return r > 0xC000 && g < 0x4000 && b < 0x4000
+}
+
+func TestProcessResizesVariants(t *testing.T) {
+	images, err := Process(encodePNG(t, halves(1600, 800)), "image/png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		variant       string
+		width, height int
+	}{
+		{"thumbnail", 320, 160},
+		{"medium", 1024, 512},
+		{"full", 1600, 800}, // never scaled up
+	}
+
+	for _, tt := range tests {
+		img := variant(t, images, tt.variant)
+		if img.Width != tt.width || img.Height != tt.height {
+			t.Errorf("%s is %dx%d, want %dx%d", tt.variant, img.Width, img.Height, tt.width, tt.height)
+		}
+
+		decoded, format, err := image.Decode(bytes.NewReader(img.Data))
+		if err != nil {
+			t.Fatalf("%s doesn't decode: %v", tt.variant, err)
+		}
+		if format != "jpeg" || decoded.Bounds().Dx() != tt.width || decoded.Bounds().Dy() != tt.height {
+			t.Errorf("%s decodes as %s %v", tt.variant, format, decoded.Bounds())
+		}
+	}
+}
+
+func TestProcessAppliesOrientation(t *testing.T) {
+	tests := []struct {
+		orientation   int
+		width, height int
+		redAt         image.Point // a pixel the red half ends up at
+	}{
+		{0, 16, 8, image.Pt(2, 4)},
+		{1, 16, 8, image.Pt(2, 4)},
+		{3, 16, 8, image.Pt(13, 4)}, // rotated 180, red is on the right
+		{6, 8, 16, image.Pt(4, 2)},  // rotated 90 clockwise, red is on top
+		{8, 8, 16, image.Pt(4, 13)}, // rotated 90 counter-clockwise, red is at the bottom
+	}
+
+	for _, tt := range tests {
+		images, err := Process(encodeJPEG(t, halves(16, 8), tt.orientation), "image/jpeg")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		full := variant(t, images, "full")
+		decoded, err := jpeg.Decode(bytes.NewReader(full.Data))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if b := decoded.Bounds(); b.Dx() != tt.width || b.Dy() != tt.height {
+			t.Errorf("orientation %d: image is %dx%d, want %dx%d", tt.orientation, b.Dx(), b.Dy(), tt.width, tt.height)
+			continue
+		}
+		if !isRed(decoded.At(tt.redAt.X, tt.redAt.Y)) {
+			t.Errorf("orientation %d: pixel %v is %v, want red", tt.orientation, tt.redAt, decoded.At(tt.redAt.X, tt.redAt.Y))
+		}
+	}
+}
+
+func TestOrient(t *testing.T) {
+	// 3x2 with a marked top-left pixel, the corner it is shown at after orienting
+	src := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	src.SetRGBA(0, 0, red)
+
+	tests := []struct {
+		orientation int
+		corner      image.Point
+		swapped     bool
+	}{
+		{1, image.Pt(0, 0), false},
+		{2, image.Pt(2, 0), false},
+		{3, image.Pt(2, 1), false},
+		{4, image.Pt(0, 1), false},
+		{5, image.Pt(0, 0), true},
+		{6, image.Pt(1, 0), true},
+		{7, image.Pt(1, 2), true},
+		{8, image.Pt(0, 2), true},
+	}
+
+	for _, tt := range tests {
+		dst := orient(src, tt.orientation)
+
+		w, h := 3, 2
+		if tt.swapped {
+			w, h = h, w
+		}
+		if dst.Bounds().Dx() != w || dst.Bounds().Dy() != h {
+			t.Errorf("orientation %d: size %v, want %dx%d", tt.orientation, dst.Bounds().Size(), w, h)
+			continue
+		}
+		if dst.RGBAAt(tt.corner.X, tt.corner.Y) != red {
+			t.Errorf("orientation %d: top-left pixel isn't at %v", tt.orientation, tt.corner)
+		}
+	}
+}
+
+func TestProcessRejects(t *testing.T) {
+	// a PNG header claiming 6000x4001 pixels, nothing is decoded past it
+	ihdr := binary.BigEndian.AppendUint32([]byte("IHDR"), 6000)
+	ihdr = binary.BigEndian.AppendUint32(ihdr, 4001)
+	ihdr = append(ihdr, 8, 2, 0, 0, 0)
+	huge := binary.BigEndian.AppendUint32([]byte("\x89PNG\r\n\x1a\n"), uint32(len(ihdr)-4))
+	huge = append(huge, ihdr...)
+	huge = binary.BigEndian.AppendUint32(huge, crc32.ChecksumIEEE(ihdr))
+
+	tests := []struct {
+		name         string
+		data         []byte
+		declaredType string
+		err          error
+	}{
+		{"png declared as jpeg", encodePNG(t, halves(4, 4)), "image/jpeg", ErrTypeMismatch},
+		{"not an image", []byte("hello"), "image/png", ErrTypeMismatch},
+		{"too many pixels", huge, "image/png", ErrTooLarge},
+	}
+
+	for _, tt := range tests {
+		if _, err := Process(tt.data, tt.declaredType); !errors.Is(err, tt.err) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package otp
+
+import "time"
+
+// Option -.
+type Option func(*Store)
+
+// TTL sets how long an issued code stays valid.
+func TTL(ttl time.Duration) Option {
+	return func(s *Store) {
+		s.ttl = ttl
+	}
+}
+
+// MaxAttempts sets how many wrong guesses lock a client out. A code is burnt after
+// four times as many guesses from all clients together.
+func MaxAttempts(attempts int) Option {
+	return func(s *Store) {
+		s.maxAttempts = attempts
+	}
+}
+
+// Cooldown sets the minimum time between two codes issued to the same subject.
+func Cooldown(cooldown time.Duration) Option {
+	return func(s *Store) {
+		s.cooldown = cooldown
+	}
+}
+
+// Lockout sets the first lockout and the longest one. Every further lockout within a
+// day doubles the previous one.
+func Lockout(base, max time.Duration) Option {
+	return func(s *Store) {
+		s.lockoutBase = base
+		s.lockoutMax = max
+	}
+}
@@ -0,0 +1,284 @@
+// Package otp issues and verifies one-time codes kept in Redis, with a cap on wrong
+// guesses, lockouts that back off exponentially and a cooldown between codes.
+//
+// Wrong guesses are counted and locked out per client, e.g. per IP address, so that
+// guessing at someone else's codes can't lock them out of their own. Each issued code
+// also takes only so many guesses from all clients together before it is burnt, and a
+// subject only so many guesses in a row from all clients together, which keeps clients
+// that change their address from guessing through codes that aren't issued here.
+package otp
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/etc"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	_defaultLength      = 6
+	_defaultTTL         = 10 * time.Minute
+	_defaultMaxAttempts = 5
+	_defaultCooldown    = time.Minute
+	_defaultLockoutBase = 5 * time.Minute
+	_defaultLockoutMax  = 24 * time.Hour
+
+	// lockouts are remembered this long for the backoff
+	_lockoutWindow = 24 * time.Hour
+
+	// guesses at one issued code from all clients together, in multiples of the attempt cap
+	_codeGuessesPerAttempt = 4
+	// guesses at a subject from all clients together, in multiples of the attempt cap
+	_subjectGuessesPerAttempt = 4
+)
+
+var (
+	// ErrInvalidCode is returned for a wrong, expired or never issued code.
+	ErrInvalidCode = errors.New("otp: invalid or expired code")
+	// ErrLocked is returned while a client is locked out of a subject after too many wrong codes.
+	ErrLocked = errors.New("otp: too many attempts")
+	// ErrCooldown is returned when a new code is requested too soon after the last one.
+	ErrCooldown = errors.New("otp: code requested too recently")
+)
+
+// waitError carries how long the caller has to wait before trying again.
+type waitError struct {
+	err  error
+	wait time.Duration
+}
+
+func (e *waitError) Error() string { return e.err.Error() }
+
+func (e *waitError) Unwrap() error { return e.err }
+
+// RetryAfter returns how long to wait before retrying after ErrLocked or ErrCooldown.
+func RetryAfter(err error) (time.Duration, bool) {
+	var w *waitError
+	if errors.As(err, &w) {
+		return w.wait, true
+	}
+	return 0, false
+}
+
+// Store -.
+type Store struct {
+	client      *redis.Client
+	ttl         time.Duration
+	maxAttempts int
+	cooldown    time.Duration
+	lockoutBase time.Duration
+	lockoutMax  time.Duration
+}
+
+// New -.
+func New(client *redis.Client, opts ...Option) *Store {
+	s := &Store{
+		client:      client,
+		ttl:         _defaultTTL,
+		maxAttempts: _defaultMaxAttempts,
+		cooldown:    _defaultCooldown,
+		lockoutBase: _defaultLockoutBase,
+		lockoutMax:  _defaultLockoutMax,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// key namespaces codes by purpose, so a code sent to verify an email can't reset a password.
+func key(purpose, subject, suffix string) string {
+	return fmt.Sprintf("otp:%s:%s:%s", purpose, subject, suffix)
+}
+
+// clientKey is key for what is kept per client of a subject.
+func clientKey(purpose, subject, client, suffix string) string {
+	return fmt.Sprintf("otp:%s:%s:%s:%s", purpose, subject, client, suffix)
+}
+
+// Issue generates a new code for subject, replacing any previous one, and hands it to
+// send. The code is only kept, and the cooldown only runs, once send succeeded, so a
+// failed delivery can be retried right away.
+func (s *Store) Issue(ctx context.Context, purpose, subject string, send func(code string) error) error {
+	// taken before sending, so that of two concurrent requests only one sends a code
+	ok, err := s.client.SetNX(ctx, key(purpose, subject, "cooldown"), 1, s.cooldown).Result()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		wait, err := s.client.TTL(ctx, key(purpose, subject, "cooldown")).Result()
+		if err != nil {
+			return err
+		}
+		return &waitError{err: ErrCooldown, wait: wait}
+	}
+
+	code, err := etc.GenerateOTP(_defaultLength)
+	if err == nil {
+		err = send(code)
+	}
+	if err != nil {
+		if delErr := s.client.Del(ctx, key(purpose, subject, "cooldown")).Err(); delErr != nil {
+			return errors.Join(err, delErr)
+		}
+		return err
+	}
+
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, key(purpose, subject, "code"), code, s.ttl)
+		pipe.Del(ctx, key(purpose, subject, "guesses"))
+		return nil
+	})
+	return err
+}
+
+// Verify checks a code client entered and consumes it on success.
+func (s *Store) Verify(ctx context.Context, purpose, subject, client, code string) error {
+	return s.VerifyFunc(ctx, purpose, subject, client, func() (bool, error) {
+		// enough clients together could otherwise guess their way through the code space
+		var guesses *redis.IntCmd
+		_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			guesses = pipe.Incr(ctx, key(purpose, subject, "guesses"))
+			pipe.Expire(ctx, key(purpose, subject, "guesses"), s.ttl)
+			return nil
+		})
+		if err != nil {
+			return false, err
+		}
+		if guesses.Val() > int64(s.maxAttempts*_codeGuessesPerAttempt) {
+			return false, s.client.Del(ctx, key(purpose, subject, "code")).Err()
+		}
+
+		stored, err := s.client.Get(ctx, key(purpose, subject, "code")).Result()
+		if err == redis.Nil {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+
+		if subtle.ConstantTimeCompare([]byte(stored), []byte(code)) != 1 {
+			return false, nil
+		}
+
+		// only the request that deletes the code consumes it
+		deleted, err := s.client.Del(ctx, key(purpose, subject, "code")).Result()
+		return deleted > 0, err
+	})
+}
+
+// VerifyFunc runs check under the same attempt cap and lockouts as Verify, for codes
+// that are not issued by the store, e.g. authenticator apps. Attempts are counted before
+// check runs, so no more than the configured number of guesses is ever checked for a
+// client, however many requests race. The guess that exhausts them locks the client out.
+// All clients together get a few times as many guesses at a subject until one of them
+// enters a right code, more are refused with ErrLocked until the last one is a code TTL old.
+// A right code clears the subject's guesses and the client's attempts and lockouts.
+func (s *Store) VerifyFunc(ctx context.Context, purpose, subject, client string, check func() (bool, error)) error {
+	if err := s.checkLock(ctx, purpose, subject, client); err != nil {
+		return err
+	}
+
+	var attempts, guesses *redis.IntCmd
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		attempts = pipe.Incr(ctx, clientKey(purpose, subject, client, "attempts"))
+		pipe.Expire(ctx, clientKey(purpose, subject, client, "attempts"), s.ttl)
+		guesses = pipe.Incr(ctx, key(purpose, subject, "subject-guesses"))
+		pipe.Expire(ctx, key(purpose, subject, "subject-guesses"), s.ttl)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if guesses.Val() > int64(s.maxAttempts*_subjectGuessesPerAttempt) {
+		return &waitError{err: ErrLocked, wait: s.ttl}
+	}
+
+	// an earlier guess used up the attempts
+	if attempts.Val() > int64(s.maxAttempts) {
+		if err = s.checkLock(ctx, purpose, subject, client); err != nil {
+			return err
+		}
+		return ErrInvalidCode
+	}
+
+	ok, err := check()
+	if err != nil {
+		return err
+	}
+
+	if ok {
+		return s.client.Del(ctx, key(purpose, subject, "subject-guesses"),
+			clientKey(purpose, subject, client, "attempts"), clientKey(purpose, subject, client, "lockouts")).Err()
+	}
+
+	if attempts.Val() == int64(s.maxAttempts) {
+		return s.lock(ctx, purpose, subject, client)
+	}
+
+	return ErrInvalidCode
+}
+
+// Fail counts a failure of subject, e.g. of a login challenge, and returns how many there
+// were, the last one less than a code TTL ago.
+func (s *Store) Fail(ctx context.Context, purpose, subject string) (int, error) {
+	var failures *redis.IntCmd
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		failures = pipe.Incr(ctx, key(purpose, subject, "failures"))
+		pipe.Expire(ctx, key(purpose, subject, "failures"), s.ttl)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return int(failures.Val()), nil
+}
+
+// checkLock returns ErrLocked while client is locked out of subject.
+func (s *Store) checkLock(ctx context.Context, purpose, subject, client string) error {
+	wait, err := s.client.TTL(ctx, clientKey(purpose, subject, client, "lock")).Result()
+	if err != nil {
+		return err
+	}
+	if wait > 0 {
+		return &waitError{err: ErrLocked, wait: wait}
+	}
+	return nil
+}
+
+// lock locks client out of subject, twice as long as the last time.
+func (s *Store) lock(ctx context.Context, purpose, subject, client string) error {
+	var lockouts *redis.IntCmd
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		lockouts = pipe.Incr(ctx, clientKey(purpose, subject, client, "lockouts"))
+		pipe.Expire(ctx, clientKey(purpose, subject, client, "lockouts"), _lockoutWindow)
+		pipe.Del(ctx, clientKey(purpose, subject, client, "attempts"))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	wait := s.lockoutBase
+	for i := int64(1); i < lockouts.Val() && wait < s.lockoutMax; i++ {
+		wait *= 2
+	}
+	if wait > s.lockoutMax {
+		wait = s.lockoutMax
+	}
+
+	err = s.client.Set(ctx, clientKey(purpose, subject, client, "lock"), 1, wait).Err()
+	if err != nil {
+		return err
+	}
+
+	return &waitError{err: ErrLocked, wait: wait}
+}
@@ -0,0 +1,28 @@
+package rbac
+
+import "time"
+
+// Option -.
+type Option func(*Enforcer)
+
+// Channel sets the Redis channel changes are announced on.
+func Channel(channel string) Option {
+	return func(e *Enforcer) {
+		e.channel = channel
+	}
+}
+
+// ReloadInterval sets how often the policy is reloaded regardless of announcements.
+// Zero or less turns the periodic reload off.
+func ReloadInterval(interval time.Duration) Option {
+	return func(e *Enforcer) {
+		e.reloadInterval = interval
+	}
+}
+
+// OnError sets the function Watch reports failed reloads to.
+func OnError(onError func(error)) Option {
+	return func(e *Enforcer) {
+		e.onError = onError
+	}
+}
@@ -0,0 +1,154 @@
+// Package rbac wraps a Casbin enforcer whose policy lives in a shared store. A change
+// made on one instance is announced on a Redis channel and every other instance reloads
+// the policy from the store, with a periodic reload in case an announcement is missed.
+package rbac
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/casbin/casbin"
+	"github.com/casbin/casbin/persist"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	_defaultChannel        = "casbin-policy-changed"
+	_defaultReloadInterval = 5 * time.Minute
+)
+
+// Enforcer -.
+type Enforcer struct {
+	// a reload builds a new enforcer and swaps it in, so one that fails leaves the last policy in place
+	current        atomic.Pointer[casbin.SyncedEnforcer]
+	loadMu         sync.Mutex // keeps an older policy from being swapped in over a newer one
+	modelPath      string
+	adapter        persist.Adapter
+	client         *redis.Client
+	channel        string
+	reloadInterval time.Duration
+	onError        func(error)
+
+	// tells this instance's announcements apart from the others'
+	instanceID string
+}
+
+// New creates an enforcer for the model file and loads the policy from the adapter.
+// The client may be nil, in which case changes are only picked up by the periodic reload.
+func New(modelPath string, adapter persist.Adapter, client *redis.Client, opts ...Option) (*Enforcer, error) {
+	e := &Enforcer{
+		modelPath:      modelPath,
+		adapter:        adapter,
+		client:         client,
+		channel:        _defaultChannel,
+		reloadInterval: _defaultReloadInterval,
+		onError:        func(error) {},
+		instanceID:     uuid.NewString(),
+	}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	if err := e.load(); err != nil {
+		return nil, fmt.Errorf("rbac - New - %w", err)
+	}
+
+	return e, nil
+}
+
+// load builds an enforcer with the policy in the store and makes it the current one.
+func (e *Enforcer) load() error {
+	e.loadMu.Lock()
+	defer e.loadMu.Unlock()
+
+	// without an adapter the enforcer loads nothing on creation, whose errors it would swallow
+	synced, err := casbin.NewSyncedEnforcerSafe(e.modelPath)
+	if err != nil {
+		return fmt.Errorf("casbin.NewSyncedEnforcerSafe: %w", err)
+	}
+
+	synced.SetAdapter(e.adapter)
+	if err = synced.LoadPolicy(); err != nil {
+		return fmt.Errorf("LoadPolicy: %w", err)
+	}
+
+	e.current.Store(synced)
+	return nil
+}
+
+// EnforceSafe decides whether sub may perform act on obj.
+func (e *Enforcer) EnforceSafe(sub, obj, act string) (bool, error) {
+	return e.current.Load().EnforceSafe(sub, obj, act)
+}
+
+// GetPolicy returns the permission rules.
+func (e *Enforcer) GetPolicy() [][]string {
+	return e.current.Load().GetPolicy()
+}
+
+// GetGroupingPolicy returns the role inheritance rules.
+func (e *Enforcer) GetGroupingPolicy() [][]string {
+	return e.current.Load().GetGroupingPolicy()
+}
+
+// GetImplicitRolesForUser returns the roles a role inherits, directly or not.
+func (e *Enforcer) GetImplicitRolesForUser(name string) []string {
+	return e.current.Load().GetImplicitRolesForUser(name)
+}
+
+// Changed reloads the policy after it was changed in the store and tells the other instances to do the same.
+func (e *Enforcer) Changed(ctx context.Context) error {
+	if err := e.load(); err != nil {
+		return err
+	}
+
+	if e.client == nil {
+		return nil
+	}
+
+	return e.client.Publish(ctx, e.channel, e.instanceID).Err()
+}
+
+// Watch reloads the policy whenever another instance announces a change and every
+// reload interval, unless the interval is not positive. It blocks until ctx is done.
+func (e *Enforcer) Watch(ctx context.Context) {
+	var messages <-chan *redis.Message
+	if e.client != nil {
+		sub := e.client.Subscribe(ctx, e.channel)
+		defer sub.Close()
+
+		messages = sub.Channel()
+	}
+
+	var ticks <-chan time.Time
+	if e.reloadInterval > 0 {
+		ticker := time.NewTicker(e.reloadInterval)
+		defer ticker.Stop()
+
+		ticks = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			if msg.Payload == e.instanceID {
+				continue
+			}
+		case <-ticks:
+		}
+
+		if err := e.load(); err != nil {
+			e.onError(fmt.Errorf("rbac - Watch - %w", err))
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/casbin/casbin/model"
+	"github.com/casbin/casbin/persist"
+)
+
+const testModel = "../../config/rbac.conf"
+
+// adapter serves a fixed policy, or err when set.
+type adapter struct {
+	lines []string
+	err   error
+}
+
+func (a *adapter) LoadPolicy(m model.Model) error {
+	if a.err != nil {
+		return a.err
+	}
+
+	for _, line := range a.lines {
+		persist.LoadPolicyLine(line, m)
+	}
+	return nil
+}
+
+func (a *adapter) SavePolicy(model.Model) error                              { return nil }
+func (a *adapter) AddPolicy(string, string, []string) error                  { return nil }
+func (a *adapter) RemovePolicy(string, string, []string) error               { return nil }
+func (a *adapter) RemoveFilteredPolicy(string, string, int, ...string) error { return nil }
+
+func TestFailedReloadKeepsPolicy(t *testing.T) {
+	store := &adapter{lines: []string{
+		"p, admin, /v1/room/*, GET",
+		"g, manager, admin",
+	}}
+
+	e, err := New(testModel, store, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store.err = errors.New("connection refused")
+	if err = e.Changed(context.Background()); err == nil {
+		t.Fatal("Changed returned no error for a failing store")
+	}
+
+	ok, err := e.EnforceSafe("manager", "/v1/room/list", "GET")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Error("policy was lost after a failed reload")
+	}
+
+	store.err = nil
+	store.lines = []string{"p, admin, /v1/hotel/*, GET"}
+	if err = e.Changed(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if ok, _ = e.EnforceSafe("admin", "/v1/room/list", "GET"); ok {
+		t.Error("reload didn't replace the policy")
+	}
+	if ok, _ = e.EnforceSafe("admin", "/v1/hotel/list", "GET"); !ok {
+		t.Error("reload didn't load the new policy")
+	}
+}
+
+func TestNewFailsOnStoreError(t *testing.T) {
+	if _, err := New(testModel, &adapter{err: errors.New("connection refused")}, nil); err == nil {
+		t.Error("New returned no error for a failing store")
+	}
+}
+
+func TestWatchWithoutReloadInterval(t *testing.T) {
+	e, err := New(testModel, &adapter{}, nil, ReloadInterval(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	e.Watch(ctx)
+}
@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Local keeps objects as files under a directory. It is meant for development, the
+// directory has to be served at baseURL by something else, e.g. gin's Static.
+type Local struct {
+	root    string
+	baseURL string
+}
+
+var _ Storage = (*Local)(nil)
+
+// NewLocal -.
+func NewLocal(root, baseURL string) (*Local, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &Local{root: root, baseURL: strings.TrimRight(baseURL, "/")}, nil
+}
+
+// Root returns the directory objects are stored in.
+func (l *Local) Root() string {
+	return l.root
+}
+
+// path maps an object name into the root directory, refusing names that would escape it.
+func (l *Local) path(objectName string) (string, error) {
+	clean := filepath.Clean("/" + objectName)
+	if clean == "/" {
+		return "", errors.New("storage - Local - empty object name")
+	}
+
+	return filepath.Join(l.root, filepath.FromSlash(clean)), nil
+}
+
+// Upload writes the object to a temporary file first, so readers never see a partial file.
+func (l *Local) Upload(ctx context.Context, objectName string, reader io.Reader, size int64, contentType string) (string, error) {
+	path, err := l.path(objectName)
+	if err != nil {
+		return "", err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = io.Copy(tmp, reader); err != nil {
+		tmp.Close()
+		return "", err
+	}
+
+	if err = tmp.Close(); err != nil {
+		return "", err
+	}
+
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return "", err
+	}
+
+	return l.PublicURL(objectName), nil
+}
+
+func (l *Local) Download(ctx context.Context, objectName string) (io.ReadCloser, error) {
+	path, err := l.path(objectName)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+
+	return f, err
+}
+
+func (l *Local) Delete(ctx context.Context, objectName string) error {
+	path, err := l.path(objectName)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
+
+func (l *Local) PublicURL(objectName string) string {
+	return l.baseURL + "/" + strings.TrimLeft(objectName, "/")
+}
+
+func (l *Local) PresignedPutURL(ctx context.Context, objectName string, size int64, contentType string) (string, time.Time, error) {
+	return "", time.Time{}, ErrNotSupported
+}
+
+func (l *Local) PresignedGetURL(ctx context.Context, objectName string) (string, time.Time, error) {
+	return "", time.Time{}, ErrNotSupported
+}
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Memory keeps objects in a map. It is meant for tests.
+type Memory struct {
+	mu      sync.RWMutex
+	objects map[string]MemoryObject
+	baseURL string
+}
+
+// MemoryObject is an object held by Memory.
+type MemoryObject struct {
+	Data        []byte
+	ContentType string
+}
+
+var _ Storage = (*Memory)(nil)
+
+// NewMemory -.
+func NewMemory(baseURL string) *Memory {
+	return &Memory{
+		objects: map[string]MemoryObject{},
+		baseURL: strings.TrimRight(baseURL, "/"),
+	}
+}
+
+// Object returns a stored object, for assertions in tests.
+func (m *Memory) Object(objectName string) (MemoryObject, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	object, ok := m.objects[objectName]
+	return object, ok
+}
+
+// Len returns the number of stored objects.
+func (m *Memory) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return len(m.objects)
+}
+
+func (m *Memory) Upload(ctx context.Context, objectName string, reader io.Reader, size int64, contentType string) (string, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+
+	m.mu.Lock()
+	m.objects[objectName] = MemoryObject{Data: data, ContentType: contentTypeOf(objectName, contentType)}
+	m.mu.Unlock()
+
+	return m.PublicURL(objectName), nil
+}
+
+func (m *Memory) Download(ctx context.Context, objectName string) (io.ReadCloser, error) {
+	object, ok := m.Object(objectName)
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return io.NopCloser(bytes.NewReader(object.Data)), nil
+}
+
+func (m *Memory) Delete(ctx context.Context, objectName string) error {
+	m.mu.Lock()
+	delete(m.objects, objectName)
+	m.mu.Unlock()
+
+	return nil
+}
+
+func (m *Memory) PublicURL(objectName string) string {
+	return m.baseURL + "/" + objectName
+}
+
+// PresignedPutURL returns a fake URL, nothing is listening on it.
+func (m *Memory) PresignedPutURL(ctx context.Context, objectName string, size int64, contentType string) (string, time.Time, error) {
+	query := url.Values{"op": {"put"}, "size": {strconv.FormatInt(size, 10)}, "type": {contentType}}
+	return m.PublicURL(objectName) + "?" + query.Encode(), time.Now().Add(15 * time.Minute), nil
+}
+
+// PresignedGetURL returns a fake URL, nothing is listening on it.
+func (m *Memory) PresignedGetURL(ctx context.Context, objectName string) (string, time.Time, error) {
+	return m.PublicURL(objectName) + "?" + url.Values{"op": {"get"}}.Encode(), time.Now().Add(15 * time.Minute), nil
+}
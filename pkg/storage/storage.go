@@ -0,0 +1,104 @@
+// Package storage abstracts the object store uploaded files are kept in.
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/Avazbek-02/Online-Hotel-System/pkg/imageproc"
+)
+
+var (
+	// ErrNotFound is returned when an object does not exist.
+	ErrNotFound = errors.New("object not found")
+	// ErrNotSupported is returned by backends that can't provide a feature, e.g. presigned URLs on the local filesystem.
+	ErrNotSupported = errors.New("not supported by this storage backend")
+)
+
+// IncomingPrefix is where presigned uploads land until they are imported. Backends may expire
+// what is left there, e.g. uploads that were never imported.
+const IncomingPrefix = "incoming/"
+
+// ContentType lists the image formats we accept, by file extension.
+var ContentType = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".gif":  "image/gif",
+	".bmp":  "image/bmp",
+	".webp": "image/webp",
+	".tiff": "image/tiff",
+}
+
+// Storage -.
+type Storage interface {
+	// Upload stores an object and returns its public URL. An empty contentType is taken from the extension.
+	Upload(ctx context.Context, objectName string, reader io.Reader, size int64, contentType string) (string, error)
+	// Download opens an object for reading. The caller must close it.
+	Download(ctx context.Context, objectName string) (io.ReadCloser, error)
+	// Delete removes an object. Deleting a missing object is not an error.
+	Delete(ctx context.Context, objectName string) error
+	// PublicURL returns the URL an object is served from.
+	PublicURL(objectName string) string
+	// PresignedPutURL returns a temporary URL a client can upload an object to directly. The upload
+	// must send exactly size bytes with the given Content-Length and Content-Type headers.
+	PresignedPutURL(ctx context.Context, objectName string, size int64, contentType string) (string, time.Time, error)
+	// PresignedGetURL returns a temporary URL to download a private object.
+	PresignedGetURL(ctx context.Context, objectName string) (string, time.Time, error)
+}
+
+// ImageObject is one stored variant of an uploaded image.
+type ImageObject struct {
+	Variant    string
+	ObjectName string
+	URL        string
+	Width      int
+	Height     int
+}
+
+// UploadImage stores the variants rendered by imageproc.Process as prefix/<variant>.jpg.
+// If one of them fails, the ones already stored are removed again.
+func UploadImage(ctx context.Context, s Storage, prefix string, images []imageproc.Image) ([]ImageObject, error) {
+	objects := make([]ImageObject, 0, len(images))
+	for _, img := range images {
+		objectName := prefix + "/" + img.Variant + imageproc.Extension
+
+		url, err := s.Upload(ctx, objectName, bytes.NewReader(img.Data), int64(len(img.Data)), imageproc.ContentType)
+		if err != nil {
+			for _, object := range objects {
+				s.Delete(ctx, object.ObjectName)
+			}
+			return nil, err
+		}
+
+		objects = append(objects, ImageObject{
+			Variant:    img.Variant,
+			ObjectName: objectName,
+			URL:        url,
+			Width:      img.Width,
+			Height:     img.Height,
+		})
+	}
+
+	return objects, nil
+}
+
+func contentTypeOf(objectName, contentType string) string {
+	if contentType != "" {
+		return contentType
+	}
+
+	for i := len(objectName) - 1; i >= 0 && objectName[i] != '/'; i-- {
+		if objectName[i] == '.' {
+			if ct, ok := ContentType[objectName[i:]]; ok {
+				return ct
+			}
+			break
+		}
+	}
+
+	return "application/octet-stream"
+}